@@ -17,7 +17,7 @@ const docTemplate = `{
     "paths": {
         "/api/shorten": {
             "post": {
-                "description": "Принимает оригинальный URL в формате JSON и возвращает короткую ссылку.",
+                "description": "Принимает оригинальный URL в формате JSON и возвращает короткую ссылку.\nНеобязательный redirect_type (301, 302, 307, 308) задаёт код перенаправления ссылки,\nquery_mode (drop, merge, override) и forward_path — перенос строки запроса и пути при переходе.",
                 "consumes": [
                     "application/json"
                 ],
@@ -37,6 +37,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/dto.ShortenRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ключ идемпотентности: повтор с тем же ключом вернёт исходный ответ",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -47,15 +53,27 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "invalid request",
+                        "description": "invalid request, invalid url, invalid redirect_type или invalid query_mode",
                         "schema": {
                             "type": "string"
                         }
                     },
+                    "403": {
+                        "description": "Превышена квота ссылок",
+                        "schema": {
+                            "$ref": "#/definitions/dto.QuotaErrorResponse"
+                        }
+                    },
                     "409": {
-                        "description": "Ссылка уже существует",
+                        "description": "Запрос с этим ключом ещё выполняется",
                         "schema": {
-                            "$ref": "#/definitions/dto.ShortenResponse"
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Ключ уже использован с другим запросом",
+                        "schema": {
+                            "type": "string"
                         }
                     },
                     "500": {
@@ -69,7 +87,7 @@ const docTemplate = `{
         },
         "/api/shorten/batch": {
             "post": {
-                "description": "Принимает массив исходных URL и возвращает массив сокращённых ссылок",
+                "description": "Принимает массив исходных URL и возвращает массив сокращённых ссылок.\nУ каждого элемента могут быть свои redirect_type (301, 302, 307, 308), query_mode и forward_path.\nПустой пакет, повтор correlation_id, некорректный URL или настройки перехода отклоняют весь пакет с 400.\nС параметром partial=true некорректные элементы не отклоняют пакет: ответ 207 содержит\nитог каждого элемента — status (created, existing, invalid, failed) и код ошибки error.",
                 "consumes": [
                     "application/json"
                 ],
@@ -92,6 +110,18 @@ const docTemplate = `{
                                 "$ref": "#/definitions/dto.BatchRequest"
                             }
                         }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Режим частичного успеха",
+                        "name": "partial",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ключ идемпотентности: повтор с тем же ключом вернёт исходный ответ",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -104,12 +134,39 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "207": {
+                        "description": "Итог по каждому элементу (partial=true)",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.BatchResponse"
+                            }
+                        }
+                    },
                     "400": {
                         "description": "Некорректный запрос",
                         "schema": {
                             "type": "string"
                         }
                     },
+                    "403": {
+                        "description": "Превышена квота ссылок",
+                        "schema": {
+                            "$ref": "#/definitions/dto.QuotaErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Запрос с этим ключом ещё выполняется",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Ключ уже использован с другим запросом",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Внутренняя ошибка",
                         "schema": {
@@ -119,6 +176,202 @@ const docTemplate = `{
                 }
             }
         },
+        "/api/shorten/import": {
+            "post": {
+                "description": "Принимает поток NDJSON (по объекту на строку) или CSV (Content-Type: text/csv,\nколонки original_url, alias, expires_at; строка заголовка необязательна).\nТело можно сжать gzip. Строки сохраняются порциями, ответ — поток NDJSON\nс итогом каждой строки: status (created, existing, invalid, conflict, failed) и код ошибки error.\nПри превышении квоты или ошибке хранилища текущая порция получает статус failed, и импорт прекращается.\nЕсли тело не удалось дочитать, поток завершается строкой со статусом failed и ошибкой read_failed.",
+                "consumes": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "urls"
+                ],
+                "summary": "Импортировать ссылки потоком",
+                "parameters": [
+                    {
+                        "description": "Строки импорта (NDJSON или CSV)",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ImportItem"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Поток NDJSON, по объекту на строку импорта",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.ImportResult"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/jobs/{id}": {
+            "get": {
+                "description": "Возвращает состояние задачи удаления текущего пользователя: pending, done или partially_failed.\nДля выполненной задачи приводится итог по каждой ссылке: deleted, not_owned, not_found или failed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "urls"
+                ],
+                "summary": "Статус задачи удаления",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор задачи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Состояние задачи",
+                        "schema": {
+                            "$ref": "#/definitions/dto.DeleteJobStatus"
+                        }
+                    },
+                    "404": {
+                        "description": "job not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/keys": {
+            "get": {
+                "description": "Возвращает метаданные активных ключей текущего пользователя без самих ключей.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "keys"
+                ],
+                "summary": "Список API-ключей пользователя",
+                "responses": {
+                    "200": {
+                        "description": "Список ключей",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.APIKey"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Выпускает именованный ключ для программного доступа через заголовок X-API-Key. Ключ возвращается только в этом ответе.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "keys"
+                ],
+                "summary": "Создать персональный API-ключ",
+                "parameters": [
+                    {
+                        "description": "Название ключа",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateAPIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Ключ создан",
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateAPIKeyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/keys/{id}": {
+            "delete": {
+                "description": "Отзывает ключ текущего пользователя. Запросы с этим ключом сразу начинают получать 401.",
+                "tags": [
+                    "keys"
+                ],
+                "summary": "Отозвать API-ключ",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор ключа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Ключ отозван",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "key not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
         "/api/user/urls": {
             "get": {
                 "description": "Возвращает список всех сокращённых ссылок, принадлежащих текущему пользователю",
@@ -154,7 +407,7 @@ const docTemplate = `{
                 }
             },
             "delete": {
-                "description": "Принимает список коротких ссылок пользователя и отправляет их на асинхронное удаление",
+                "description": "Принимает список коротких ссылок пользователя и отправляет их на асинхронное удаление.\nЗапрос сохраняется в журнале до ответа 202 и будет выполнен даже после перезапуска сервиса.\nВ ответе — задача удаления; заголовок Location указывает, где узнать её итог.\nЕсли очередь удаления заполнена, отвечает 503 с заголовком Retry-After.",
                 "consumes": [
                     "application/json"
                 ],
@@ -183,7 +436,13 @@ const docTemplate = `{
                     "202": {
                         "description": "Запрос принят на обработку",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/dto.DeleteJobStatus"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "Адрес статуса задачи удаления"
+                            }
                         }
                     },
                     "400": {
@@ -191,6 +450,140 @@ const docTemplate = `{
                         "schema": {
                             "type": "string"
                         }
+                    },
+                    "500": {
+                        "description": "Не удалось сохранить запрос в журнал",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "Очередь удаления заполнена, повторите позже",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/urls/export": {
+            "get": {
+                "description": "Потоком отдаёт все ссылки текущего пользователя, включая удалённые, с датой создания,\nпризнаком удаления, сроком жизни и числом переходов. Формат: json (массив, по умолчанию),\nndjson (по объекту на строку) или csv (short_url, original_url, created_at, is_deleted, expires_at, clicks,\nredirect_type, query_mode, forward_path; нулевой redirect_type — пустая ячейка).",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "urls"
+                ],
+                "summary": "Выгрузить все ссылки пользователя",
+                "parameters": [
+                    {
+                        "enum": [
+                            "json",
+                            "ndjson",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "Формат выгрузки",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.LinkExport"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неизвестный формат",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/urls/{id}/options": {
+            "put": {
+                "description": "Заменяет настройки перехода по активной ссылке текущего пользователя.\nНезаданные поля возвращаются к умолчаниям сервера.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "urls"
+                ],
+                "summary": "Изменить настройки перехода по ссылке",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Короткий идентификатор ссылки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новые настройки",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.LinkOptions"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Настройки сохранены"
+                    },
+                    "400": {
+                        "description": "invalid request, invalid redirect_type или invalid query_mode",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "link not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Отвечает 200, пока процесс способен обрабатывать HTTP-запросы. Зависимости не проверяются.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Проверка живости процесса",
+                "responses": {
+                    "200": {
+                        "description": "Процесс жив",
+                        "schema": {
+                            "$ref": "#/definitions/dto.HealthReport"
+                        }
                     }
                 }
             }
@@ -221,9 +614,35 @@ const docTemplate = `{
                 }
             }
         },
+        "/readyz": {
+            "get": {
+                "description": "Проверяет активное хранилище (ping БД или доступность файла на запись) и воркеры. Возвращает состояние каждого компонента.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Проверка готовности к приёму трафика",
+                "responses": {
+                    "200": {
+                        "description": "Все компоненты готовы",
+                        "schema": {
+                            "$ref": "#/definitions/dto.HealthReport"
+                        }
+                    },
+                    "503": {
+                        "description": "Хотя бы один компонент не готов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.HealthReport"
+                        }
+                    }
+                }
+            }
+        },
         "/{id}": {
             "get": {
-                "description": "Получает оригинальный URL по его короткому идентификатору и делает перенаправление.",
+                "description": "Получает оригинальный URL по его короткому идентификатору и делает перенаправление\nс кодом ссылки (redirect_type) или кодом сервера по умолчанию.\nПостоянные перенаправления (301, 308) отдаются с Cache-Control: public, max-age,\nне дольше срока жизни ссылки.\nПо настройкам ссылки строка запроса (query_mode) и путь после кода (forward_path, маршрут /{id}/*)\nпереносятся в адрес перенаправления; путь у ссылки без forward_path даёт 404.",
                 "tags": [
                     "redirect"
                 ],
@@ -238,12 +657,36 @@ const docTemplate = `{
                     }
                 ],
                 "responses": {
+                    "301": {
+                        "description": "Moved Permanently",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "302": {
+                        "description": "Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "307": {
                         "description": "Temporary Redirect",
                         "schema": {
                             "type": "string"
                         }
                     },
+                    "308": {
+                        "description": "Permanent Redirect",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid redirect path",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "404": {
                         "description": "URL not found",
                         "schema": {
@@ -261,14 +704,46 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "dto.APIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                }
+            }
+        },
         "dto.BatchRequest": {
             "type": "object",
             "properties": {
                 "correlation_id": {
                     "type": "string"
                 },
+                "forward_path": {
+                    "description": "ForwardPath переносит путь после кода ссылки: /{id}/docs/x ведёт на \u003cадрес\u003e/docs/x.",
+                    "type": "boolean"
+                },
                 "original_url": {
                     "type": "string"
+                },
+                "query_mode": {
+                    "description": "QueryMode — что делать со строкой запроса короткой ссылки: drop отбрасывает её,\nmerge добавляет параметры к адресу, оставляя значения адреса при совпадении имён,\noverride добавляет параметры, заменяя совпадающие.",
+                    "type": "string"
+                },
+                "redirect_type": {
+                    "description": "RedirectType — HTTP-код перенаправления: 301, 302, 307 или 308.",
+                    "type": "integer"
                 }
             }
         },
@@ -278,14 +753,243 @@ const docTemplate = `{
                 "correlation_id": {
                     "type": "string"
                 },
+                "error": {
+                    "type": "string"
+                },
                 "short_url": {
                     "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ComponentHealth": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CreateAPIKeyRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CreateAPIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.DeleteJobStatus": {
+            "type": "object",
+            "properties": {
+                "completed_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.DeleteResult"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.DeleteResult": {
+            "type": "object",
+            "properties": {
+                "short_url": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.HealthReport": {
+            "type": "object",
+            "properties": {
+                "components": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/dto.ComponentHealth"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ImportItem": {
+            "type": "object",
+            "properties": {
+                "alias": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "forward_path": {
+                    "description": "ForwardPath переносит путь после кода ссылки: /{id}/docs/x ведёт на \u003cадрес\u003e/docs/x.",
+                    "type": "boolean"
+                },
+                "original_url": {
+                    "type": "string"
+                },
+                "query_mode": {
+                    "description": "QueryMode — что делать со строкой запроса короткой ссылки: drop отбрасывает её,\nmerge добавляет параметры к адресу, оставляя значения адреса при совпадении имён,\noverride добавляет параметры, заменяя совпадающие.",
+                    "type": "string"
+                },
+                "redirect_type": {
+                    "description": "RedirectType — HTTP-код перенаправления: 301, 302, 307 или 308.",
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.ImportResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "line": {
+                    "type": "integer"
+                },
+                "original_url": {
+                    "type": "string"
+                },
+                "short_url": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.LinkExport": {
+            "type": "object",
+            "properties": {
+                "clicks": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "forward_path": {
+                    "description": "ForwardPath переносит путь после кода ссылки: /{id}/docs/x ведёт на \u003cадрес\u003e/docs/x.",
+                    "type": "boolean"
+                },
+                "is_deleted": {
+                    "type": "boolean"
+                },
+                "original_url": {
+                    "type": "string"
+                },
+                "query_mode": {
+                    "description": "QueryMode — что делать со строкой запроса короткой ссылки: drop отбрасывает её,\nmerge добавляет параметры к адресу, оставляя значения адреса при совпадении имён,\noverride добавляет параметры, заменяя совпадающие.",
+                    "type": "string"
+                },
+                "redirect_type": {
+                    "description": "RedirectType — HTTP-код перенаправления: 301, 302, 307 или 308.",
+                    "type": "integer"
+                },
+                "short_url": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.LinkOptions": {
+            "type": "object",
+            "properties": {
+                "forward_path": {
+                    "description": "ForwardPath переносит путь после кода ссылки: /{id}/docs/x ведёт на \u003cадрес\u003e/docs/x.",
+                    "type": "boolean"
+                },
+                "query_mode": {
+                    "description": "QueryMode — что делать со строкой запроса короткой ссылки: drop отбрасывает её,\nmerge добавляет параметры к адресу, оставляя значения адреса при совпадении имён,\noverride добавляет параметры, заменяя совпадающие.",
+                    "type": "string"
+                },
+                "redirect_type": {
+                    "description": "RedirectType — HTTP-код перенаправления: 301, 302, 307 или 308.",
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.QuotaErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "requested": {
+                    "type": "integer"
+                },
+                "tier": {
+                    "type": "string"
+                },
+                "used": {
+                    "type": "integer"
                 }
             }
         },
         "dto.ShortenRequest": {
             "type": "object",
             "properties": {
+                "forward_path": {
+                    "description": "ForwardPath переносит путь после кода ссылки: /{id}/docs/x ведёт на \u003cадрес\u003e/docs/x.",
+                    "type": "boolean"
+                },
+                "query_mode": {
+                    "description": "QueryMode — что делать со строкой запроса короткой ссылки: drop отбрасывает её,\nmerge добавляет параметры к адресу, оставляя значения адреса при совпадении имён,\noverride добавляет параметры, заменяя совпадающие.",
+                    "type": "string"
+                },
+                "redirect_type": {
+                    "description": "RedirectType — HTTP-код перенаправления: 301, 302, 307 или 308.",
+                    "type": "integer"
+                },
                 "url": {
                     "type": "string"
                 }
@@ -30,6 +30,31 @@ import (
 // @host            localhost:8080
 // @BasePath        /
 func main() {
+	// shortener migrate up|down|status — управление схемой без запуска сервера
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := initapp.RunMigrateCommand(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Ошибка миграции: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// shortener backup|restore — резервная копия в формате, не зависящем от хранилища
+	if len(os.Args) > 1 && os.Args[1] == "backup" {
+		if err := initapp.RunBackupCommand(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Ошибка резервного копирования: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "restore" {
+		if err := initapp.RunRestoreCommand(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Ошибка восстановления: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if err := initapp.InitializeApp(); err != nil {
 		fmt.Fprintf(os.Stderr, "Ошибка старта приложения: %v\n", err)
 		os.Exit(1)
@@ -0,0 +1,307 @@
+// Package backup пишет и читает резервные копии сервиса в формате, не зависящем от хранилища.
+//
+// Копия — gzip-сжатый JSONL. Первая строка — заголовок с форматом и версией, затем
+// по строке на ссылку и на API-ключ, последняя строка — итог с числом записей и
+// SHA-256 всех строк данных. Без итоговой строки копия считается обрезанной.
+package backup
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/migrate"
+)
+
+const (
+	// Format — значение поля format в заголовке копии.
+	Format = "shortener-backup"
+	// Version — версия формата, которую пишет Write. Read читает версии не новее этой.
+	Version = 1
+
+	restoreBatchSize = 500
+	maxLineSize      = 16 << 20
+)
+
+var (
+	// ErrNotEmpty — восстанавливать можно только в пустое хранилище.
+	ErrNotEmpty = errors.New("target store is not empty")
+	// ErrCorrupt — копия повреждена, обрезана или не является копией сервиса.
+	ErrCorrupt = errors.New("backup is corrupt")
+	// ErrUnsupportedVersion — копия записана более новой версией формата.
+	ErrUnsupportedVersion = errors.New("unsupported backup version")
+)
+
+const (
+	recordHeader = "header"
+	recordLink   = "link"
+	recordAPIKey = "api_key"
+	recordEnd    = "end"
+)
+
+// record — строка копии.
+type record struct {
+	Type string `json:"type"`
+
+	// header
+	Format    string     `json:"format,omitempty"`
+	Version   int        `json:"version,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+
+	Link   *dto.StoredLink `json:"link,omitempty"`
+	APIKey *apiKeyRecord   `json:"api_key,omitempty"`
+
+	// end
+	Links    int    `json:"links,omitempty"`
+	APIKeys  int    `json:"api_keys,omitempty"`
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// apiKeyRecord — API-ключ в копии. dto.APIKey скрывает хеш и владельца из JSON.
+type apiKeyRecord struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Hash       string     `json:"hash"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// Stats — сколько записей попало в копию или восстановлено из неё.
+type Stats struct {
+	Links   int
+	APIKeys int
+}
+
+// Snapshotter — хранилище, которое умеет выполнить чтение над согласованным срезом данных
+// (PostgreSQL, открытый migrate.OpenStore).
+type Snapshotter interface {
+	ReadOnlySnapshot(ctx context.Context, fn func(migrate.Store) error) error
+}
+
+// Write пишет копию src в w. Если src — Snapshotter, копия снимается со среза,
+// поэтому согласована на момент начала даже при работающем сервисе.
+func Write(ctx context.Context, w io.Writer, src migrate.Store) (Stats, error) {
+	if db, ok := src.(Snapshotter); ok {
+		var stats Stats
+		err := db.ReadOnlySnapshot(ctx, func(snapshot migrate.Store) error {
+			var err error
+			stats, err = write(ctx, w, snapshot)
+			return err
+		})
+		return stats, err
+	}
+	return write(ctx, w, src)
+}
+
+func write(ctx context.Context, w io.Writer, src migrate.Store) (Stats, error) {
+	gz := gzip.NewWriter(w)
+	buf := bufio.NewWriter(gz)
+	sum := sha256.New()
+	var stats Stats
+
+	now := time.Now().UTC()
+	if err := writeRecord(buf, nil, record{Type: recordHeader, Format: Format, Version: Version, CreatedAt: &now}); err != nil {
+		return stats, err
+	}
+
+	err := src.IterateLinks(ctx, "", func(link dto.StoredLink) error {
+		stats.Links++
+		return writeRecord(buf, sum, record{Type: recordLink, Link: &link})
+	})
+	if err != nil {
+		return stats, err
+	}
+	err = src.IterateAPIKeys(ctx, func(key dto.APIKey) error {
+		stats.APIKeys++
+		rec := apiKeyRecord(key)
+		return writeRecord(buf, sum, record{Type: recordAPIKey, APIKey: &rec})
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	end := record{Type: recordEnd, Links: stats.Links, APIKeys: stats.APIKeys, Checksum: hex.EncodeToString(sum.Sum(nil))}
+	if err := writeRecord(buf, nil, end); err != nil {
+		return stats, err
+	}
+	if err := buf.Flush(); err != nil {
+		return stats, err
+	}
+	return stats, gz.Close()
+}
+
+// writeRecord пишет строку копии; строки данных учитываются в контрольной сумме.
+func writeRecord(w io.Writer, sum hash.Hash, rec record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if sum != nil {
+		sum.Write(data)
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// Restore загружает копию из r в пустое хранилище dst. Ссылки пишутся пачками;
+// если копия окажется обрезанной или повреждённой, уже записанные данные остаются
+// в dst, а вызывающий получает ErrCorrupt.
+func Restore(ctx context.Context, r io.Reader, dst migrate.Store) (Stats, error) {
+	var stats Stats
+
+	empty, err := isEmpty(ctx, dst)
+	if err != nil {
+		return stats, err
+	}
+	if !empty {
+		return stats, ErrNotEmpty
+	}
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return stats, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	defer gz.Close()
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	header, err := nextRecord(scanner)
+	if err != nil {
+		return stats, err
+	}
+	if header.Type != recordHeader || header.Format != Format {
+		return stats, fmt.Errorf("%w: missing header", ErrCorrupt)
+	}
+	if header.Version > Version {
+		return stats, fmt.Errorf("%w: %d", ErrUnsupportedVersion, header.Version)
+	}
+
+	sum := sha256.New()
+	batch := make([]dto.StoredLink, 0, restoreBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if _, err := dst.ImportLinks(ctx, batch); err != nil {
+			return err
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+		line, rec, err := nextLine(scanner)
+		if err != nil {
+			return stats, err
+		}
+
+		switch rec.Type {
+		case recordLink:
+			if rec.Link == nil {
+				return stats, fmt.Errorf("%w: empty link record", ErrCorrupt)
+			}
+			sum.Write(line)
+			batch = append(batch, *rec.Link)
+			stats.Links++
+			if len(batch) == restoreBatchSize {
+				if err := flush(); err != nil {
+					return stats, err
+				}
+			}
+		case recordAPIKey:
+			if rec.APIKey == nil {
+				return stats, fmt.Errorf("%w: empty api key record", ErrCorrupt)
+			}
+			sum.Write(line)
+			if err := restoreAPIKey(ctx, dst, dto.APIKey(*rec.APIKey)); err != nil {
+				return stats, err
+			}
+			stats.APIKeys++
+		case recordEnd:
+			if err := flush(); err != nil {
+				return stats, err
+			}
+			if rec.Links != stats.Links || rec.APIKeys != stats.APIKeys || rec.Checksum != hex.EncodeToString(sum.Sum(nil)) {
+				return stats, fmt.Errorf("%w: checksum mismatch", ErrCorrupt)
+			}
+			return stats, nil
+		default:
+			return stats, fmt.Errorf("%w: unknown record %q", ErrCorrupt, rec.Type)
+		}
+	}
+}
+
+// errStop прерывает обход, когда ответ уже известен.
+var errStop = errors.New("stop")
+
+// isEmpty сообщает, что в хранилище нет ни ссылок, ни API-ключей: ключи из копии
+// иначе смешались бы с уже выданными.
+func isEmpty(ctx context.Context, store migrate.Store) (bool, error) {
+	empty := true
+	err := store.IterateLinks(ctx, "", func(dto.StoredLink) error {
+		empty = false
+		return errStop
+	})
+	if err == nil && empty {
+		err = store.IterateAPIKeys(ctx, func(dto.APIKey) error {
+			empty = false
+			return errStop
+		})
+	}
+	if err != nil && !errors.Is(err, errStop) {
+		return false, err
+	}
+	return empty, nil
+}
+
+// restoreAPIKey сохраняет ключ вместе с временем последнего использования.
+func restoreAPIKey(ctx context.Context, dst migrate.Store, key dto.APIKey) error {
+	if err := dst.SaveAPIKey(ctx, key); err != nil {
+		return err
+	}
+	if key.LastUsedAt == nil {
+		return nil
+	}
+	if toucher, ok := dst.(interface {
+		TouchAPIKey(ctx context.Context, keyID string, usedAt time.Time) error
+	}); ok {
+		return toucher.TouchAPIKey(ctx, key.ID, *key.LastUsedAt)
+	}
+	return nil
+}
+
+func nextRecord(scanner *bufio.Scanner) (record, error) {
+	_, rec, err := nextLine(scanner)
+	return rec, err
+}
+
+// nextLine читает строку копии вместе с её исходными байтами (для контрольной суммы).
+func nextLine(scanner *bufio.Scanner) ([]byte, record, error) {
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, record{}, fmt.Errorf("%w: %v", ErrCorrupt, err)
+		}
+		return nil, record{}, fmt.Errorf("%w: unexpected end of backup", ErrCorrupt)
+	}
+	line := append(scanner.Bytes(), '\n')
+	var rec record
+	if err := json.Unmarshal(line, &rec); err != nil {
+		return nil, record{}, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	return line, rec, nil
+}
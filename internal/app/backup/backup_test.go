@@ -0,0 +1,141 @@
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/migrate"
+	"github.com/DaniYer/GoProject.git/internal/app/storage/file"
+	"github.com/DaniYer/GoProject.git/internal/app/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFilledStore(t *testing.T) *file.FileStore {
+	t.Helper()
+	ctx := context.Background()
+	store, err := file.NewFileStore(filepath.Join(t.TempDir(), "storage.json"))
+	require.NoError(t, err)
+
+	expiresAt := time.Now().Add(time.Hour)
+	_, err = store.SaveBatch(ctx, "alice", []dto.BatchSaveItem{
+		{ShortURL: "a1", OriginalURL: "https://example.com/1"},
+		{ShortURL: "a2", OriginalURL: "https://example.com/2", ExpiresAt: &expiresAt},
+	})
+	require.NoError(t, err)
+	_, err = store.BatchDelete(ctx, "alice", []string{"a2"})
+	require.NoError(t, err)
+	require.NoError(t, store.AddClicks(ctx, map[string]int64{"a1": 2}))
+
+	usedAt := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, store.SaveAPIKey(ctx, dto.APIKey{
+		ID: "7f1d9d3c-3b9a-4c55-9d7e-0c4f7c1a2b3c", UserID: "alice", Name: "ci",
+		Prefix: "sk_abc", Hash: "hash", CreatedAt: time.Now().UTC(),
+	}))
+	require.NoError(t, store.TouchAPIKey(ctx, "7f1d9d3c-3b9a-4c55-9d7e-0c4f7c1a2b3c", usedAt))
+	return store
+}
+
+func TestBackup_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	src := newFilledStore(t)
+	path := filepath.Join(t.TempDir(), "shortener.backup.gz")
+
+	stats, err := WriteFile(ctx, path, src)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Links: 2, APIKeys: 1}, stats)
+
+	dst := memory.NewMemoryStore()
+	stats, found, err := RestoreFile(ctx, path, dst)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, Stats{Links: 2, APIKeys: 1}, stats)
+
+	_, _, err = migrate.Verify(ctx, src, dst, nil)
+	require.NoError(t, err)
+
+	key, err := dst.GetAPIKeyByHash(ctx, "hash")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", key.UserID)
+	assert.NotNil(t, key.LastUsedAt)
+
+	// Повторно в непустое хранилище не восстанавливается
+	_, _, err = RestoreFile(ctx, path, dst)
+	assert.ErrorIs(t, err, ErrNotEmpty)
+}
+
+func TestRestore_RejectsStoreWithAPIKeys(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "shortener.backup.gz")
+	_, err := WriteFile(ctx, path, newFilledStore(t))
+	require.NoError(t, err)
+
+	// Ссылок нет, но ключ уже выдан: хранилище не пустое
+	dst := memory.NewMemoryStore()
+	require.NoError(t, dst.SaveAPIKey(ctx, dto.APIKey{
+		ID: "0b6c2f8e-5d1a-4e7b-9c3d-2a1f0e9d8c7b", UserID: "bob", Name: "local",
+		Prefix: "sk_def", Hash: "other", CreatedAt: time.Now().UTC(),
+	}))
+	_, _, err = RestoreFile(ctx, path, dst)
+	assert.ErrorIs(t, err, ErrNotEmpty)
+}
+
+func TestRestore_RejectsDamagedBackups(t *testing.T) {
+	ctx := context.Background()
+	var full bytes.Buffer
+	_, err := Write(ctx, &full, newFilledStore(t))
+	require.NoError(t, err)
+
+	// Копия без итоговой строки считается обрезанной
+	gz, err := gzip.NewReader(bytes.NewReader(full.Bytes()))
+	require.NoError(t, err)
+	var plain bytes.Buffer
+	_, err = plain.ReadFrom(gz)
+	require.NoError(t, err)
+	lines := bytes.SplitAfter(plain.Bytes(), []byte("\n"))
+	truncated := bytes.Join(lines[:len(lines)-2], nil)
+
+	_, err = Restore(ctx, gzipped(t, truncated), memory.NewMemoryStore())
+	assert.ErrorIs(t, err, ErrCorrupt)
+
+	_, err = Restore(ctx, gzipped(t, []byte(`{"type":"header","format":"shortener-backup","version":99}`+"\n")), memory.NewMemoryStore())
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+
+	_, err = Restore(ctx, bytes.NewReader([]byte("not gzip")), memory.NewMemoryStore())
+	assert.ErrorIs(t, err, ErrCorrupt)
+}
+
+// snapshotStore — хранилище, срез которого — отдельная копия данных.
+type snapshotStore struct {
+	migrate.Store
+	snapshot migrate.Store
+}
+
+func (s snapshotStore) ReadOnlySnapshot(ctx context.Context, fn func(migrate.Store) error) error {
+	return fn(s.snapshot)
+}
+
+func TestWrite_ReadsFromSnapshot(t *testing.T) {
+	ctx := context.Background()
+	src := snapshotStore{Store: memory.NewMemoryStore(), snapshot: newFilledStore(t)}
+
+	var buf bytes.Buffer
+	stats, err := Write(ctx, &buf, src)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Links: 2, APIKeys: 1}, stats)
+}
+
+func gzipped(t *testing.T, data []byte) *bytes.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	return bytes.NewReader(buf.Bytes())
+}
@@ -0,0 +1,47 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/DaniYer/GoProject.git/internal/app/migrate"
+)
+
+// WriteFile пишет копию в path через временный файл, поэтому сбой посреди записи
+// не портит предыдущую копию.
+func WriteFile(ctx context.Context, path string, src migrate.Store) (Stats, error) {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return Stats{}, err
+	}
+	stats, err := Write(ctx, f, src)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return stats, err
+	}
+	return stats, os.Rename(tmp, path)
+}
+
+// RestoreFile загружает копию из path в пустое хранилище. Отсутствие файла не ошибка:
+// возвращается нулевая статистика и false.
+func RestoreFile(ctx context.Context, path string, dst migrate.Store) (Stats, bool, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Stats{}, false, nil
+	}
+	if err != nil {
+		return Stats{}, false, err
+	}
+	defer f.Close()
+
+	stats, err := Restore(ctx, f, dst)
+	return stats, true, err
+}
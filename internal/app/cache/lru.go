@@ -0,0 +1,125 @@
+// Package cache содержит кэш переходов по коротким ссылкам: LRU ограниченного размера
+// и обёртку над service.URLStore, которая читает через него.
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+)
+
+// entry — запись кэша: найденная ссылка или ошибка отрицательного ответа.
+type entry struct {
+	key       string
+	link      dto.ResolvedLink
+	err       error
+	expiresAt time.Time
+}
+
+// lru — потокобезопасный кэш на size записей с вытеснением давно не использованных.
+// Запись живёт до своего expiresAt и при чтении после него считается отсутствующей.
+type lru struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List
+	items map[string]*list.Element
+	// generation растёт при каждой инвалидации: заполнение, начатое до неё,
+	// не должно вернуть в кэш устаревшее значение
+	generation uint64
+	evicted    func()
+}
+
+// newLRU создаёт кэш на size записей. evicted, если задан, вызывается при вытеснении
+// записи из-за нехватки места.
+func newLRU(size int, evicted func()) *lru {
+	return &lru{
+		size:    size,
+		order:   list.New(),
+		items:   make(map[string]*list.Element, size),
+		evicted: evicted,
+	}
+}
+
+// get возвращает запись по ключу, если она есть и не истекла.
+func (c *lru) get(key string, now time.Time) (entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return entry{}, false
+	}
+	e := elem.Value.(*entry)
+	if !now.Before(e.expiresAt) {
+		c.removeElement(elem)
+		return entry{}, false
+	}
+	c.order.MoveToFront(elem)
+	return *e, true
+}
+
+// currentGeneration возвращает номер текущего поколения для последующего add.
+func (c *lru) currentGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// add сохраняет запись, если с момента получения generation не было инвалидаций.
+func (c *lru) add(e entry, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+	if elem, ok := c.items[e.key]; ok {
+		*elem.Value.(*entry) = e
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.items[e.key] = c.order.PushFront(&e)
+	for c.order.Len() > c.size {
+		c.removeElement(c.order.Back())
+		if c.evicted != nil {
+			c.evicted()
+		}
+	}
+}
+
+// remove удаляет записи по ключам.
+func (c *lru) remove(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	for _, key := range keys {
+		if elem, ok := c.items[key]; ok {
+			c.removeElement(elem)
+		}
+	}
+}
+
+// purge очищает кэш целиком.
+func (c *lru) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.order.Init()
+	c.items = make(map[string]*list.Element, c.size)
+}
+
+// len возвращает число записей в кэше, включая ещё не вычищенные истёкшие.
+func (c *lru) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *lru) removeElement(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*entry).key)
+}
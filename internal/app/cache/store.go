@@ -0,0 +1,139 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/metrics"
+	"github.com/DaniYer/GoProject.git/internal/app/service"
+)
+
+// Config — параметры кэша переходов.
+type Config struct {
+	// Size — максимальное число записей.
+	Size int
+	// TTL — сколько живёт найденный адрес.
+	TTL time.Duration
+	// NegativeTTL — сколько живёт ответ "не найдено" или "удалено" для кода.
+	NegativeTTL time.Duration
+}
+
+// Store — обёртка над service.URLStore, которая отвечает на Get и Resolve из LRU-кэша.
+// Запись сбрасывается, когда ссылку меняют или удаляют через эту обёртку,
+// и живёт не дольше самой ссылки. Остальные методы передаются хранилищу как есть.
+type Store struct {
+	service.URLStore
+	cache *lru
+	cfg   Config
+	now   func() time.Time
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// NewStore оборачивает хранилище next кэшем. Запись кэша истекает не позже самой ссылки.
+func NewStore(next service.URLStore, cfg Config) *Store {
+	return &Store{
+		URLStore: next,
+		cache:    newLRU(cfg.Size, metrics.RedirectCacheEvictions.Inc),
+		cfg:      cfg,
+		now:      time.Now,
+	}
+}
+
+// Get возвращает оригинальный URL из кэша, как Resolve.
+func (s *Store) Get(ctx context.Context, shortURL string) (string, error) {
+	link, err := s.Resolve(ctx, shortURL)
+	return link.OriginalURL, err
+}
+
+// Resolve возвращает ссылку из кэша, а при промахе — из хранилища,
+// запоминая и ссылку, и ответ "не найдено"/"удалено". Прочие ошибки не кэшируются.
+func (s *Store) Resolve(ctx context.Context, shortURL string) (dto.ResolvedLink, error) {
+	now := s.now()
+	if e, ok := s.cache.get(shortURL, now); ok {
+		s.hits.Add(1)
+		metrics.RedirectCacheLookups.WithLabelValues(metrics.CacheHit).Inc()
+		return e.link, e.err
+	}
+	s.misses.Add(1)
+	metrics.RedirectCacheLookups.WithLabelValues(metrics.CacheMiss).Inc()
+
+	generation := s.cache.currentGeneration()
+	link, err := s.URLStore.Resolve(ctx, shortURL)
+	switch {
+	case err == nil:
+		deadline := now.Add(s.cfg.TTL)
+		if link.ExpiresAt != nil && link.ExpiresAt.Before(deadline) {
+			deadline = *link.ExpiresAt
+		}
+		s.cache.add(entry{key: shortURL, link: link, expiresAt: deadline}, generation)
+	case isNegative(err):
+		s.cache.add(entry{key: shortURL, err: err, expiresAt: now.Add(s.cfg.NegativeTTL)}, generation)
+	}
+	return link, err
+}
+
+// isNegative сообщает, что ошибка хранилища означает отсутствие ссылки, а не сбой.
+func isNegative(err error) bool {
+	msg := err.Error()
+	return msg == "not found" || msg == "gone"
+}
+
+// Save сбрасывает запись кода: он мог быть закэширован как несуществующий.
+func (s *Store) Save(ctx context.Context, shortURL, originalURL, userID string) (string, error) {
+	result, err := s.URLStore.Save(ctx, shortURL, originalURL, userID)
+	s.cache.remove(shortURL, result)
+	return result, err
+}
+
+// SaveBatch сбрасывает записи всех кодов пакета.
+func (s *Store) SaveBatch(ctx context.Context, userID string, items []dto.BatchSaveItem) ([]dto.BatchSaveItem, error) {
+	result, err := s.URLStore.SaveBatch(ctx, userID, items)
+	codes := make([]string, 0, len(items))
+	for _, item := range items {
+		codes = append(codes, item.ShortURL)
+	}
+	s.cache.remove(codes...)
+	return result, err
+}
+
+// BatchDelete сбрасывает записи удаляемых кодов.
+func (s *Store) BatchDelete(ctx context.Context, userID string, shortURLs []string) ([]dto.DeleteResult, error) {
+	result, err := s.URLStore.BatchDelete(ctx, userID, shortURLs)
+	s.cache.remove(shortURLs...)
+	return result, err
+}
+
+// UpdateLinkOptions сбрасывает запись ссылки: в ней хранятся и настройки перехода.
+func (s *Store) UpdateLinkOptions(ctx context.Context, userID, shortURL string, opts dto.LinkOptions) error {
+	err := s.URLStore.UpdateLinkOptions(ctx, userID, shortURL, opts)
+	s.cache.remove(shortURL)
+	return err
+}
+
+// Invalidate сбрасывает записи кодов, изменённых в обход этой обёртки.
+func (s *Store) Invalidate(shortURLs ...string) {
+	s.cache.remove(shortURLs...)
+}
+
+// Purge очищает кэш целиком.
+func (s *Store) Purge() {
+	s.cache.purge()
+}
+
+// Len возвращает текущее число записей в кэше.
+func (s *Store) Len() int {
+	return s.cache.len()
+}
+
+// HitRatio возвращает долю обращений, обслуженных из кэша, с момента создания.
+func (s *Store) HitRatio() float64 {
+	hits, misses := s.hits.Load(), s.misses.Load()
+	if hits+misses == 0 {
+		return 0
+	}
+	return float64(hits) / float64(hits+misses)
+}
@@ -0,0 +1,169 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingStore считает обращения к хранилищу за адресом ссылки.
+type countingStore struct {
+	*memory.MemoryStore
+	gets int
+	// beforeGet, если задан, вызывается перед чтением из хранилища
+	beforeGet func()
+}
+
+func (c *countingStore) Resolve(ctx context.Context, shortURL string) (dto.ResolvedLink, error) {
+	c.gets++
+	if c.beforeGet != nil {
+		c.beforeGet()
+	}
+	return c.MemoryStore.Resolve(ctx, shortURL)
+}
+
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func newTestStore(t *testing.T, size int) (*Store, *countingStore, *fakeClock) {
+	t.Helper()
+	next := &countingStore{MemoryStore: memory.NewMemoryStore()}
+	clock := &fakeClock{now: time.Now()}
+	store := NewStore(next, Config{Size: size, TTL: time.Minute, NegativeTTL: 10 * time.Second})
+	store.now = clock.Now
+	return store, next, clock
+}
+
+func TestStore_ReadThrough(t *testing.T) {
+	ctx := context.Background()
+	store, next, clock := newTestStore(t, 10)
+	_, err := store.Save(ctx, "abc", "https://example.com", "alice")
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		original, err := store.Get(ctx, "abc")
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com", original)
+	}
+	assert.Equal(t, 1, next.gets)
+	assert.InDelta(t, 2.0/3.0, store.HitRatio(), 0.001)
+
+	// По истечении TTL адрес перечитывается
+	clock.now = clock.now.Add(2 * time.Minute)
+	_, err = store.Get(ctx, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, 2, next.gets)
+}
+
+func TestStore_NegativeCaching(t *testing.T) {
+	ctx := context.Background()
+	store, next, clock := newTestStore(t, 10)
+
+	for i := 0; i < 2; i++ {
+		_, err := store.Get(ctx, "missing")
+		assert.EqualError(t, err, "not found")
+	}
+	assert.Equal(t, 1, next.gets)
+
+	clock.now = clock.now.Add(11 * time.Second)
+	_, err := store.Get(ctx, "missing")
+	assert.EqualError(t, err, "not found")
+	assert.Equal(t, 2, next.gets)
+
+	// Создание ссылки с этим кодом сбрасывает отрицательный ответ
+	_, err = store.SaveBatch(ctx, "alice", []dto.BatchSaveItem{{ShortURL: "missing", OriginalURL: "https://example.com/new"}})
+	require.NoError(t, err)
+	original, err := store.Get(ctx, "missing")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/new", original)
+}
+
+func TestStore_InvalidatesOnDelete(t *testing.T) {
+	ctx := context.Background()
+	store, _, _ := newTestStore(t, 10)
+	_, err := store.Save(ctx, "abc", "https://example.com", "alice")
+	require.NoError(t, err)
+	_, err = store.Get(ctx, "abc")
+	require.NoError(t, err)
+
+	_, err = store.BatchDelete(ctx, "alice", []string{"abc"})
+	require.NoError(t, err)
+
+	_, err = store.Get(ctx, "abc")
+	assert.EqualError(t, err, "gone")
+}
+
+func TestStore_EntryExpiresWithLink(t *testing.T) {
+	ctx := context.Background()
+	store, _, clock := newTestStore(t, 10)
+	expiresAt := clock.now.Add(5 * time.Second)
+	_, err := store.SaveBatch(ctx, "alice", []dto.BatchSaveItem{
+		{ShortURL: "tmp", OriginalURL: "https://example.com/tmp", ExpiresAt: &expiresAt},
+	})
+	require.NoError(t, err)
+
+	_, err = store.Get(ctx, "tmp")
+	require.NoError(t, err)
+	assert.Equal(t, 1, store.Len())
+
+	// Запись кэша не переживает саму ссылку, хотя TTL кэша ещё не истёк
+	clock.now = expiresAt
+	_, ok := store.cache.get("tmp", clock.now)
+	assert.False(t, ok)
+}
+
+func TestStore_EvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	store, next, _ := newTestStore(t, 2)
+	for _, code := range []string{"a", "b", "c"} {
+		_, err := store.Save(ctx, code, "https://example.com/"+code, "alice")
+		require.NoError(t, err)
+	}
+
+	_, _ = store.Get(ctx, "a")
+	_, _ = store.Get(ctx, "b")
+	_, _ = store.Get(ctx, "a")
+	_, _ = store.Get(ctx, "c") // вытесняет b
+	assert.Equal(t, 2, store.Len())
+
+	gets := next.gets
+	_, _ = store.Get(ctx, "a")
+	assert.Equal(t, gets, next.gets)
+	_, _ = store.Get(ctx, "b")
+	assert.Equal(t, gets+1, next.gets)
+}
+
+func TestStore_InvalidationDuringFillWins(t *testing.T) {
+	ctx := context.Background()
+	store, next, _ := newTestStore(t, 10)
+	_, err := store.Save(ctx, "abc", "https://example.com", "alice")
+	require.NoError(t, err)
+
+	// Ссылку удалили, пока шло чтение: прочитанный адрес не должен попасть в кэш
+	next.beforeGet = func() { store.Invalidate("abc") }
+	_, err = store.Get(ctx, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, 0, store.Len())
+}
+
+func TestStore_InvalidatesOnUpdateLinkOptions(t *testing.T) {
+	ctx := context.Background()
+	store, _, _ := newTestStore(t, 10)
+	_, err := store.Save(ctx, "abc", "https://example.com", "alice")
+	require.NoError(t, err)
+	link, err := store.Resolve(ctx, "abc")
+	require.NoError(t, err)
+	assert.Zero(t, link.RedirectType)
+
+	require.NoError(t, store.UpdateLinkOptions(ctx, "alice", "abc", dto.LinkOptions{RedirectType: 301}))
+
+	link, err = store.Resolve(ctx, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, 301, link.RedirectType)
+}
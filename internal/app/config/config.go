@@ -4,6 +4,9 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/caarlos0/env/v6"
 )
@@ -13,6 +16,10 @@ const (
 	DefaultServerAddress   = "localhost:8080"
 	DefaultBaseURL         = "http://localhost:8080"
 	DefaultDatabaseDSN     = ""
+	DefaultLogLevel        = "info"
+
+	// SQLiteDSNPrefix — префикс DATABASE_DSN, выбирающий встроенное хранилище SQLite
+	SQLiteDSNPrefix = "sqlite://"
 )
 
 type Config struct {
@@ -20,6 +27,97 @@ type Config struct {
 	BaseURL         string `env:"BASE_URL" envDefault:"http://localhost:8080"`
 	FileStoragePath string
 	DatabaseDSN     string `env:"DATABASE_DSN" envDefault:"localDB"`
+	LogLevel        string `env:"LOG_LEVEL" envDefault:"info"`
+
+	// SkipMigrations отключает применение миграций при старте — например,
+	// когда схему обновляют администраторы БД командой shortener migrate up.
+	SkipMigrations bool `env:"SKIP_MIGRATIONS"`
+
+	// Пул соединений PostgreSQL: границы числа соединений (0 — умолчания pgx),
+	// время жизни и простоя соединения, период проверки и таймаут подключения.
+	// DB_STATEMENT_CACHE_MODE — cache_statement, cache_describe, exec или simple_protocol;
+	// за PgBouncer в режиме транзакций нужен simple_protocol
+	DBMaxConns           int32         `env:"DB_MAX_CONNS"`
+	DBMinConns           int32         `env:"DB_MIN_CONNS"`
+	DBMaxConnLifetime    time.Duration `env:"DB_MAX_CONN_LIFETIME" envDefault:"1h"`
+	DBMaxConnIdleTime    time.Duration `env:"DB_MAX_CONN_IDLE_TIME" envDefault:"30m"`
+	DBHealthCheckPeriod  time.Duration `env:"DB_HEALTH_CHECK_PERIOD" envDefault:"1m"`
+	DBConnectTimeout     time.Duration `env:"DB_CONNECT_TIMEOUT" envDefault:"5s"`
+	DBStatementCacheMode string        `env:"DB_STATEMENT_CACHE_MODE" envDefault:"cache_statement"`
+
+	// Реплики PostgreSQL для чтения ссылок: DSN через запятую, период проверки их доступности
+	// и окно после записи, в течение которого чтения тех же ссылок и того же пользователя
+	// идут в основную базу. Пул каждой реплики настраивается теми же DB_* параметрами
+	DatabaseReplicaDSNs          []string      `env:"DATABASE_REPLICA_DSNS" envSeparator:","`
+	DBReplicaHealthCheckInterval time.Duration `env:"DB_REPLICA_HEALTH_CHECK_INTERVAL" envDefault:"5s"`
+	DBReplicaStickyWindow        time.Duration `env:"DB_REPLICA_STICKY_WINDOW" envDefault:"5s"`
+
+	// AdminAddress — адрес отдельного служебного listener'а для /metrics.
+	// Если не задан, /metrics обслуживается основным сервером.
+	AdminAddress string `env:"ADMIN_ADDRESS"`
+
+	// Трассировка OpenTelemetry: экспортёр none, stdout или otlp (OTLP/HTTP).
+	TracingExporter    string  `env:"TRACING_EXPORTER" envDefault:"none"`
+	TracingEndpoint    string  `env:"TRACING_ENDPOINT"`
+	TracingServiceName string  `env:"TRACING_SERVICE_NAME" envDefault:"shortener"`
+	TracingSampleRatio float64 `env:"TRACING_SAMPLE_RATIO" envDefault:"1"`
+
+	// Лимиты частоты запросов по группам маршрутов: запросов в секунду и допустимый всплеск.
+	// Нулевое значение отключает лимит группы. Лимит создания действует и на пользователя,
+	// и на IP-адрес: все пользователи с одного адреса делят его корзину.
+	RateLimitCreateRPS     float64 `env:"RATE_LIMIT_CREATE_RPS" envDefault:"5"`
+	RateLimitCreateBurst   int     `env:"RATE_LIMIT_CREATE_BURST" envDefault:"20"`
+	RateLimitRedirectRPS   float64 `env:"RATE_LIMIT_REDIRECT_RPS" envDefault:"50"`
+	RateLimitRedirectBurst int     `env:"RATE_LIMIT_REDIRECT_BURST" envDefault:"100"`
+	RateLimitUserRPS       float64 `env:"RATE_LIMIT_USER_RPS" envDefault:"10"`
+	RateLimitUserBurst     int     `env:"RATE_LIMIT_USER_BURST" envDefault:"30"`
+
+	// Квоты на число активных ссылок.
+	// QUOTA_TIERS задаёт тарифы в виде "free:100,pro:10000"; без него квоты отключены.
+	// QUOTA_USER_TIERS назначает тарифы отдельным пользователям: "userID:pro,...".
+	QuotaTiers       string `env:"QUOTA_TIERS"`
+	QuotaDefaultTier string `env:"QUOTA_DEFAULT_TIER" envDefault:"free"`
+	QuotaUserTiers   string `env:"QUOTA_USER_TIERS"`
+
+	// Асинхронное удаление: ёмкость очереди, размер пачки и интервал её сброса,
+	// число повторов с начальной паузой и файл для окончательно неудавшихся удалений.
+	// Принятые задачи хранятся в таблице delete_outbox (PostgreSQL, SQLite) или в журнале
+	// DELETE_JOURNAL_PATH (файловое и in-memory хранилища); пустой путь отключает журнал.
+	// Итог выполненной задачи доступен по /api/user/jobs/{id} в течение DELETE_JOB_RETENTION.
+	DeleteQueueSize      int           `env:"DELETE_QUEUE_SIZE" envDefault:"1024"`
+	DeleteBatchSize      int           `env:"DELETE_BATCH_SIZE" envDefault:"100"`
+	DeleteFlushInterval  time.Duration `env:"DELETE_FLUSH_INTERVAL" envDefault:"5s"`
+	DeleteMaxRetries     int           `env:"DELETE_MAX_RETRIES" envDefault:"3"`
+	DeleteRetryBackoff   time.Duration `env:"DELETE_RETRY_BACKOFF" envDefault:"200ms"`
+	DeleteDeadLetterPath string        `env:"DELETE_DEAD_LETTER_PATH" envDefault:"delete_dead_letters.jsonl"`
+	DeleteJournalPath    string        `env:"DELETE_JOURNAL_PATH" envDefault:"delete_journal.jsonl"`
+	DeleteJournalLease   time.Duration `env:"DELETE_JOURNAL_LEASE" envDefault:"1m"`
+	DeleteJobRetention   time.Duration `env:"DELETE_JOB_RETENTION" envDefault:"24h"`
+
+	// Сколько хранится ответ на запрос создания с заголовком Idempotency-Key
+	IdempotencyTTL time.Duration `env:"IDEMPOTENCY_TTL" envDefault:"24h"`
+
+	// Сколько строк потокового импорта сохраняется одним пакетом
+	ImportChunkSize int `env:"IMPORT_CHUNK_SIZE" envDefault:"500"`
+
+	// Как часто накопленные переходы по ссылкам сбрасываются в хранилище
+	ClickFlushInterval time.Duration `env:"CLICK_FLUSH_INTERVAL" envDefault:"10s"`
+
+	// Кэш переходов по коротким ссылкам: число записей (0 отключает кэш), время жизни
+	// найденного адреса и отрицательного ответа для неизвестных или удалённых кодов.
+	// С PostgreSQL изменения ссылок другими экземплярами приходят через LISTEN/NOTIFY
+	RedirectCacheSize        int           `env:"REDIRECT_CACHE_SIZE" envDefault:"10000"`
+	RedirectCacheTTL         time.Duration `env:"REDIRECT_CACHE_TTL" envDefault:"1m"`
+	RedirectCacheNegativeTTL time.Duration `env:"REDIRECT_CACHE_NEGATIVE_TTL" envDefault:"10s"`
+
+	// Код перенаправления для ссылок без собственного redirect_type (301, 302, 307 или 308)
+	// и сколько браузерам и прокси разрешено помнить постоянные перенаправления (301, 308)
+	RedirectStatus          int           `env:"REDIRECT_STATUS" envDefault:"307"`
+	RedirectPermanentMaxAge time.Duration `env:"REDIRECT_PERMANENT_MAX_AGE" envDefault:"24h"`
+
+	// Файл снимка in-memory хранилища: загружается при старте и пишется при остановке.
+	// Пустой путь — данные in-memory хранилища не переживают рестарт
+	MemorySnapshotPath string `env:"MEMORY_SNAPSHOT_PATH"`
 }
 
 func NewConfig() *Config {
@@ -35,6 +133,8 @@ func NewConfig() *Config {
 	serverAddressFlag := flag.String("a", DefaultServerAddress, "Адрес сервера (например, localhost:8080)")
 	baseURLFlag := flag.String("b", DefaultBaseURL, "Базовый URL для сокращённых ссылок")
 	dsnFlag := flag.String("d", DefaultDatabaseDSN, "Строка подключения к базе данных")
+	logLevelFlag := flag.String("l", DefaultLogLevel, "Уровень логирования (debug, info, warn, error)")
+	skipMigrationsFlag := flag.Bool("skip-migrations", false, "Не применять миграции базы данных при старте")
 	flag.Parse()
 
 	// Определяем итоговые значения по приоритету: env → flags → default
@@ -42,6 +142,8 @@ func NewConfig() *Config {
 	cfg.ServerAddress = getConfigValue(os.Getenv("SERVER_ADDRESS"), *serverAddressFlag, DefaultServerAddress)
 	cfg.BaseURL = getConfigValue(os.Getenv("BASE_URL"), *baseURLFlag, DefaultBaseURL)
 	cfg.DatabaseDSN = getConfigValue(os.Getenv("DATABASE_DSN"), *dsnFlag, DefaultDatabaseDSN)
+	cfg.LogLevel = getConfigValue(os.Getenv("LOG_LEVEL"), *logLevelFlag, DefaultLogLevel)
+	cfg.SkipMigrations = cfg.SkipMigrations || *skipMigrationsFlag
 
 	return cfg
 }
@@ -56,3 +158,42 @@ func getConfigValue(envValue, flagValue, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// SQLitePath возвращает путь к файлу базы, если DATABASE_DSN задан в виде sqlite://<путь>.
+func (c *Config) SQLitePath() (string, bool) {
+	path, ok := strings.CutPrefix(c.DatabaseDSN, SQLiteDSNPrefix)
+	return path, ok && path != ""
+}
+
+// QuotaTierLimits разбирает QUOTA_TIERS в карту тариф → лимит.
+// Записи с нечисловым лимитом пропускаются с предупреждением.
+func (c *Config) QuotaTierLimits() map[string]int {
+	limits := make(map[string]int)
+	for tier, value := range parsePairs(c.QuotaTiers) {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			fmt.Printf("Некорректный лимит тарифа %q: %v\n", tier, err)
+			continue
+		}
+		limits[tier] = limit
+	}
+	return limits
+}
+
+// QuotaUserTierMap разбирает QUOTA_USER_TIERS в карту userID → тариф.
+func (c *Config) QuotaUserTierMap() map[string]string {
+	return parsePairs(c.QuotaUserTiers)
+}
+
+// parsePairs разбирает строку вида "key:value,key2:value2".
+func parsePairs(raw string) map[string]string {
+	pairs := make(map[string]string)
+	for _, item := range strings.Split(raw, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(item), ":")
+		if !ok || key == "" {
+			continue
+		}
+		pairs[key] = value
+	}
+	return pairs
+}
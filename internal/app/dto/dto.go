@@ -1,17 +1,44 @@
 package dto
 
+import "time"
+
 type BatchRequest struct {
 	CorrelationID string `json:"correlation_id"`
 	OriginalURL   string `json:"original_url"`
+	LinkOptions
 }
 
 type BatchResponse struct {
 	CorrelationID string `json:"correlation_id"`
-	ShortURL      string `json:"short_url"`
+	ShortURL      string `json:"short_url,omitempty"`
+	Status        string `json:"status,omitempty"`
+	Error         string `json:"error,omitempty"`
 }
 
 type ShortenRequest struct {
 	URL string `json:"url"`
+	LinkOptions
+}
+
+// LinkOptions — настройки перехода по ссылке, задаваемые при создании и редактировании.
+// Нулевое значение поля означает умолчание сервера.
+type LinkOptions struct {
+	// RedirectType — HTTP-код перенаправления: 301, 302, 307 или 308.
+	RedirectType int `json:"redirect_type,omitempty"`
+	// QueryMode — что делать со строкой запроса короткой ссылки: drop отбрасывает её,
+	// merge добавляет параметры к адресу, оставляя значения адреса при совпадении имён,
+	// override добавляет параметры, заменяя совпадающие.
+	QueryMode string `json:"query_mode,omitempty"`
+	// ForwardPath переносит путь после кода ссылки: /{id}/docs/x ведёт на <адрес>/docs/x.
+	ForwardPath bool `json:"forward_path,omitempty"`
+}
+
+// ResolvedLink — ссылка, найденная для перехода: адрес, срок жизни (nil — бессрочная)
+// и настройки перехода.
+type ResolvedLink struct {
+	OriginalURL string
+	ExpiresAt   *time.Time
+	LinkOptions
 }
 
 type ShortenResponse struct {
@@ -23,4 +50,129 @@ type UserURL struct {
 	OriginalURL string `json:"original_url"`
 }
 
+// LinkExport — ссылка пользователя со всеми метаданными для выгрузки.
+// CreatedAt пуст у ссылок, созданных до появления этого поля.
+type LinkExport struct {
+	ShortURL    string     `json:"short_url"`
+	OriginalURL string     `json:"original_url"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	Deleted     bool       `json:"is_deleted"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	Clicks      int64      `json:"clicks"`
+	LinkOptions
+}
+
+// StoredLink — ссылка со всеми хранимыми полями, включая владельца.
+// Используется для переноса данных между хранилищами и в резервных копиях.
+type StoredLink struct {
+	ShortURL    string     `json:"short_url"`
+	OriginalURL string     `json:"original_url"`
+	UserID      string     `json:"user_id"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	Deleted     bool       `json:"is_deleted,omitempty"`
+	Clicks      int64      `json:"clicks,omitempty"`
+	LinkOptions
+}
+
 type DeleteRequest []string
+
+// BatchSaveItem — элемент пакетного сохранения. На входе ShortURL — сгенерированный код
+// или псевдоним, на выходе — фактический: для уже существующей ссылки это её код, а Existing=true.
+// Conflict=true означает, что код занят другой ссылкой и элемент не сохранён.
+// ExpiresAt — необязательный срок жизни ссылки, LinkOptions — настройки перехода.
+type BatchSaveItem struct {
+	ShortURL    string
+	OriginalURL string
+	ExpiresAt   *time.Time
+	LinkOptions
+	Existing bool
+	Conflict bool
+}
+
+// ImportItem — строка импорта ссылок (NDJSON или CSV).
+type ImportItem struct {
+	OriginalURL string     `json:"original_url"`
+	Alias       string     `json:"alias,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	LinkOptions
+}
+
+// ImportResult — итог обработки строки импорта.
+type ImportResult struct {
+	Line        int    `json:"line"`
+	OriginalURL string `json:"original_url,omitempty"`
+	ShortURL    string `json:"short_url,omitempty"`
+	Status      string `json:"status"`
+	Error       string `json:"error,omitempty"`
+}
+
+type APIKey struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"-"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Hash       string     `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name string `json:"name"`
+}
+
+type CreateAPIKeyResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+type QuotaErrorResponse struct {
+	Error     string `json:"error"`
+	Message   string `json:"message"`
+	Tier      string `json:"tier"`
+	Limit     int    `json:"limit"`
+	Used      int    `json:"used"`
+	Requested int    `json:"requested"`
+}
+
+type ComponentHealth struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type HealthReport struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentHealth `json:"components,omitempty"`
+}
+
+// DeleteJob — принятый запрос на удаление, сохранённый в журнале до его выполнения.
+type DeleteJob struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	ShortURLs []string  `json:"short_urls"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type DeleteResult struct {
+	ShortURL string `json:"short_url"`
+	Status   string `json:"status"`
+}
+
+type DeleteJobStatus struct {
+	ID          string         `json:"id"`
+	Status      string         `json:"status"`
+	CreatedAt   time.Time      `json:"created_at"`
+	CompletedAt *time.Time     `json:"completed_at,omitempty"`
+	Results     []DeleteResult `json:"results,omitempty"`
+}
+
+// IdempotencyRecord — сохранённый по Idempotency-Key запрос и, после его завершения, ответ.
+type IdempotencyRecord struct {
+	Fingerprint string
+	Completed   bool
+	StatusCode  int
+	ContentType string
+	Location    string
+	Body        []byte
+	ExpiresAt   time.Time
+}
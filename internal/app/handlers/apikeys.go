@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/middlewares"
+	"github.com/DaniYer/GoProject.git/internal/app/service"
+	"github.com/go-chi/chi/v5"
+)
+
+// NewCreateAPIKeyHandler godoc
+// @Summary      Создать персональный API-ключ
+// @Description  Выпускает именованный ключ для программного доступа через заголовок X-API-Key. Ключ возвращается только в этом ответе.
+// @Tags         keys
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.CreateAPIKeyRequest true "Название ключа"
+// @Success      201 {object} dto.CreateAPIKeyResponse "Ключ создан"
+// @Failure      400 {string} string "invalid request"
+// @Failure      500 {string} string "internal error"
+// @Router       /api/user/keys [post]
+func NewCreateAPIKeyHandler(svc *service.URLService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(middlewares.UserIDKey).(string)
+
+		var req dto.CreateAPIKeyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+		req.Name = strings.TrimSpace(req.Name)
+		if req.Name == "" {
+			http.Error(w, "name is required", http.StatusBadRequest)
+			return
+		}
+
+		key, rawKey, err := svc.CreateAPIKey(r.Context(), userID, req.Name)
+		if err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(dto.CreateAPIKeyResponse{APIKey: key, Key: rawKey})
+	}
+}
+
+// NewListAPIKeysHandler godoc
+// @Summary      Список API-ключей пользователя
+// @Description  Возвращает метаданные активных ключей текущего пользователя без самих ключей.
+// @Tags         keys
+// @Produce      json
+// @Success      200 {array} dto.APIKey "Список ключей"
+// @Failure      500 {string} string "internal error"
+// @Router       /api/user/keys [get]
+func NewListAPIKeysHandler(svc *service.URLService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(middlewares.UserIDKey).(string)
+
+		keys, err := svc.ListAPIKeys(r.Context(), userID)
+		if err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		if keys == nil {
+			keys = []dto.APIKey{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(keys)
+	}
+}
+
+// NewRevokeAPIKeyHandler godoc
+// @Summary      Отозвать API-ключ
+// @Description  Отзывает ключ текущего пользователя. Запросы с этим ключом сразу начинают получать 401.
+// @Tags         keys
+// @Param        id path string true "Идентификатор ключа"
+// @Success      204 {string} string "Ключ отозван"
+// @Failure      404 {string} string "key not found"
+// @Failure      500 {string} string "internal error"
+// @Router       /api/user/keys/{id} [delete]
+func NewRevokeAPIKeyHandler(svc *service.URLService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(middlewares.UserIDKey).(string)
+
+		err := svc.RevokeAPIKey(r.Context(), userID, chi.URLParam(r, "id"))
+		if errors.Is(err, service.ErrAPIKeyNotFound) {
+			http.Error(w, "key not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/middlewares"
+	"github.com/DaniYer/GoProject.git/internal/app/service"
+	"github.com/DaniYer/GoProject.git/internal/app/storage/memory"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeys_Lifecycle(t *testing.T) {
+	store := memory.NewMemoryStore()
+	svc := &service.URLService{
+		Store:   store,
+		Keys:    store,
+		BaseURL: "http://localhost:8080",
+	}
+	middlewares.InitAPIKeyResolver(svc)
+	t.Cleanup(func() { middlewares.InitAPIKeyResolver(nil) })
+
+	router := chi.NewRouter()
+	router.Use(middlewares.AuthMiddleware)
+	router.Post("/api/user/keys", NewCreateAPIKeyHandler(svc))
+	router.Get("/api/user/keys", NewListAPIKeysHandler(svc))
+	router.Delete("/api/user/keys/{id}", NewRevokeAPIKeyHandler(svc))
+	router.Post("/api/shorten", NewHandleShortenURLv13(svc))
+
+	// Создаём ключ из браузерной сессии
+	body, _ := json.Marshal(dto.CreateAPIKeyRequest{Name: "ci"})
+	req := httptest.NewRequest(http.MethodPost, "/api/user/keys", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	var created dto.CreateAPIKeyResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&created))
+	assert.NotEmpty(t, created.Key)
+	assert.Equal(t, "ci", created.Name)
+
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+
+	// Ключ работает без куки и даёт того же пользователя
+	body, _ = json.Marshal(dto.ShortenRequest{URL: "http://example.com/ci"})
+	req = httptest.NewRequest(http.MethodPost, "/api/shorten", bytes.NewReader(body))
+	req.Header.Set(middlewares.APIKeyHeader, created.Key)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Empty(t, rec.Result().Cookies())
+
+	req = httptest.NewRequest(http.MethodGet, "/api/user/keys", nil)
+	req.Header.Set(middlewares.APIKeyHeader, created.Key)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var keys []dto.APIKey
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&keys))
+	require.Len(t, keys, 1)
+	assert.Equal(t, created.ID, keys[0].ID)
+	assert.NotNil(t, keys[0].LastUsedAt)
+	assert.NotContains(t, rec.Body.String(), created.Key)
+
+	// Отзыв из сессии владельца
+	req = httptest.NewRequest(http.MethodDelete, "/api/user/keys/"+created.ID, nil)
+	req.AddCookie(cookies[0])
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/user/keys", nil)
+	req.Header.Set(middlewares.APIKeyHeader, created.Key)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestAPIKeys_RevokeForeignKey(t *testing.T) {
+	store := memory.NewMemoryStore()
+	svc := &service.URLService{Store: store, Keys: store}
+
+	key, _, err := svc.CreateAPIKey(context.Background(), "owner", "deploy")
+	require.NoError(t, err)
+
+	router := chi.NewRouter()
+	router.Use(middlewares.InjectTestUserIDMiddleware("intruder"))
+	router.Delete("/api/user/keys/{id}", NewRevokeAPIKeyHandler(svc))
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/user/keys/"+key.ID, nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	keys, err := svc.ListAPIKeys(context.Background(), "owner")
+	require.NoError(t, err)
+	assert.Len(t, keys, 1)
+}
+
+// failingTouchStore — хранилище ключей, в котором не записывается время использования.
+type failingTouchStore struct {
+	*memory.MemoryStore
+}
+
+func (s failingTouchStore) TouchAPIKey(ctx context.Context, keyID string, usedAt time.Time) error {
+	return errors.New("database is read-only")
+}
+
+func TestAPIKeys_TouchFailureDoesNotRejectKey(t *testing.T) {
+	store := memory.NewMemoryStore()
+	svc := &service.URLService{Store: store, Keys: failingTouchStore{store}}
+	_, rawKey, err := svc.CreateAPIKey(context.Background(), "alice", "ci")
+	require.NoError(t, err)
+
+	userID, err := svc.ResolveAPIKey(context.Background(), rawKey)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", userID)
+}
@@ -21,7 +21,7 @@ func GetUserURLsHandler(svc *service.URLService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID := r.Context().Value(middlewares.UserIDKey).(string)
 
-		urls, err := svc.GetAllUserURLs(userID)
+		urls, err := svc.GetAllUserURLs(r.Context(), userID)
 		if err != nil {
 			http.Error(w, "internal error", http.StatusInternalServerError)
 			return
@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/DaniYer/GoProject.git/internal/app/dto"
 	"github.com/DaniYer/GoProject.git/internal/app/middlewares"
@@ -11,13 +13,23 @@ import (
 
 // NewBatchShortenURLHandler godoc
 // @Summary      Сократить ссылки пачкой
-// @Description  Принимает массив исходных URL и возвращает массив сокращённых ссылок
+// @Description  Принимает массив исходных URL и возвращает массив сокращённых ссылок.
+// @Description  У каждого элемента могут быть свои redirect_type (301, 302, 307, 308), query_mode и forward_path.
+// @Description  Пустой пакет, повтор correlation_id, некорректный URL или настройки перехода отклоняют весь пакет с 400.
+// @Description  С параметром partial=true некорректные элементы не отклоняют пакет: ответ 207 содержит
+// @Description  итог каждого элемента — status (created, existing, invalid, failed) и код ошибки error.
 // @Tags         urls
 // @Accept       json
 // @Produce      json
 // @Param        input body []dto.BatchRequest true "Список ссылок для сокращения"
+// @Param        partial query bool false "Режим частичного успеха"
+// @Param        Idempotency-Key header string false "Ключ идемпотентности: повтор с тем же ключом вернёт исходный ответ"
 // @Success      201 {array} dto.BatchResponse
+// @Success      207 {array} dto.BatchResponse "Итог по каждому элементу (partial=true)"
 // @Failure      400 {string} string "Некорректный запрос"
+// @Failure      403 {object} dto.QuotaErrorResponse "Превышена квота ссылок"
+// @Failure      409 {string} string "Запрос с этим ключом ещё выполняется"
+// @Failure      422 {string} string "Ключ уже использован с другим запросом"
 // @Failure      500 {string} string "Внутренняя ошибка"
 // @Router       /api/shorten/batch [post]
 func NewBatchShortenURLHandler(svc *service.URLService) http.HandlerFunc {
@@ -30,14 +42,33 @@ func NewBatchShortenURLHandler(svc *service.URLService) http.HandlerFunc {
 			return
 		}
 
-		responses, err := svc.ShortenBatch(req, userID)
+		partial, _ := strconv.ParseBool(r.URL.Query().Get("partial"))
+		status := http.StatusCreated
+		shorten := svc.ShortenBatch
+		if partial {
+			status = http.StatusMultiStatus
+			shorten = svc.ShortenBatchPartial
+		}
+
+		responses, err := shorten(r.Context(), req, userID)
+		if writeQuotaError(w, err) {
+			return
+		}
+		if errors.Is(err, service.ErrEmptyBatch) ||
+			errors.Is(err, service.ErrDuplicateCorrelationID) ||
+			errors.Is(err, service.ErrInvalidURL) ||
+			errors.Is(err, service.ErrInvalidRedirectType) ||
+			errors.Is(err, service.ErrInvalidQueryMode) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		if err != nil {
 			http.Error(w, "internal error", http.StatusInternalServerError)
 			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusCreated)
+		w.WriteHeader(status)
 		json.NewEncoder(w).Encode(responses)
 	}
 }
@@ -2,23 +2,31 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/DaniYer/GoProject.git/internal/app/dto"
 	"github.com/DaniYer/GoProject.git/internal/app/middlewares"
 	"github.com/DaniYer/GoProject.git/internal/app/service"
 	"github.com/DaniYer/GoProject.git/internal/app/worker"
+	"github.com/go-chi/chi/v5"
 )
 
 // NewBatchDeleteHandler godoc
 // @Summary      Удалить сокращённые ссылки пачкой
-// @Description  Принимает список коротких ссылок пользователя и отправляет их на асинхронное удаление
+// @Description  Принимает список коротких ссылок пользователя и отправляет их на асинхронное удаление.
+// @Description  Запрос сохраняется в журнале до ответа 202 и будет выполнен даже после перезапуска сервиса.
+// @Description  В ответе — задача удаления; заголовок Location указывает, где узнать её итог.
+// @Description  Если очередь удаления заполнена, отвечает 503 с заголовком Retry-After.
 // @Tags         urls
 // @Accept       json
 // @Produce      json
 // @Param        input body dto.DeleteRequest true "Список коротких ссылок для удаления"
-// @Success      202 {string} string "Запрос принят на обработку"
+// @Success      202 {object} dto.DeleteJobStatus "Запрос принят на обработку"
+// @Header       202 {string} Location "Адрес статуса задачи удаления"
 // @Failure      400 {string} string "Некорректный запрос"
+// @Failure      500 {string} string "Не удалось сохранить запрос в журнал"
+// @Failure      503 {string} string "Очередь удаления заполнена, повторите позже"
 // @Router       /api/user/urls [delete]
 func NewBatchDeleteHandler(svc *service.URLService, pool *worker.DeleteWorkerPool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -30,14 +38,60 @@ func NewBatchDeleteHandler(svc *service.URLService, pool *worker.DeleteWorkerPoo
 			return
 		}
 
-		for _, shortURL := range req {
-			task := worker.DeleteTask{
-				UserID: userID,
-				Short:  shortURL,
-			}
-			pool.AddTask(task)
+		job, err := pool.AddTask(r.Context(), worker.DeleteTask{
+			UserID:    userID,
+			ShortURLs: req,
+		})
+		if errors.Is(err, worker.ErrQueueFull) || errors.Is(err, worker.ErrShuttingDown) {
+			middlewares.LoggerFromContext(r.Context()).Warnw("delete rejected", "error", err)
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		if err != nil {
+			middlewares.LoggerFromContext(r.Context()).Errorw("delete enqueue failed", "error", err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
 		}
 
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", "/api/user/jobs/"+job.ID)
 		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(dto.DeleteJobStatus{
+			ID:        job.ID,
+			Status:    service.DeleteJobPending,
+			CreatedAt: job.CreatedAt,
+		})
+	}
+}
+
+// NewDeleteJobStatusHandler godoc
+// @Summary      Статус задачи удаления
+// @Description  Возвращает состояние задачи удаления текущего пользователя: pending, done или partially_failed.
+// @Description  Для выполненной задачи приводится итог по каждой ссылке: deleted, not_owned, not_found или failed.
+// @Tags         urls
+// @Produce      json
+// @Param        id path string true "Идентификатор задачи"
+// @Success      200 {object} dto.DeleteJobStatus "Состояние задачи"
+// @Failure      404 {string} string "job not found"
+// @Failure      500 {string} string "internal error"
+// @Router       /api/user/jobs/{id} [get]
+func NewDeleteJobStatusHandler(pool *worker.DeleteWorkerPool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(middlewares.UserIDKey).(string)
+
+		status, err := pool.Job(r.Context(), userID, chi.URLParam(r, "id"))
+		if errors.Is(err, service.ErrDeleteJobNotFound) {
+			http.Error(w, "job not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			middlewares.LoggerFromContext(r.Context()).Errorw("get delete job failed", "error", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(status)
 	}
 }
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/middlewares"
+	"github.com/DaniYer/GoProject.git/internal/app/service"
+	"github.com/DaniYer/GoProject.git/internal/app/storage/memory"
+	"github.com/DaniYer/GoProject.git/internal/app/worker"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchDelete_QueueFullReturns503(t *testing.T) {
+	store := NewInMemoryMockStore()
+	svc := &service.URLService{Store: store}
+	// Воркер не запущен, очередь на одну задачу
+	pool := worker.NewDeleteWorkerPool(store, worker.Config{QueueSize: 1}, nil, nil, nil)
+
+	router := chi.NewRouter()
+	router.Use(middlewares.InjectTestUserIDMiddleware("test-user-id"))
+	router.Delete("/api/user/urls", NewBatchDeleteHandler(svc, pool))
+
+	send := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodDelete, "/api/user/urls", bytes.NewBufferString(`["abc","def"]`))
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusAccepted, send().Code)
+
+	rec := send()
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+}
+
+func TestBatchDelete_ReturnsJobLocation(t *testing.T) {
+	store := memory.NewMemoryStore()
+	_, err := store.Save(context.Background(), "abc", "http://example.com", "test-user-id")
+	require.NoError(t, err)
+	svc := &service.URLService{Store: store}
+	pool := worker.NewDeleteWorkerPool(store, worker.Config{FlushInterval: time.Hour}, nil, nil, nil)
+	pool.Start()
+
+	router := chi.NewRouter()
+	router.Use(middlewares.InjectTestUserIDMiddleware("test-user-id"))
+	router.Delete("/api/user/urls", NewBatchDeleteHandler(svc, pool))
+	router.Get("/api/user/jobs/{id}", NewDeleteJobStatusHandler(pool))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/user/urls", bytes.NewBufferString(`["abc","nope"]`)))
+	require.Equal(t, http.StatusAccepted, rec.Code)
+
+	var accepted dto.DeleteJobStatus
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&accepted))
+	assert.Equal(t, service.DeleteJobPending, accepted.Status)
+	location := rec.Header().Get("Location")
+	assert.Equal(t, "/api/user/jobs/"+accepted.ID, location)
+
+	pool.Shutdown()
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, location, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var status dto.DeleteJobStatus
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&status))
+	assert.Equal(t, service.DeleteJobPartiallyFailed, status.Status)
+	assert.Equal(t, []dto.DeleteResult{
+		{ShortURL: "abc", Status: service.DeleteResultDeleted},
+		{ShortURL: "nope", Status: service.DeleteResultNotFound},
+	}, status.Results)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/user/jobs/unknown", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/middlewares"
+	"github.com/DaniYer/GoProject.git/internal/app/service"
+	"github.com/DaniYer/GoProject.git/internal/app/storage/file"
+	"github.com/DaniYer/GoProject.git/internal/app/storage/memory"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchShorten_ReturnsExistingCodes(t *testing.T) {
+	fileStore, err := file.NewFileStore(filepath.Join(t.TempDir(), "storage.json"))
+	require.NoError(t, err)
+
+	stores := map[string]service.URLStore{
+		"memory": memory.NewMemoryStore(),
+		"file":   fileStore,
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			_, err := store.Save(context.Background(), "exist001", "http://example.com/old", "test-user-id")
+			require.NoError(t, err)
+
+			svc := &service.URLService{Store: store, BaseURL: "http://localhost:8080"}
+			router := chi.NewRouter()
+			router.Use(middlewares.InjectTestUserIDMiddleware("test-user-id"))
+			router.Post("/api/shorten/batch", NewBatchShortenURLHandler(svc))
+
+			body, _ := json.Marshal([]dto.BatchRequest{
+				{CorrelationID: "1", OriginalURL: "http://example.com/old"},
+				{CorrelationID: "2", OriginalURL: "http://example.com/new"},
+				{CorrelationID: "3", OriginalURL: "http://example.com/new"},
+			})
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/shorten/batch", bytes.NewReader(body)))
+			require.Equal(t, http.StatusCreated, rec.Code)
+
+			var resp []dto.BatchResponse
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+			require.Len(t, resp, 3)
+			assert.Equal(t, "http://localhost:8080/exist001", resp[0].ShortURL)
+			// Повтор внутри пакета получает тот же код, что и первое вхождение
+			assert.Equal(t, resp[1].ShortURL, resp[2].ShortURL)
+
+			count, err := store.CountActiveByUser(context.Background(), "test-user-id")
+			require.NoError(t, err)
+			assert.Equal(t, 2, count)
+		})
+	}
+}
+
+func newBatchRouter(svc *service.URLService) *chi.Mux {
+	router := chi.NewRouter()
+	router.Use(middlewares.InjectTestUserIDMiddleware("test-user-id"))
+	router.Post("/api/shorten/batch", NewBatchShortenURLHandler(svc))
+	return router
+}
+
+func TestBatchShorten_PartialMode(t *testing.T) {
+	store := memory.NewMemoryStore()
+	_, err := store.Save(context.Background(), "exist001", "http://example.com/old", "test-user-id")
+	require.NoError(t, err)
+	router := newBatchRouter(&service.URLService{Store: store, BaseURL: "http://localhost:8080"})
+
+	body, _ := json.Marshal([]dto.BatchRequest{
+		{CorrelationID: "1", OriginalURL: "http://example.com/old"},
+		{CorrelationID: "2", OriginalURL: "not a url"},
+		{CorrelationID: "3", OriginalURL: "https://example.com/new"},
+		{CorrelationID: "4", OriginalURL: ""},
+	})
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/shorten/batch?partial=true", bytes.NewReader(body)))
+	require.Equal(t, http.StatusMultiStatus, rec.Code)
+
+	var resp []dto.BatchResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	require.Len(t, resp, 4)
+	assert.Equal(t, dto.BatchResponse{CorrelationID: "1", ShortURL: "http://localhost:8080/exist001", Status: service.BatchItemExisting}, resp[0])
+	assert.Equal(t, dto.BatchResponse{CorrelationID: "2", Status: service.BatchItemInvalid, Error: service.BatchErrorInvalidURL}, resp[1])
+	assert.Equal(t, service.BatchItemCreated, resp[2].Status)
+	assert.NotEmpty(t, resp[2].ShortURL)
+	assert.Equal(t, dto.BatchResponse{CorrelationID: "4", Status: service.BatchItemInvalid, Error: service.BatchErrorInvalidURL}, resp[3])
+}
+
+func TestBatchShorten_RejectsBadBatches(t *testing.T) {
+	router := newBatchRouter(&service.URLService{Store: memory.NewMemoryStore(), BaseURL: "http://localhost:8080"})
+
+	tests := []struct {
+		name    string
+		query   string
+		body    string
+		message string
+	}{
+		{"empty", "", `[]`, "batch is empty"},
+		{"empty partial", "?partial=true", `[]`, "batch is empty"},
+		{"duplicate correlation", "?partial=true",
+			`[{"correlation_id":"1","original_url":"http://a.com"},{"correlation_id":"1","original_url":"http://b.com"}]`,
+			`duplicate correlation_id: correlation_id "1"`},
+		{"invalid url in strict mode", "",
+			`[{"correlation_id":"1","original_url":"http://a.com"},{"correlation_id":"2","original_url":"ftp://b.com"}]`,
+			`invalid url: correlation_id "2"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/shorten/batch"+tt.query, bytes.NewBufferString(tt.body)))
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, tt.message+"\n", rec.Body.String())
+		})
+	}
+}
+
+// sequenceIDs выдаёт коды по порядку, повторяя последний, когда они кончаются.
+func sequenceIDs(ids ...string) func() string {
+	return func() string {
+		id := ids[0]
+		if len(ids) > 1 {
+			ids = ids[1:]
+		}
+		return id
+	}
+}
+
+func TestBatchShorten_RetriesTakenShortURL(t *testing.T) {
+	store := memory.NewMemoryStore()
+	_, err := store.Save(context.Background(), "taken01", "http://example.com/other", "other-user")
+	require.NoError(t, err)
+
+	t.Run("strict", func(t *testing.T) {
+		svc := &service.URLService{Store: store, BaseURL: "http://localhost:8080",
+			GenerateID: sequenceIDs("taken01", "fresh01", "fresh02")}
+		body, _ := json.Marshal([]dto.BatchRequest{
+			{CorrelationID: "1", OriginalURL: "http://example.com/a"},
+			{CorrelationID: "2", OriginalURL: "http://example.com/b"},
+		})
+		rec := httptest.NewRecorder()
+		newBatchRouter(svc).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/shorten/batch", bytes.NewReader(body)))
+		require.Equal(t, http.StatusCreated, rec.Code)
+
+		var resp []dto.BatchResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		require.Len(t, resp, 2)
+		assert.Equal(t, "http://localhost:8080/fresh02", resp[0].ShortURL)
+		assert.Equal(t, "http://localhost:8080/fresh01", resp[1].ShortURL)
+
+		original, err := store.Get(context.Background(), "fresh02")
+		require.NoError(t, err)
+		assert.Equal(t, "http://example.com/a", original)
+	})
+
+	t.Run("partial", func(t *testing.T) {
+		// Генератор всё время выдаёт занятый код: элемент не сохраняется и помечается failed
+		svc := &service.URLService{Store: store, BaseURL: "http://localhost:8080",
+			GenerateID: sequenceIDs("taken01")}
+		body, _ := json.Marshal([]dto.BatchRequest{{CorrelationID: "1", OriginalURL: "http://example.com/c"}})
+		rec := httptest.NewRecorder()
+		newBatchRouter(svc).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/shorten/batch?partial=true", bytes.NewReader(body)))
+		require.Equal(t, http.StatusMultiStatus, rec.Code)
+
+		var resp []dto.BatchResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.Equal(t, []dto.BatchResponse{{CorrelationID: "1", Status: service.BatchItemFailed, Error: service.BatchErrorShortURLTaken}}, resp)
+
+		_, err := store.GetByOriginalURL(context.Background(), "http://example.com/c")
+		assert.Error(t, err)
+	})
+}
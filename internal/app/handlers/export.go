@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/middlewares"
+	"github.com/DaniYer/GoProject.git/internal/app/service"
+)
+
+// exportFlushEvery — через сколько строк выгрузки ответ проталкивается клиенту.
+const exportFlushEvery = 500
+
+// linkWriter пишет ссылки выгрузки в одном из форматов.
+type linkWriter interface {
+	begin() error
+	write(link dto.LinkExport) error
+	end() error
+}
+
+// NewExportUserURLsHandler godoc
+// @Summary      Выгрузить все ссылки пользователя
+// @Description  Потоком отдаёт все ссылки текущего пользователя, включая удалённые, с датой создания,
+// @Description  признаком удаления, сроком жизни и числом переходов. Формат: json (массив, по умолчанию),
+// @Description  ndjson (по объекту на строку) или csv (short_url, original_url, created_at, is_deleted, expires_at, clicks,
+// @Description  redirect_type, query_mode, forward_path; нулевой redirect_type — пустая ячейка).
+// @Tags         urls
+// @Produce      json
+// @Produce      text/csv
+// @Param        format query string false "Формат выгрузки" Enums(json, ndjson, csv)
+// @Success      200 {array} dto.LinkExport
+// @Failure      400 {string} string "Неизвестный формат"
+// @Failure      500 {string} string "Внутренняя ошибка"
+// @Router       /api/user/urls/export [get]
+func NewExportUserURLsHandler(svc *service.URLService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(middlewares.UserIDKey).(string)
+
+		format := r.URL.Query().Get("format")
+		var out linkWriter
+		var contentType string
+		switch format {
+		case "", "json":
+			format, contentType = "json", "application/json"
+			out = &jsonLinkWriter{w: w}
+		case "ndjson":
+			contentType = "application/x-ndjson"
+			out = &ndjsonLinkWriter{enc: json.NewEncoder(w)}
+		case "csv":
+			contentType = "text/csv; charset=utf-8"
+			out = &csvLinkWriter{w: csv.NewWriter(w)}
+		default:
+			http.Error(w, "unknown export format", http.StatusBadRequest)
+			return
+		}
+
+		// Заголовок ответа пишется вместе с первой ссылкой: пока ничего не отправлено,
+		// ошибку хранилища ещё можно вернуть статусом 500
+		started := false
+		start := func() error {
+			started = true
+			w.Header().Set("Content-Type", contentType)
+			w.Header().Set("Content-Disposition", `attachment; filename="urls.`+format+`"`)
+			w.WriteHeader(http.StatusOK)
+			return out.begin()
+		}
+
+		flusher := http.NewResponseController(w)
+		written := 0
+		err := svc.ExportUserURLs(r.Context(), userID, func(link dto.LinkExport) error {
+			if !started {
+				if err := start(); err != nil {
+					return err
+				}
+			}
+			if err := out.write(link); err != nil {
+				return err
+			}
+			written++
+			if written%exportFlushEvery == 0 {
+				flusher.Flush()
+			}
+			return nil
+		})
+		if err != nil {
+			if !started {
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			// Ответ уже идёт: обрываем его, не дописывая конец, чтобы клиент увидел неполную выгрузку
+			middlewares.LoggerFromContext(r.Context()).Errorw("export failed", "written", written, "error", err)
+			return
+		}
+		if !started {
+			if err := start(); err != nil {
+				return
+			}
+		}
+		out.end()
+	}
+}
+
+// jsonLinkWriter пишет JSON-массив по элементу, не собирая его в памяти.
+type jsonLinkWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (j *jsonLinkWriter) begin() error {
+	_, err := io.WriteString(j.w, "[")
+	return err
+}
+
+func (j *jsonLinkWriter) write(link dto.LinkExport) error {
+	if j.count > 0 {
+		if _, err := io.WriteString(j.w, ","); err != nil {
+			return err
+		}
+	}
+	j.count++
+	data, err := json.Marshal(link)
+	if err != nil {
+		return err
+	}
+	_, err = j.w.Write(data)
+	return err
+}
+
+func (j *jsonLinkWriter) end() error {
+	_, err := io.WriteString(j.w, "]\n")
+	return err
+}
+
+type ndjsonLinkWriter struct {
+	enc *json.Encoder
+}
+
+func (n *ndjsonLinkWriter) begin() error { return nil }
+
+func (n *ndjsonLinkWriter) write(link dto.LinkExport) error { return n.enc.Encode(link) }
+
+func (n *ndjsonLinkWriter) end() error { return nil }
+
+type csvLinkWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvLinkWriter) begin() error {
+	return c.w.Write([]string{"short_url", "original_url", "created_at", "is_deleted", "expires_at", "clicks",
+		"redirect_type", "query_mode", "forward_path"})
+}
+
+func (c *csvLinkWriter) write(link dto.LinkExport) error {
+	err := c.w.Write([]string{
+		link.ShortURL,
+		link.OriginalURL,
+		formatExportTime(link.CreatedAt),
+		strconv.FormatBool(link.Deleted),
+		formatExportTime(link.ExpiresAt),
+		strconv.FormatInt(link.Clicks, 10),
+		formatRedirectType(link.RedirectType),
+		link.QueryMode,
+		strconv.FormatBool(link.ForwardPath),
+	})
+	if err != nil {
+		return err
+	}
+	// csv.Writer буферизует строки — без Flush периодический сброс ответа ничего бы не отправил
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvLinkWriter) end() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// formatRedirectType оставляет ячейку пустой для ссылок без собственного кода перенаправления.
+func formatRedirectType(code int) string {
+	if code == 0 {
+		return ""
+	}
+	return strconv.Itoa(code)
+}
+
+func formatExportTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/middlewares"
+	"github.com/DaniYer/GoProject.git/internal/app/service"
+	"github.com/DaniYer/GoProject.git/internal/app/storage/file"
+	"github.com/DaniYer/GoProject.git/internal/app/storage/memory"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exportTestStore — хранилище со счётчиками переходов.
+type exportTestStore interface {
+	service.URLStore
+	service.ClickStore
+}
+
+func TestExport_AllFormats(t *testing.T) {
+	fileStore, err := file.NewFileStore(filepath.Join(t.TempDir(), "storage.json"))
+	require.NoError(t, err)
+
+	stores := map[string]exportTestStore{
+		"memory": memory.NewMemoryStore(),
+		"file":   fileStore,
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			_, err := store.Save(ctx, "live", "https://example.com/live", "test-user-id")
+			require.NoError(t, err)
+			_, err = store.Save(ctx, "dead", "https://example.com/dead", "test-user-id")
+			require.NoError(t, err)
+			_, err = store.Save(ctx, "foreign", "https://example.com/foreign", "other-user")
+			require.NoError(t, err)
+			_, err = store.BatchDelete(ctx, "test-user-id", []string{"dead"})
+			require.NoError(t, err)
+			require.NoError(t, store.AddClicks(ctx, map[string]int64{"live": 3}))
+			require.NoError(t, store.UpdateLinkOptions(ctx, "test-user-id", "live", dto.LinkOptions{
+				RedirectType: http.StatusMovedPermanently, QueryMode: "merge", ForwardPath: true,
+			}))
+
+			svc := &service.URLService{Store: store, BaseURL: "http://localhost:8080"}
+			router := chi.NewRouter()
+			router.Use(middlewares.InjectTestUserIDMiddleware("test-user-id"))
+			router.Get("/api/user/urls/export", NewExportUserURLsHandler(svc))
+			get := func(query string) *httptest.ResponseRecorder {
+				rec := httptest.NewRecorder()
+				router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/user/urls/export"+query, nil))
+				return rec
+			}
+
+			rec := get("")
+			require.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			var links []dto.LinkExport
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&links))
+			require.Len(t, links, 2)
+			byShort := map[string]dto.LinkExport{}
+			for _, link := range links {
+				assert.NotNil(t, link.CreatedAt)
+				byShort[link.ShortURL] = link
+			}
+			assert.Equal(t, int64(3), byShort["http://localhost:8080/live"].Clicks)
+			assert.False(t, byShort["http://localhost:8080/live"].Deleted)
+			assert.True(t, byShort["http://localhost:8080/dead"].Deleted)
+
+			rec = get("?format=ndjson")
+			require.Equal(t, http.StatusOK, rec.Code)
+			decoder := json.NewDecoder(rec.Body)
+			lines := 0
+			for decoder.More() {
+				var link dto.LinkExport
+				require.NoError(t, decoder.Decode(&link))
+				lines++
+			}
+			assert.Equal(t, 2, lines)
+
+			rec = get("?format=csv")
+			require.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, `attachment; filename="urls.csv"`, rec.Header().Get("Content-Disposition"))
+			records, err := csv.NewReader(rec.Body).ReadAll()
+			require.NoError(t, err)
+			require.Len(t, records, 3)
+			assert.Equal(t, []string{"short_url", "original_url", "created_at", "is_deleted", "expires_at", "clicks",
+				"redirect_type", "query_mode", "forward_path"}, records[0])
+			for _, record := range records[1:] {
+				if record[0] == "http://localhost:8080/live" {
+					assert.Equal(t, []string{"301", "merge", "true"}, record[6:])
+				} else {
+					assert.Equal(t, []string{"", "", "false"}, record[6:])
+				}
+			}
+
+			assert.Equal(t, http.StatusBadRequest, get("?format=xml").Code)
+		})
+	}
+}
+
+func TestExport_EmptyIsValidDocument(t *testing.T) {
+	svc := &service.URLService{Store: memory.NewMemoryStore(), BaseURL: "http://localhost:8080"}
+	router := chi.NewRouter()
+	router.Use(middlewares.InjectTestUserIDMiddleware("test-user-id"))
+	router.Get("/api/user/urls/export", NewExportUserURLsHandler(svc))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/user/urls/export", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[]`, rec.Body.String())
+}
@@ -1,9 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"io"
 	"net/http"
 
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
 	"github.com/DaniYer/GoProject.git/internal/app/middlewares"
 	"github.com/DaniYer/GoProject.git/internal/app/service"
 )
@@ -16,7 +18,8 @@ import (
 // @Produce      plain
 // @Param        url body string true "Оригинальный URL"
 // @Success      201 {string} string "Короткая ссылка создана"
-// @Failure      400 {string} string "Ошибка чтения тела"
+// @Failure      400 {string} string "Ошибка чтения тела или некорректный URL"
+// @Failure      403 {object} dto.QuotaErrorResponse "Превышена квота ссылок"
 // @Failure      409 {string} string "Ссылка уже существует"
 // @Failure      500 {string} string "Ошибка сохранения"
 // @Router       / [post]
@@ -39,24 +42,27 @@ func GenerateShortURLHandler(w http.ResponseWriter, r *http.Request, svc *servic
 
 	originalURL := string(body)
 
-	existingShortURL, err := svc.Store.GetByOriginalURL(originalURL)
-	resultURL := svc.BaseURL + "/" + existingShortURL
-
-	if err == nil {
-		w.WriteHeader(http.StatusConflict)
-		w.Header().Set("Content-Type", "text/plain")
-		w.Write([]byte(resultURL))
+	shortID, existed, err := svc.Shorten(r.Context(), originalURL, userID, dto.LinkOptions{})
+	if writeQuotaError(w, err) {
+		return
+	}
+	if errors.Is(err, service.ErrInvalidURL) {
+		http.Error(w, "Некорректный URL", http.StatusBadRequest)
 		return
 	}
-
-	shortID := service.GenerateRandomID()
-	shortID, err = svc.Store.Save(shortID, originalURL, userID)
 	if err != nil {
 		http.Error(w, "Ошибка сохранения", http.StatusInternalServerError)
 		return
 	}
 
-	resultURL = svc.BaseURL + "/" + shortID
+	resultURL := svc.BaseURL + "/" + shortID
+	if existed {
+		w.WriteHeader(http.StatusConflict)
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(resultURL))
+		return
+	}
+
 	w.WriteHeader(http.StatusCreated)
 	w.Header().Set("Content-Type", "text/plain")
 	w.Write([]byte(resultURL))
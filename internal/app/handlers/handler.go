@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/DaniYer/GoProject.git/internal/app/dto"
@@ -12,13 +13,19 @@ import (
 // NewHandleShortenURLv13 godoc
 // @Summary      Создать короткую ссылку (JSON API v1.3)
 // @Description  Принимает оригинальный URL в формате JSON и возвращает короткую ссылку.
+// @Description  Необязательный redirect_type (301, 302, 307, 308) задаёт код перенаправления ссылки,
+// @Description  query_mode (drop, merge, override) и forward_path — перенос строки запроса и пути при переходе.
 // @Tags         urls
 // @Accept       json
 // @Produce      json
 // @Param        request body dto.ShortenRequest true "Данные для сокращения"
+// @Param        Idempotency-Key header string false "Ключ идемпотентности: повтор с тем же ключом вернёт исходный ответ"
 // @Success      201 {object} dto.ShortenResponse "Короткая ссылка создана"
 // @Success      409 {object} dto.ShortenResponse "Ссылка уже существует"
-// @Failure      400 {string} string "invalid request"
+// @Failure      400 {string} string "invalid request, invalid url, invalid redirect_type или invalid query_mode"
+// @Failure      403 {object} dto.QuotaErrorResponse "Превышена квота ссылок"
+// @Failure      409 {string} string "Запрос с этим ключом ещё выполняется"
+// @Failure      422 {string} string "Ключ уже использован с другим запросом"
 // @Failure      500 {string} string "internal error"
 // @Router       /api/shorten [post]
 func NewHandleShortenURLv13(svc *service.URLService) http.HandlerFunc {
@@ -31,7 +38,14 @@ func NewHandleShortenURLv13(svc *service.URLService) http.HandlerFunc {
 			return
 		}
 
-		shortID, existed, err := svc.Shorten(req.URL, userID)
+		shortID, existed, err := svc.Shorten(r.Context(), req.URL, userID, req.LinkOptions)
+		if writeQuotaError(w, err) {
+			return
+		}
+		if errors.Is(err, service.ErrInvalidURL) || errors.Is(err, service.ErrInvalidRedirectType) || errors.Is(err, service.ErrInvalidQueryMode) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		if err != nil {
 			http.Error(w, "internal error", http.StatusInternalServerError)
 			return
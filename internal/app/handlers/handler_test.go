@@ -3,6 +3,7 @@ package handlers
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -28,14 +29,25 @@ func NewInMemoryMockStore() *InMemoryMockStore {
 	}
 }
 
-func (m *InMemoryMockStore) Save(shortURL, originalURL, userID string) (string, error) {
+func (m *InMemoryMockStore) Save(ctx context.Context, shortURL, originalURL, userID string) (string, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.data[shortURL] = originalURL
 	return shortURL, nil
 }
 
-func (m *InMemoryMockStore) GetByOriginalURL(originalURL string) (string, error) {
+func (m *InMemoryMockStore) SaveBatch(ctx context.Context, userID string, items []dto.BatchSaveItem) ([]dto.BatchSaveItem, error) {
+	for i, item := range items {
+		shortURL, err := m.Save(ctx, item.ShortURL, item.OriginalURL, userID)
+		if err != nil {
+			return nil, err
+		}
+		items[i].ShortURL = shortURL
+	}
+	return items, nil
+}
+
+func (m *InMemoryMockStore) GetByOriginalURL(ctx context.Context, originalURL string) (string, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 	for k, v := range m.data {
@@ -46,7 +58,7 @@ func (m *InMemoryMockStore) GetByOriginalURL(originalURL string) (string, error)
 	return "", errors.New("not found")
 }
 
-func (m *InMemoryMockStore) Get(shortURL string) (string, error) {
+func (m *InMemoryMockStore) Get(ctx context.Context, shortURL string) (string, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 	originalURL, ok := m.data[shortURL]
@@ -56,11 +68,38 @@ func (m *InMemoryMockStore) Get(shortURL string) (string, error) {
 	return originalURL, nil
 }
 
-func (m *InMemoryMockStore) GetAllByUser(userID string) ([]dto.UserURL, error) {
+func (m *InMemoryMockStore) Resolve(ctx context.Context, shortURL string) (dto.ResolvedLink, error) {
+	originalURL, err := m.Get(ctx, shortURL)
+	if err != nil {
+		return dto.ResolvedLink{}, err
+	}
+	return dto.ResolvedLink{OriginalURL: originalURL}, nil
+}
+
+func (m *InMemoryMockStore) UpdateLinkOptions(ctx context.Context, userID, shortURL string, opts dto.LinkOptions) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if _, ok := m.data[shortURL]; !ok {
+		return service.ErrLinkNotFound
+	}
+	return nil
+}
+
+func (m *InMemoryMockStore) GetAllByUser(ctx context.Context, userID string) ([]dto.UserURL, error) {
 	return nil, nil
 }
 
-func (m *InMemoryMockStore) BatchDelete(userID string, shortURLs []string) error {
+func (m *InMemoryMockStore) BatchDelete(ctx context.Context, userID string, shortURLs []string) ([]dto.DeleteResult, error) {
+	return nil, nil
+}
+
+func (m *InMemoryMockStore) CountActiveByUser(ctx context.Context, userID string) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.data), nil
+}
+
+func (m *InMemoryMockStore) IterateByUser(ctx context.Context, userID string, fn func(dto.LinkExport) error) error {
 	return nil
 }
 
@@ -102,3 +141,36 @@ func TestHandleShortenURLv13_Success(t *testing.T) {
 	json.NewDecoder(reader).Decode(&resp)
 	assert.Contains(t, resp.Result, svc.BaseURL+"/")
 }
+
+func TestHandleShortenURLv13_InvalidLinkOptions(t *testing.T) {
+	svc := service.URLService{Store: NewInMemoryMockStore(), BaseURL: "http://localhost:8080"}
+	router := buildTestRouter(&svc)
+
+	for _, opts := range []dto.LinkOptions{{RedirectType: 303}, {QueryMode: "keep"}} {
+		body, _ := json.Marshal(dto.ShortenRequest{URL: "http://example.com", LinkOptions: opts})
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/shorten", bytes.NewBuffer(body)))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestShorten_RejectsInvalidURLLikeBatch(t *testing.T) {
+	svc := service.URLService{Store: NewInMemoryMockStore(), BaseURL: "http://localhost:8080"}
+	router := chi.NewRouter()
+	router.Use(middlewares.InjectTestUserIDMiddleware("test-user-id"))
+	router.Post("/", NewGenerateShortURLHandler(&svc))
+	router.Post("/api/shorten", NewHandleShortenURLv13(&svc))
+
+	// Одиночное сокращение отклоняет те же адреса, что и пакет
+	for _, raw := range []string{"ftp://example.com/file", "javascript:alert(1)", "example.com"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(raw)))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, raw)
+
+		body, _ := json.Marshal(dto.ShortenRequest{URL: raw})
+		rec = httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/shorten", bytes.NewBuffer(body)))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, raw)
+	}
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/health"
+)
+
+// NewLivenessHandler godoc
+// @Summary      Проверка живости процесса
+// @Description  Отвечает 200, пока процесс способен обрабатывать HTTP-запросы. Зависимости не проверяются.
+// @Tags         health
+// @Produce      json
+// @Success      200 {object} dto.HealthReport "Процесс жив"
+// @Router       /healthz [get]
+func NewLivenessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(dto.HealthReport{Status: health.StatusOK})
+	}
+}
+
+// NewReadinessHandler godoc
+// @Summary      Проверка готовности к приёму трафика
+// @Description  Проверяет активное хранилище (ping БД или доступность файла на запись) и воркеры. Возвращает состояние каждого компонента.
+// @Tags         health
+// @Produce      json
+// @Success      200 {object} dto.HealthReport "Все компоненты готовы"
+// @Failure      503 {object} dto.HealthReport "Хотя бы один компонент не готов"
+// @Router       /readyz [get]
+func NewReadinessHandler(checker *health.Checker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := checker.Check(r.Context())
+
+		w.Header().Set("Content-Type", "application/json")
+		if report.Status != health.StatusOK {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(report)
+	}
+}
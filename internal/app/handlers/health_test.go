@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/health"
+	"github.com/DaniYer/GoProject.git/internal/app/storage/file"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLiveness_AlwaysOK(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewLivenessHandler()(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, rec.Body.String())
+}
+
+func TestReadiness_ReportsEachComponent(t *testing.T) {
+	checker := health.NewChecker(time.Second)
+	checker.Register("storage", func(ctx context.Context) error { return nil })
+	checker.Register("delete_worker", func(ctx context.Context) error { return errors.New("delete worker is not running") })
+
+	rec := httptest.NewRecorder()
+	NewReadinessHandler(checker)(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	var report dto.HealthReport
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&report))
+	assert.Equal(t, dto.HealthReport{
+		Status: health.StatusFail,
+		Components: map[string]dto.ComponentHealth{
+			"storage":       {Status: health.StatusOK},
+			"delete_worker": {Status: health.StatusFail, Error: "delete worker is not running"},
+		},
+	}, report)
+}
+
+func TestReadiness_FileStoreWithoutDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storage.json")
+	store, err := file.NewFileStore(path)
+	require.NoError(t, err)
+
+	checker := health.NewChecker(time.Second)
+	checker.Register("storage", store.Ping)
+
+	rec := httptest.NewRecorder()
+	NewReadinessHandler(checker)(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// Файл пропал — экземпляр больше не готов принимать запись
+	require.NoError(t, os.Remove(path))
+	rec = httptest.NewRecorder()
+	NewReadinessHandler(checker)(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/middlewares"
+	"github.com/DaniYer/GoProject.git/internal/app/service"
+)
+
+// maxImportLineSize — максимальная длина строки NDJSON.
+const maxImportLineSize = 1 << 20
+
+// errImportLine — строку импорта не удалось разобрать; импорт продолжается со следующей.
+var errImportLine = errors.New("invalid import line")
+
+// importLine — разобранная строка импорта с номером строки во входном потоке.
+type importLine struct {
+	line int
+	item dto.ImportItem
+	err  error
+}
+
+// importReader возвращает следующую строку импорта или io.EOF.
+// При ошибке чтения строка содержит номер строки, которую не удалось прочитать.
+type importReader func() (importLine, error)
+
+// NewImportHandler godoc
+// @Summary      Импортировать ссылки потоком
+// @Description  Принимает поток NDJSON (по объекту на строку) или CSV (Content-Type: text/csv,
+// @Description  колонки original_url, alias, expires_at; строка заголовка необязательна).
+// @Description  Тело можно сжать gzip. Строки сохраняются порциями, ответ — поток NDJSON
+// @Description  с итогом каждой строки: status (created, existing, invalid, conflict, failed) и код ошибки error.
+// @Description  При превышении квоты или ошибке хранилища текущая порция получает статус failed, и импорт прекращается.
+// @Description  Если тело не удалось дочитать, поток завершается строкой со статусом failed и ошибкой read_failed.
+// @Tags         urls
+// @Accept       json
+// @Accept       text/csv
+// @Produce      json
+// @Param        input body dto.ImportItem true "Строки импорта (NDJSON или CSV)"
+// @Success      200 {array} dto.ImportResult "Поток NDJSON, по объекту на строку импорта"
+// @Failure      400 {string} string "Некорректный запрос"
+// @Router       /api/shorten/import [post]
+func NewImportHandler(svc *service.URLService, chunkSize int) http.HandlerFunc {
+	if chunkSize <= 0 {
+		chunkSize = 500
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(middlewares.UserIDKey).(string)
+		logger := middlewares.LoggerFromContext(r.Context())
+
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		next := ndjsonImportReader(r.Body)
+		if mediaType == "text/csv" {
+			next = csvImportReader(r.Body)
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+		encoder := json.NewEncoder(w)
+		flusher := http.NewResponseController(w)
+
+		chunk := make([]importLine, 0, chunkSize)
+		for {
+			line, err := next()
+			readFailed := err != nil && !errors.Is(err, io.EOF)
+			if err == nil {
+				chunk = append(chunk, line)
+				if len(chunk) < chunkSize {
+					continue
+				}
+			} else if readFailed {
+				logger.Warnw("import stream read failed", "line", line.line, "error", err)
+			}
+
+			if len(chunk) > 0 {
+				results, saveErr := importChunk(r, svc, chunk, userID)
+				for _, result := range results {
+					encoder.Encode(result)
+				}
+				flusher.Flush()
+				if saveErr != nil {
+					if !errors.Is(saveErr, service.ErrQuotaExceeded) {
+						logger.Errorw("import chunk failed", "error", saveErr)
+					}
+					return
+				}
+				chunk = chunk[:0]
+			}
+			if readFailed {
+				// Без итоговой строки клиент принял бы обрыв за конец потока
+				encoder.Encode(dto.ImportResult{Line: line.line, Status: service.ImportItemFailed, Error: service.ImportErrorReadFailed})
+			}
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// importChunk сохраняет порцию строк и возвращает итог каждой в порядке входного потока.
+// Если порция не сохранилась, все её корректные строки получают статус failed.
+func importChunk(r *http.Request, svc *service.URLService, chunk []importLine, userID string) ([]dto.ImportResult, error) {
+	items := make([]dto.ImportItem, 0, len(chunk))
+	for _, line := range chunk {
+		if line.err == nil {
+			items = append(items, line.item)
+		}
+	}
+
+	saved, err := svc.ImportBatch(r.Context(), items, userID)
+	results := make([]dto.ImportResult, len(chunk))
+	j := 0
+	for i, line := range chunk {
+		switch {
+		case line.err != nil:
+			results[i] = dto.ImportResult{Status: service.BatchItemInvalid, Error: service.ImportErrorInvalidLine}
+		case err != nil:
+			code := service.ImportErrorInternal
+			if errors.Is(err, service.ErrQuotaExceeded) {
+				code = service.ImportErrorQuota
+			}
+			results[i] = dto.ImportResult{OriginalURL: line.item.OriginalURL, Status: service.ImportItemFailed, Error: code}
+			j++
+		default:
+			results[i] = saved[j]
+			j++
+		}
+		results[i].Line = line.line
+	}
+	return results, err
+}
+
+// ndjsonImportReader читает по объекту dto.ImportItem на строку, пропуская пустые строки.
+func ndjsonImportReader(body io.Reader) importReader {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLineSize)
+	lineNo := 0
+	return func() (importLine, error) {
+		for scanner.Scan() {
+			lineNo++
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" {
+				continue
+			}
+			line := importLine{line: lineNo}
+			if err := json.Unmarshal([]byte(text), &line.item); err != nil {
+				line.err = errImportLine
+			}
+			return line, nil
+		}
+		if err := scanner.Err(); err != nil {
+			return importLine{line: lineNo + 1}, err
+		}
+		return importLine{}, io.EOF
+	}
+}
+
+// csvImportReader читает строки original_url[,alias[,expires_at]]; expires_at — в RFC 3339.
+// Первая строка пропускается, если это заголовок.
+func csvImportReader(body io.Reader) importReader {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	first := true
+	lastLine := 0
+	return func() (importLine, error) {
+		for {
+			record, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				return importLine{}, io.EOF
+			}
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				first = false
+				lastLine = parseErr.Line
+				return importLine{line: parseErr.Line, err: errImportLine}, nil
+			}
+			if err != nil {
+				return importLine{line: lastLine + 1}, err
+			}
+
+			lineNo, _ := reader.FieldPos(0)
+			lastLine = lineNo
+			if first {
+				first = false
+				if strings.EqualFold(strings.TrimSpace(record[0]), "original_url") {
+					continue
+				}
+			}
+			return parseCSVImportRecord(lineNo, record), nil
+		}
+	}
+}
+
+func parseCSVImportRecord(lineNo int, record []string) importLine {
+	line := importLine{line: lineNo}
+	if len(record) > 3 {
+		line.err = errImportLine
+		return line
+	}
+	line.item.OriginalURL = strings.TrimSpace(record[0])
+	if len(record) > 1 {
+		line.item.Alias = strings.TrimSpace(record[1])
+	}
+	if len(record) > 2 && strings.TrimSpace(record[2]) != "" {
+		expiresAt, err := time.Parse(time.RFC3339, strings.TrimSpace(record[2]))
+		if err != nil {
+			line.err = errImportLine
+			return line
+		}
+		line.item.ExpiresAt = &expiresAt
+	}
+	return line
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/middlewares"
+	"github.com/DaniYer/GoProject.git/internal/app/service"
+	"github.com/DaniYer/GoProject.git/internal/app/storage/file"
+	"github.com/DaniYer/GoProject.git/internal/app/storage/memory"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newImportRouter(svc *service.URLService, chunkSize int) *chi.Mux {
+	router := chi.NewRouter()
+	router.Use(middlewares.InjectTestUserIDMiddleware("test-user-id"))
+	router.Use(middlewares.GzipHandle)
+	router.Post("/api/shorten/import", NewImportHandler(svc, chunkSize))
+	return router
+}
+
+func decodeImportResults(t *testing.T, body io.Reader) []dto.ImportResult {
+	t.Helper()
+	var results []dto.ImportResult
+	decoder := json.NewDecoder(body)
+	for decoder.More() {
+		var result dto.ImportResult
+		require.NoError(t, decoder.Decode(&result))
+		results = append(results, result)
+	}
+	return results
+}
+
+func TestImport_NDJSON(t *testing.T) {
+	fileStore, err := file.NewFileStore(filepath.Join(t.TempDir(), "storage.json"))
+	require.NoError(t, err)
+
+	stores := map[string]service.URLStore{
+		"memory": memory.NewMemoryStore(),
+		"file":   fileStore,
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			_, err := store.Save(context.Background(), "taken", "http://example.com/taken", "other-user")
+			require.NoError(t, err)
+			svc := &service.URLService{Store: store, BaseURL: "http://localhost:8080"}
+
+			expiresAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+			body := strings.Join([]string{
+				`{"original_url":"https://example.com/a","alias":"promo"}`,
+				`{"original_url":"https://example.com/b","expires_at":"` + expiresAt + `"}`,
+				``,
+				`not json`,
+				`{"original_url":"https://example.com/c","alias":"taken"}`,
+				`{"original_url":"https://example.com/d","alias":"bad alias"}`,
+				`{"original_url":"http://example.com/taken"}`,
+				`{"original_url":"https://example.com/e","expires_at":"2000-01-01T00:00:00Z"}`,
+			}, "\n")
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/shorten/import", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/x-ndjson")
+			newImportRouter(svc, 2).ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+
+			results := decodeImportResults(t, rec.Body)
+			require.Len(t, results, 7)
+			assert.Equal(t, dto.ImportResult{Line: 1, OriginalURL: "https://example.com/a", ShortURL: "http://localhost:8080/promo", Status: service.BatchItemCreated}, results[0])
+			assert.Equal(t, 2, results[1].Line)
+			assert.Equal(t, service.BatchItemCreated, results[1].Status)
+			assert.Equal(t, dto.ImportResult{Line: 4, Status: service.BatchItemInvalid, Error: service.ImportErrorInvalidLine}, results[2])
+			assert.Equal(t, dto.ImportResult{Line: 5, OriginalURL: "https://example.com/c", Status: service.ImportItemConflict, Error: service.ImportErrorAliasTaken}, results[3])
+			assert.Equal(t, dto.ImportResult{Line: 6, OriginalURL: "https://example.com/d", Status: service.BatchItemInvalid, Error: service.ImportErrorInvalidAlias}, results[4])
+			assert.Equal(t, dto.ImportResult{Line: 7, OriginalURL: "http://example.com/taken", ShortURL: "http://localhost:8080/taken", Status: service.BatchItemExisting}, results[5])
+			assert.Equal(t, dto.ImportResult{Line: 8, OriginalURL: "https://example.com/e", Status: service.BatchItemInvalid, Error: service.ImportErrorExpired}, results[6])
+
+			originalURL, err := svc.Get(context.Background(), "promo")
+			require.NoError(t, err)
+			assert.Equal(t, "https://example.com/a", originalURL)
+		})
+	}
+}
+
+func TestImport_GzipCSV(t *testing.T) {
+	svc := &service.URLService{Store: memory.NewMemoryStore(), BaseURL: "http://localhost:8080"}
+
+	var body bytes.Buffer
+	gz := gzip.NewWriter(&body)
+	io.WriteString(gz, "original_url,alias,expires_at\nhttps://example.com/a,promo,\nhttps://example.com/b,,not-a-date\nhttps://example.com/c\n")
+	require.NoError(t, gz.Close())
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/shorten/import", &body)
+	req.Header.Set("Content-Type", "text/csv")
+	req.Header.Set("Content-Encoding", "gzip")
+	newImportRouter(svc, 100).ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	results := decodeImportResults(t, rec.Body)
+	require.Len(t, results, 3)
+	assert.Equal(t, dto.ImportResult{Line: 2, OriginalURL: "https://example.com/a", ShortURL: "http://localhost:8080/promo", Status: service.BatchItemCreated}, results[0])
+	assert.Equal(t, dto.ImportResult{Line: 3, Status: service.BatchItemInvalid, Error: service.ImportErrorInvalidLine}, results[1])
+	assert.Equal(t, 4, results[2].Line)
+	assert.Equal(t, service.BatchItemCreated, results[2].Status)
+}
+
+func TestImport_StopsOnQuota(t *testing.T) {
+	svc := &service.URLService{
+		Store:   memory.NewMemoryStore(),
+		Quota:   service.NewQuotaPolicy(map[string]int{"free": 2}, "free", nil),
+		BaseURL: "http://localhost:8080",
+	}
+
+	var body strings.Builder
+	for i := 0; i < 5; i++ {
+		body.WriteString(`{"original_url":"https://example.com/` + string(rune('a'+i)) + `"}` + "\n")
+	}
+	rec := httptest.NewRecorder()
+	newImportRouter(svc, 2).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/shorten/import", strings.NewReader(body.String())))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// Первая порция сохранена, вторая упёрлась в квоту, третья уже не читается
+	results := decodeImportResults(t, rec.Body)
+	require.Len(t, results, 4)
+	assert.Equal(t, service.BatchItemCreated, results[1].Status)
+	assert.Equal(t, dto.ImportResult{Line: 3, OriginalURL: "https://example.com/c", Status: service.ImportItemFailed, Error: service.ImportErrorQuota}, results[2])
+}
+
+func TestImport_ExpiredLinkIsGone(t *testing.T) {
+	store := memory.NewMemoryStore()
+	expiresAt := time.Now().Add(-time.Second)
+	_, err := store.SaveBatch(context.Background(), "test-user-id", []dto.BatchSaveItem{
+		{ShortURL: "old", OriginalURL: "https://example.com/old", ExpiresAt: &expiresAt},
+	})
+	require.NoError(t, err)
+
+	_, err = store.Get(context.Background(), "old")
+	assert.EqualError(t, err, "gone")
+}
+
+func TestImport_RejectsReservedAlias(t *testing.T) {
+	svc := &service.URLService{
+		Store:           memory.NewMemoryStore(),
+		BaseURL:         "http://localhost:8080",
+		ReservedAliases: []string{"healthz", "api"},
+	}
+	body := strings.Join([]string{
+		`{"original_url":"https://example.com/a","alias":"healthz"}`,
+		`{"original_url":"https://example.com/b","alias":"api"}`,
+		`{"original_url":"https://example.com/c","alias":"healthz2"}`,
+	}, "\n")
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/shorten/import", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	newImportRouter(svc, 10).ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	results := decodeImportResults(t, rec.Body)
+	require.Len(t, results, 3)
+	assert.Equal(t, dto.ImportResult{Line: 1, OriginalURL: "https://example.com/a", Status: service.BatchItemInvalid, Error: service.ImportErrorAliasReserved}, results[0])
+	assert.Equal(t, dto.ImportResult{Line: 2, OriginalURL: "https://example.com/b", Status: service.BatchItemInvalid, Error: service.ImportErrorAliasReserved}, results[1])
+	assert.Equal(t, service.BatchItemCreated, results[2].Status)
+}
+
+func TestImport_ReportsReadFailure(t *testing.T) {
+	svc := &service.URLService{Store: memory.NewMemoryStore(), BaseURL: "http://localhost:8080"}
+	body := `{"original_url":"https://example.com/a"}` + "\n" +
+		`{"original_url":"https://example.com/` + strings.Repeat("x", maxImportLineSize) + `"}` + "\n" +
+		`{"original_url":"https://example.com/c"}` + "\n"
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/shorten/import", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	newImportRouter(svc, 10).ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// Прочитанное сохраняется, а обрыв виден последней строкой ответа
+	results := decodeImportResults(t, rec.Body)
+	require.Len(t, results, 2)
+	assert.Equal(t, service.BatchItemCreated, results[0].Status)
+	assert.Equal(t, dto.ImportResult{Line: 2, Status: service.ImportItemFailed, Error: service.ImportErrorReadFailed}, results[1])
+}
+
+func TestImport_ReportsBrokenGzip(t *testing.T) {
+	svc := &service.URLService{Store: memory.NewMemoryStore(), BaseURL: "http://localhost:8080"}
+
+	var body bytes.Buffer
+	gz := gzip.NewWriter(&body)
+	_, err := gz.Write([]byte("https://example.com/a\nhttps://example.com/b\n"))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	truncated := body.Bytes()[:body.Len()-4]
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/shorten/import", bytes.NewReader(truncated))
+	req.Header.Set("Content-Type", "text/csv")
+	req.Header.Set("Content-Encoding", "gzip")
+	newImportRouter(svc, 10).ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	results := decodeImportResults(t, rec.Body)
+	require.NotEmpty(t, results)
+	last := results[len(results)-1]
+	assert.Equal(t, service.ImportItemFailed, last.Status)
+	assert.Equal(t, service.ImportErrorReadFailed, last.Error)
+}
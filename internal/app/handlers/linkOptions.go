@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/middlewares"
+	"github.com/DaniYer/GoProject.git/internal/app/service"
+	"github.com/go-chi/chi/v5"
+)
+
+// NewUpdateLinkOptionsHandler godoc
+// @Summary      Изменить настройки перехода по ссылке
+// @Description  Заменяет настройки перехода по активной ссылке текущего пользователя.
+// @Description  Незаданные поля возвращаются к умолчаниям сервера.
+// @Tags         urls
+// @Accept       json
+// @Param        id      path string          true "Короткий идентификатор ссылки"
+// @Param        request body dto.LinkOptions true "Новые настройки"
+// @Success      204 "Настройки сохранены"
+// @Failure      400 {string} string "invalid request, invalid redirect_type или invalid query_mode"
+// @Failure      404 {string} string "link not found"
+// @Failure      500 {string} string "internal error"
+// @Router       /api/user/urls/{id}/options [put]
+func NewUpdateLinkOptionsHandler(svc *service.URLService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(middlewares.UserIDKey).(string)
+
+		var opts dto.LinkOptions
+		if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+
+		err := svc.UpdateLinkOptions(r.Context(), userID, chi.URLParam(r, "id"), opts)
+		switch {
+		case errors.Is(err, service.ErrInvalidRedirectType), errors.Is(err, service.ErrInvalidQueryMode):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, service.ErrLinkNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case err != nil:
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
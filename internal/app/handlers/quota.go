@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/service"
+)
+
+// writeQuotaError отвечает 403 со структурированным описанием превышенной квоты.
+// Возвращает false, если err не связана с квотой и её нужно обработать вызывающему.
+func writeQuotaError(w http.ResponseWriter, err error) bool {
+	var quotaErr *service.QuotaExceededError
+	if !errors.As(err, &quotaErr) {
+		return false
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(dto.QuotaErrorResponse{
+		Error:     "quota_exceeded",
+		Message:   "active link limit reached for your tier",
+		Tier:      quotaErr.Tier,
+		Limit:     quotaErr.Limit,
+		Used:      quotaErr.Used,
+		Requested: quotaErr.Requested,
+	})
+	return true
+}
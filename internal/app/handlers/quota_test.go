@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/middlewares"
+	"github.com/DaniYer/GoProject.git/internal/app/service"
+	"github.com/DaniYer/GoProject.git/internal/app/storage/memory"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuota_RejectsOverLimit(t *testing.T) {
+	store := memory.NewMemoryStore()
+	svc := &service.URLService{
+		Store:   store,
+		Quota:   service.NewQuotaPolicy(map[string]int{"free": 2, "pro": 10}, "free", map[string]string{"vip": "pro"}),
+		BaseURL: "http://localhost:8080",
+	}
+
+	router := chi.NewRouter()
+	router.Use(middlewares.InjectTestUserIDMiddleware("test-user-id"))
+	router.Post("/", NewGenerateShortURLHandler(svc))
+	router.Post("/api/shorten/batch", NewBatchShortenURLHandler(svc))
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusCreated, post("/", "http://example.com/1").Code)
+
+	// Пакет из двух ссылок не помещается в оставшуюся квоту и не создаётся целиком
+	batch, _ := json.Marshal([]dto.BatchRequest{
+		{CorrelationID: "a", OriginalURL: "http://example.com/a"},
+		{CorrelationID: "b", OriginalURL: "http://example.com/b"},
+	})
+	rec := post("/api/shorten/batch", string(batch))
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	var quotaErr dto.QuotaErrorResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&quotaErr))
+	assert.Equal(t, dto.QuotaErrorResponse{
+		Error:     "quota_exceeded",
+		Message:   "active link limit reached for your tier",
+		Tier:      "free",
+		Limit:     2,
+		Used:      1,
+		Requested: 2,
+	}, quotaErr)
+
+	assert.Equal(t, http.StatusCreated, post("/", "http://example.com/2").Code)
+	assert.Equal(t, http.StatusForbidden, post("/", "http://example.com/3").Code)
+
+	// Уже существующая ссылка квоту не расходует
+	assert.Equal(t, http.StatusConflict, post("/", "http://example.com/1").Code)
+
+	// И пакет из уже существующих ссылок у исчерпанной квоты проходит
+	batch, _ = json.Marshal([]dto.BatchRequest{
+		{CorrelationID: "a", OriginalURL: "http://example.com/1"},
+		{CorrelationID: "b", OriginalURL: "http://example.com/2"},
+	})
+	assert.Equal(t, http.StatusCreated, post("/api/shorten/batch", string(batch)).Code)
+
+	// После удаления место освобождается
+	urls, err := store.GetAllByUser(context.Background(), "test-user-id")
+	require.NoError(t, err)
+	_, err = store.BatchDelete(context.Background(), "test-user-id", []string{urls[0].ShortURL})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, post("/", "http://example.com/3").Code)
+}
+
+func TestQuota_DisabledWithoutTiers(t *testing.T) {
+	assert.Nil(t, service.NewQuotaPolicy(nil, "free", nil))
+
+	svc := &service.URLService{Store: memory.NewMemoryStore()}
+	router := chi.NewRouter()
+	router.Use(middlewares.InjectTestUserIDMiddleware("test-user-id"))
+	router.Post("/", NewGenerateShortURLHandler(svc))
+
+	for i := 0; i < 5; i++ {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString("http://example.com/"+string(rune('a'+i)))))
+		assert.Equal(t, http.StatusCreated, rec.Code)
+	}
+}
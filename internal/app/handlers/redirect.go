@@ -1,35 +1,86 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/metrics"
 	"github.com/DaniYer/GoProject.git/internal/app/service"
 	"github.com/go-chi/chi/v5"
 )
 
 // NewRedirectToOriginalURL godoc
 // @Summary      Перенаправление по короткой ссылке
-// @Description  Получает оригинальный URL по его короткому идентификатору и делает перенаправление.
+// @Description  Получает оригинальный URL по его короткому идентификатору и делает перенаправление
+// @Description  с кодом ссылки (redirect_type) или кодом сервера по умолчанию.
+// @Description  Постоянные перенаправления (301, 308) отдаются с Cache-Control: public, max-age,
+// @Description  не дольше срока жизни ссылки.
+// @Description  По настройкам ссылки строка запроса (query_mode) и путь после кода (forward_path, маршрут /{id}/*)
+// @Description  переносятся в адрес перенаправления; путь у ссылки без forward_path даёт 404.
 // @Tags         redirect
 // @Param        id   path      string  true  "Короткий идентификатор ссылки"
+// @Success      301  {string}  string  "Moved Permanently"
+// @Success      302  {string}  string  "Found"
 // @Success      307  {string}  string  "Temporary Redirect"
+// @Success      308  {string}  string  "Permanent Redirect"
+// @Failure      400  {string}  string  "invalid redirect path"
 // @Failure      404  {string}  string  "URL not found"
 // @Failure      410  {string}  string  "URL deleted"
 // @Router       /{id} [get]
-func NewRedirectToOriginalURL(svc *service.URLService) http.HandlerFunc {
+func NewRedirectToOriginalURL(svc *service.URLService, permanentMaxAge time.Duration) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		shortURL := chi.URLParam(r, "id")
 
-		originalURL, err := svc.Store.Get(shortURL)
+		link, err := svc.Resolve(r.Context(), shortURL, extraPath(r), r.URL.RawQuery)
+		if errors.Is(err, service.ErrInvalidRedirectPath) {
+			metrics.Redirects.WithLabelValues(metrics.RedirectMiss).Inc()
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		if err != nil {
 			if err.Error() == "gone" {
+				metrics.Redirects.WithLabelValues(metrics.RedirectGone).Inc()
 				http.Error(w, "URL deleted", http.StatusGone)
 				return
 			}
+			metrics.Redirects.WithLabelValues(metrics.RedirectMiss).Inc()
 			http.Error(w, "URL not found", http.StatusNotFound)
 			return
 		}
 
-		http.Redirect(w, r, originalURL, http.StatusTemporaryRedirect)
+		metrics.Redirects.WithLabelValues(metrics.RedirectHit).Inc()
+		if isPermanentRedirect(link.RedirectType) {
+			w.Header().Set("Cache-Control", permanentCacheControl(link, permanentMaxAge, time.Now()))
+		}
+		http.Redirect(w, r, link.OriginalURL, link.RedirectType)
+	}
+}
+
+// extraPath возвращает путь после кода ссылки так, как он пришёл в запросе. Параметр "*"
+// у chi раскодирован, если в запросе нет RawPath, и после склейки %25 и %2F исказились бы.
+func extraPath(r *http.Request) string {
+	if chi.URLParam(r, "*") == "" {
+		return ""
+	}
+	_, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.EscapedPath(), "/"), "/")
+	return rest
+}
+
+// isPermanentRedirect сообщает, что браузеры и прокси могут запомнить перенаправление.
+func isPermanentRedirect(code int) bool {
+	return code == http.StatusMovedPermanently || code == http.StatusPermanentRedirect
+}
+
+// permanentCacheControl ограничивает кэширование постоянного перенаправления сроком maxAge,
+// а для ссылки со сроком жизни — моментом её истечения.
+func permanentCacheControl(link dto.ResolvedLink, maxAge time.Duration, now time.Time) string {
+	if link.ExpiresAt != nil {
+		maxAge = min(maxAge, link.ExpiresAt.Sub(now))
 	}
+	seconds := max(int64(maxAge/time.Second), 0)
+	return "public, max-age=" + strconv.FormatInt(seconds, 10)
 }
@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/DaniYer/GoProject.git/internal/app/dto"
 	"github.com/DaniYer/GoProject.git/internal/app/service"
@@ -13,29 +15,64 @@ import (
 )
 
 type MockRedirectStore struct {
-	GetFunc func(shortURL string) (string, error)
+	GetFunc     func(shortURL string) (string, error)
+	ResolveFunc func(shortURL string) (dto.ResolvedLink, error)
 }
 
-func (m *MockRedirectStore) Get(shortURL string) (string, error) {
+func (m *MockRedirectStore) Get(ctx context.Context, shortURL string) (string, error) {
 	if m.GetFunc != nil {
 		return m.GetFunc(shortURL)
 	}
 	return "", nil
 }
 
-func (m *MockRedirectStore) Save(shortURL, originalURL, userID string) (string, error) {
+func (m *MockRedirectStore) Resolve(ctx context.Context, shortURL string) (dto.ResolvedLink, error) {
+	if m.ResolveFunc != nil {
+		return m.ResolveFunc(shortURL)
+	}
+	originalURL, err := m.Get(ctx, shortURL)
+	if err != nil {
+		return dto.ResolvedLink{}, err
+	}
+	return dto.ResolvedLink{OriginalURL: originalURL}, nil
+}
+
+func (m *MockRedirectStore) UpdateLinkOptions(ctx context.Context, userID, shortURL string, opts dto.LinkOptions) error {
+	return nil
+}
+
+func (m *MockRedirectStore) Save(ctx context.Context, shortURL, originalURL, userID string) (string, error) {
 	return shortURL, nil
 }
 
-func (m *MockRedirectStore) GetByOriginalURL(originalURL string) (string, error) {
+func (m *MockRedirectStore) SaveBatch(ctx context.Context, userID string, items []dto.BatchSaveItem) ([]dto.BatchSaveItem, error) {
+	for i, item := range items {
+		shortURL, err := m.Save(ctx, item.ShortURL, item.OriginalURL, userID)
+		if err != nil {
+			return nil, err
+		}
+		items[i].ShortURL = shortURL
+	}
+	return items, nil
+}
+
+func (m *MockRedirectStore) GetByOriginalURL(ctx context.Context, originalURL string) (string, error) {
 	return "", nil
 }
 
-func (m *MockRedirectStore) GetAllByUser(userID string) ([]dto.UserURL, error) {
+func (m *MockRedirectStore) GetAllByUser(ctx context.Context, userID string) ([]dto.UserURL, error) {
+	return nil, nil
+}
+
+func (m *MockRedirectStore) BatchDelete(ctx context.Context, userID string, shortURLs []string) ([]dto.DeleteResult, error) {
 	return nil, nil
 }
 
-func (m *MockRedirectStore) BatchDelete(userID string, shortURLs []string) error {
+func (m *MockRedirectStore) CountActiveByUser(ctx context.Context, userID string) (int, error) {
+	return 0, nil
+}
+
+func (m *MockRedirectStore) IterateByUser(ctx context.Context, userID string, fn func(dto.LinkExport) error) error {
 	return nil
 }
 
@@ -55,7 +92,7 @@ func TestRedirectToOriginalURL_Success(t *testing.T) {
 	}
 
 	router := chi.NewRouter()
-	router.Get("/{id}", NewRedirectToOriginalURL(svc))
+	router.Get("/{id}", NewRedirectToOriginalURL(svc, 0))
 
 	req := httptest.NewRequest(http.MethodGet, "/abcd1234", nil)
 	rec := httptest.NewRecorder()
@@ -69,3 +106,105 @@ func TestRedirectToOriginalURL_Success(t *testing.T) {
 	location := res.Header.Get("Location")
 	assert.Equal(t, "http://example.com", location)
 }
+
+func TestRedirectToOriginalURL_RedirectType(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+	links := map[string]dto.ResolvedLink{
+		"temp":    {OriginalURL: "http://example.com/temp"},
+		"found":   {OriginalURL: "http://example.com/found", LinkOptions: dto.LinkOptions{RedirectType: http.StatusFound}},
+		"perm":    {OriginalURL: "http://example.com/perm", LinkOptions: dto.LinkOptions{RedirectType: http.StatusMovedPermanently}},
+		"expires": {OriginalURL: "http://example.com/expires", ExpiresAt: &expiresAt, LinkOptions: dto.LinkOptions{RedirectType: http.StatusPermanentRedirect}},
+	}
+	mockStore := &MockRedirectStore{
+		ResolveFunc: func(shortURL string) (dto.ResolvedLink, error) {
+			link, ok := links[shortURL]
+			if !ok {
+				return dto.ResolvedLink{}, errors.New("not found")
+			}
+			return link, nil
+		},
+	}
+	svc := &service.URLService{Store: mockStore, RedirectType: http.StatusTemporaryRedirect}
+
+	router := chi.NewRouter()
+	router.Get("/{id}", NewRedirectToOriginalURL(svc, 24*time.Hour))
+
+	tests := []struct {
+		id           string
+		status       int
+		cacheControl string
+	}{
+		{id: "temp", status: http.StatusTemporaryRedirect},
+		{id: "found", status: http.StatusFound},
+		{id: "perm", status: http.StatusMovedPermanently, cacheControl: "public, max-age=86400"},
+		// Кэш постоянного перенаправления не переживает ссылку
+		{id: "expires", status: http.StatusPermanentRedirect, cacheControl: "public, max-age=3599"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/"+tt.id, nil))
+
+			assert.Equal(t, tt.status, rec.Code)
+			assert.Equal(t, links[tt.id].OriginalURL, rec.Header().Get("Location"))
+			assert.Equal(t, tt.cacheControl, rec.Header().Get("Cache-Control"))
+		})
+	}
+}
+
+func TestPermanentCacheControl_ExpiredLink(t *testing.T) {
+	now := time.Now()
+	expiresAt := now.Add(-time.Second)
+	link := dto.ResolvedLink{ExpiresAt: &expiresAt}
+	assert.Equal(t, "public, max-age=0", permanentCacheControl(link, time.Hour, now))
+}
+
+func TestRedirectToOriginalURL_Passthrough(t *testing.T) {
+	links := map[string]dto.ResolvedLink{
+		"plain":    {OriginalURL: "https://example.com/landing?ref=own"},
+		"merge":    {OriginalURL: "https://example.com/landing?ref=own&b=1", LinkOptions: dto.LinkOptions{QueryMode: service.QueryModeMerge}},
+		"override": {OriginalURL: "https://example.com/landing?ref=own&b=1#top", LinkOptions: dto.LinkOptions{QueryMode: service.QueryModeOverride}},
+		"docs":     {OriginalURL: "https://example.com/docs/", LinkOptions: dto.LinkOptions{ForwardPath: true, QueryMode: service.QueryModeMerge}},
+	}
+	mockStore := &MockRedirectStore{
+		ResolveFunc: func(shortURL string) (dto.ResolvedLink, error) {
+			link, ok := links[shortURL]
+			if !ok {
+				return dto.ResolvedLink{}, errors.New("not found")
+			}
+			return link, nil
+		},
+	}
+	svc := &service.URLService{Store: mockStore}
+
+	router := chi.NewRouter()
+	handler := NewRedirectToOriginalURL(svc, 0)
+	router.Get("/{id}", handler)
+	router.Get("/{id}/*", handler)
+
+	tests := []struct {
+		name     string
+		path     string
+		status   int
+		location string
+	}{
+		{name: "drop by default", path: "/plain?utm_source=mail", status: http.StatusTemporaryRedirect, location: "https://example.com/landing?ref=own"},
+		{name: "merge keeps own values", path: "/merge?ref=ads&utm_source=mail", status: http.StatusTemporaryRedirect, location: "https://example.com/landing?ref=own&b=1&utm_source=mail"},
+		{name: "override replaces own values", path: "/override?ref=ads&utm_source=mail", status: http.StatusTemporaryRedirect, location: "https://example.com/landing?b=1&ref=ads&utm_source=mail#top"},
+		{name: "forward path", path: "/docs/guide/install?v=2", status: http.StatusTemporaryRedirect, location: "https://example.com/docs/guide/install?v=2"},
+		{name: "forward escaped path", path: "/docs/a%20b", status: http.StatusTemporaryRedirect, location: "https://example.com/docs/a%20b"},
+		{name: "forward escaped percent", path: "/docs/100%25", status: http.StatusTemporaryRedirect, location: "https://example.com/docs/100%25"},
+		{name: "forward percent is not decoded twice", path: "/docs/a%2541", status: http.StatusTemporaryRedirect, location: "https://example.com/docs/a%2541"},
+		{name: "forward escaped slash", path: "/docs/a%2Fb/c", status: http.StatusTemporaryRedirect, location: "https://example.com/docs/a%2Fb/c"},
+		{name: "path without forward_path", path: "/plain/guide", status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.status, rec.Code)
+			assert.Equal(t, tt.location, rec.Header().Get("Location"))
+		})
+	}
+}
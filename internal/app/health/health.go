@@ -0,0 +1,88 @@
+// Package health собирает проверки готовности компонентов сервиса
+// (хранилище, воркеры) для эндпоинта /readyz.
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+)
+
+// Статусы компонента и сервиса в целом.
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// CheckFunc проверяет один компонент. nil означает, что компонент готов.
+type CheckFunc func(ctx context.Context) error
+
+// Pinger — компонент, который умеет проверить собственную доступность.
+// Реализуется хранилищами.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Checker — набор именованных проверок готовности.
+type Checker struct {
+	timeout time.Duration
+	names   []string
+	checks  map[string]CheckFunc
+}
+
+// NewChecker создаёт набор проверок. timeout ограничивает каждую проверку,
+// чтобы зависшая БД не подвешивала и сам /readyz.
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{
+		timeout: timeout,
+		checks:  make(map[string]CheckFunc),
+	}
+}
+
+// Register добавляет проверку компонента name.
+func (c *Checker) Register(name string, check CheckFunc) {
+	if _, ok := c.checks[name]; !ok {
+		c.names = append(c.names, name)
+	}
+	c.checks[name] = check
+}
+
+// Check параллельно выполняет все проверки и возвращает отчёт по компонентам.
+// Сервис готов, только если готовы все компоненты.
+func (c *Checker) Check(ctx context.Context) dto.HealthReport {
+	report := dto.HealthReport{
+		Status:     StatusOK,
+		Components: make(map[string]dto.ComponentHealth, len(c.names)),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, name := range c.names {
+		wg.Add(1)
+		go func(name string, check CheckFunc) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+
+			component := dto.ComponentHealth{Status: StatusOK}
+			if err := check(checkCtx); err != nil {
+				component = dto.ComponentHealth{Status: StatusFail, Error: err.Error()}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Components[name] = component
+			if component.Status != StatusOK {
+				report.Status = StatusFail
+			}
+		}(name, c.checks[name])
+	}
+	wg.Wait()
+
+	return report
+}
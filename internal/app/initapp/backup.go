@@ -0,0 +1,94 @@
+package initapp
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os/signal"
+	"syscall"
+
+	"github.com/DaniYer/GoProject.git/internal/app/backup"
+	"github.com/DaniYer/GoProject.git/internal/app/migrate"
+)
+
+const backupUsage = `Использование: shortener backup -from <хранилище> -out <файл>
+
+Снимает резервную копию в формате, не зависящем от хранилища.
+Хранилище: file:<путь>, sqlite:<путь> или postgres:<DSN>. Оно только читается:
+миграции не применяются, несуществующий файл даёт ошибку.
+`
+
+const restoreUsage = `Использование: shortener restore -to <хранилище> -in <файл>
+
+Загружает резервную копию в пустое хранилище.
+Хранилище: file:<путь>, sqlite:<путь> или postgres:<DSN>.
+`
+
+// RunBackupCommand выполняет подкоманду backup и пишет итог в out.
+func RunBackupCommand(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("backup", flag.ContinueOnError)
+	flags.SetOutput(out)
+	flags.Usage = func() { fmt.Fprint(out, backupUsage) }
+	from := flags.String("from", "", "Хранилище, с которого снимается копия")
+	path := flags.String("out", "", "Файл копии")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *from == "" || *path == "" {
+		flags.Usage()
+		return errors.New("нужно указать -from и -out")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	src, closeSrc, err := migrate.OpenStoreReadOnly(*from)
+	if err != nil {
+		return err
+	}
+	defer closeSrc()
+
+	stats, err := backup.WriteFile(ctx, *path, src)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "копия %s: %d ссылок, %d API-ключей\n", *path, stats.Links, stats.APIKeys)
+	return nil
+}
+
+// RunRestoreCommand выполняет подкоманду restore и пишет итог в out.
+func RunRestoreCommand(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("restore", flag.ContinueOnError)
+	flags.SetOutput(out)
+	flags.Usage = func() { fmt.Fprint(out, restoreUsage) }
+	to := flags.String("to", "", "Пустое хранилище, в которое загружается копия")
+	path := flags.String("in", "", "Файл копии")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *to == "" || *path == "" {
+		flags.Usage()
+		return errors.New("нужно указать -to и -in")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	dst, closeDst, err := migrate.OpenStore(*to)
+	if err != nil {
+		return err
+	}
+	defer closeDst()
+
+	stats, found, err := backup.RestoreFile(ctx, *path, dst)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("файл %s не найден", *path)
+	}
+	fmt.Fprintf(out, "восстановлено %d ссылок, %d API-ключей\n", stats.Links, stats.APIKeys)
+	return nil
+}
@@ -3,25 +3,35 @@
 package initapp
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	_ "github.com/DaniYer/GoProject.git/api/docs" // импортируем для генерации Swagger документации
+	"github.com/DaniYer/GoProject.git/internal/app/backup"
+	"github.com/DaniYer/GoProject.git/internal/app/cache"
 	"github.com/DaniYer/GoProject.git/internal/app/config"
 	"github.com/DaniYer/GoProject.git/internal/app/handlers"
+	"github.com/DaniYer/GoProject.git/internal/app/health"
+	"github.com/DaniYer/GoProject.git/internal/app/metrics"
 	"github.com/DaniYer/GoProject.git/internal/app/middlewares"
 	"github.com/DaniYer/GoProject.git/internal/app/service"
 	"github.com/DaniYer/GoProject.git/internal/app/storage/database"
 	"github.com/DaniYer/GoProject.git/internal/app/storage/file"
 	"github.com/DaniYer/GoProject.git/internal/app/storage/memory"
+	"github.com/DaniYer/GoProject.git/internal/app/storage/sqlite"
+	"github.com/DaniYer/GoProject.git/internal/app/tracing"
 	"github.com/DaniYer/GoProject.git/internal/app/worker"
 	"github.com/go-chi/chi/v5"
-	_ "github.com/jackc/pgx/v5/stdlib" // PostgreSQL драйвер
-	"github.com/pressly/goose"         // Миграции
 	httpSwagger "github.com/swaggo/http-swagger"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 // InitializeApp инициализирует конфигурацию, логирование, подключение к базе данных,
@@ -31,8 +41,8 @@ func InitializeApp() error {
 	// Загружаем конфигурацию приложения (параметры сервера, DSN, пути к файлам и т.д.)
 	cfg := config.NewConfig()
 
-	// Инициализация логгера
-	logger, err := zap.NewDevelopment()
+	// Инициализация логгера: JSON в stdout с уровнем из конфигурации
+	logger, err := newLogger(cfg.LogLevel)
 	if err != nil {
 		return fmt.Errorf("init logger error: %w", err)
 	}
@@ -40,23 +50,80 @@ func InitializeApp() error {
 	sugar := logger.Sugar()
 	middlewares.InitLogger(sugar)
 
+	if err := service.ValidateRedirectType(cfg.RedirectStatus); err != nil {
+		return fmt.Errorf("REDIRECT_STATUS %d: %w", cfg.RedirectStatus, err)
+	}
+
+	// Трассировка OpenTelemetry
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Config{
+		Exporter:    cfg.TracingExporter,
+		Endpoint:    cfg.TracingEndpoint,
+		ServiceName: cfg.TracingServiceName,
+		SampleRatio: cfg.TracingSampleRatio,
+	})
+	if err != nil {
+		return fmt.Errorf("init tracing error: %w", err)
+	}
+	defer shutdownTracing(context.Background())
+
 	var (
-		db    *sql.DB
-		store service.URLStore
+		db      *sql.DB
+		store   service.URLStore
+		backend string
 	)
 
-	// Если указан DSN базы данных — подключаем PostgreSQL и запускаем миграции
-	if cfg.DatabaseDSN != "" && cfg.DatabaseDSN != config.DefaultDatabaseDSN {
-		db, err = database.InitDB("pgx", cfg.DatabaseDSN)
+	// DSN вида sqlite://<путь> выбирает встроенную базу SQLite,
+	// любой другой непустой DSN — PostgreSQL; в обоих случаях применяются вшитые миграции
+	if path, ok := cfg.SQLitePath(); ok {
+		db, err = sqlite.OpenDB(path)
+		if err != nil {
+			sugar.Errorf("SQLite open error: %v", err)
+			return err
+		}
+		defer db.Close()
+		if err := migrateOnStart(cfg, sugar, db, sqlite.Migrate); err != nil {
+			return err
+		}
+		store = sqlite.NewSQLiteStore(db)
+		backend = "sqlite"
+	} else if cfg.DatabaseDSN != "" && cfg.DatabaseDSN != config.DefaultDatabaseDSN {
+		poolCfg := database.PoolConfig{
+			MaxConns:           cfg.DBMaxConns,
+			MinConns:           cfg.DBMinConns,
+			MaxConnLifetime:    cfg.DBMaxConnLifetime,
+			MaxConnIdleTime:    cfg.DBMaxConnIdleTime,
+			HealthCheckPeriod:  cfg.DBHealthCheckPeriod,
+			ConnectTimeout:     cfg.DBConnectTimeout,
+			StatementCacheMode: cfg.DBStatementCacheMode,
+		}
+		pool, err := database.NewPool(context.Background(), cfg.DatabaseDSN, poolCfg)
 		if err != nil {
 			sugar.Errorf("DB connect error: %v", err)
 			return err
 		}
-		if err := goose.Up(db, "internal/app/storage/database/migrations"); err != nil {
-			sugar.Errorf("Migration error: %v", err)
+		defer pool.Close()
+		metrics.RegisterPgxPool(pool.Stat)
+
+		// Миграциям и /ping нужен database/sql — он работает поверх того же пула
+		db = database.OpenSQL(pool)
+		defer db.Close()
+		if err := migrateOnStart(cfg, sugar, db, database.Migrate); err != nil {
 			return err
 		}
-		store = database.NewDBStore(db)
+		dbStore := database.NewDBStore(pool)
+		err = dbStore.AttachReplicas(context.Background(), database.ReplicaConfig{
+			DSNs:                cfg.DatabaseReplicaDSNs,
+			Pool:                poolCfg,
+			HealthCheckInterval: cfg.DBReplicaHealthCheckInterval,
+			StickyWindow:        cfg.DBReplicaStickyWindow,
+		}, sugar)
+		if err != nil {
+			sugar.Errorf("DB replica config error: %v", err)
+			return err
+		}
+		defer dbStore.Close()
+		store = dbStore
+		backend = "postgresql"
 	}
 
 	// Если БД нет, пробуем файловое хранилище
@@ -66,21 +133,133 @@ func InitializeApp() error {
 			sugar.Errorf("FileStore init error: %v", err)
 		} else {
 			store = fs
+			backend = "file"
 		}
 	}
 
 	// Если и файлового нет — используем in-memory
 	if store == nil {
 		sugar.Infof("Using in-memory storage")
-		store = memory.NewMemoryStore()
+		memStore := memory.NewMemoryStore()
+		if cfg.MemorySnapshotPath != "" {
+			stats, found, err := backup.RestoreFile(context.Background(), cfg.MemorySnapshotPath, memStore)
+			if err != nil {
+				sugar.Errorf("Memory snapshot load error: %v", err)
+				return err
+			}
+			if found {
+				sugar.Infow("Memory snapshot loaded", "links", stats.Links, "api_keys", stats.APIKeys)
+			}
+			// Срабатывает последним, уже после остановки воркеров и сброса счётчиков переходов
+			defer func() {
+				stats, err := backup.WriteFile(context.Background(), cfg.MemorySnapshotPath, memStore)
+				if err != nil {
+					sugar.Errorf("Memory snapshot write error: %v", err)
+					return
+				}
+				sugar.Infow("Memory snapshot written", "links", stats.Links, "api_keys", stats.APIKeys)
+			}()
+		}
+		store = memStore
+		backend = "memory"
+	}
+
+	// Персональные API-ключи хранятся в том же бэкенде, что и ссылки
+	keys, _ := store.(service.APIKeyStore)
+
+	// Журнал принятых удалений: outbox в базе данных, иначе JSONL-файл
+	var journal worker.Journal
+	if outbox, ok := store.(worker.Journal); ok {
+		journal = outbox
+	} else if cfg.DeleteJournalPath != "" {
+		fileJournal, err := worker.OpenFileJournal(cfg.DeleteJournalPath)
+		if err != nil {
+			sugar.Errorf("Delete journal open error: %v", err)
+			return err
+		}
+		defer fileJournal.Close()
+		journal = fileJournal
+	}
+
+	// Ключи идемпотентности: таблица в базе данных, иначе память процесса
+	idempotencyStore, ok := store.(middlewares.IdempotencyStore)
+	if !ok {
+		idempotencyStore = middlewares.NewMemoryIdempotencyStore()
+	}
+
+	// Счётчик переходов сбрасывается в то же хранилище, что и ссылки
+	var clicks *worker.ClickCounter
+	if clickStore, ok := store.(service.ClickStore); ok {
+		clicks = worker.NewClickCounter(clickStore, cfg.ClickFlushInterval, sugar)
+		clicks.Start()
+		defer clicks.Shutdown()
+	}
+
+	// Проверки готовности: активное хранилище и воркер удаления
+	readiness := health.NewChecker(2 * time.Second)
+	if pinger, ok := store.(health.Pinger); ok {
+		readiness.Register("storage", pinger.Ping)
 	}
 
+	// Переходы читаются через LRU-кэш; он сам сбрасывает записи при изменении ссылок.
+	// In-memory хранилище и так отвечает из памяти, кэш ему не нужен
+	if cfg.RedirectCacheSize > 0 && backend != "memory" {
+		cached := cache.NewStore(store, cache.Config{
+			Size:        cfg.RedirectCacheSize,
+			TTL:         cfg.RedirectCacheTTL,
+			NegativeTTL: cfg.RedirectCacheNegativeTTL,
+		})
+		metrics.RegisterRedirectCache(cached.Len, cached.HitRatio)
+		store = cached
+
+		// Ссылки, изменённые другими экземплярами, приходят через LISTEN/NOTIFY
+		if backend == "postgresql" {
+			listener := database.NewChangeListener(cfg.DatabaseDSN, func(change database.URLChange) {
+				cached.Invalidate(change.ShortURL)
+			}, cached.Purge, sugar)
+			listener.Start()
+			defer listener.Shutdown()
+		}
+	}
+
+	// Каждый вызов хранилища попадает в трассу отдельным спаном
+	store = tracing.NewStore(store, backend)
+
 	// Сервис работы с короткими ссылками
 	urlService := service.NewURLService(store, cfg.BaseURL)
+	urlService.RedirectType = cfg.RedirectStatus
+	urlService.Logger = sugar
+	urlService.Quota = service.NewQuotaPolicy(cfg.QuotaTierLimits(), cfg.QuotaDefaultTier, cfg.QuotaUserTierMap())
+
+	if clicks != nil {
+		urlService.Clicks = clicks
+	}
+	if keys != nil {
+		urlService.Keys = keys
+		middlewares.InitAPIKeyResolver(urlService)
+	}
 
 	// Запускаем пул воркеров для асинхронного удаления
-	workerPool := worker.NewDeleteWorkerPool(urlService, 1024, 5*time.Second)
+	var deadLetters worker.DeadLetterSink
+	if cfg.DeleteDeadLetterPath != "" {
+		deadLetters = worker.NewFileDeadLetters(cfg.DeleteDeadLetterPath, sugar)
+	}
+	workerPool := worker.NewDeleteWorkerPool(store, worker.Config{
+		QueueSize:     cfg.DeleteQueueSize,
+		BatchSize:     cfg.DeleteBatchSize,
+		FlushInterval: cfg.DeleteFlushInterval,
+		MaxRetries:    cfg.DeleteMaxRetries,
+		RetryBackoff:  cfg.DeleteRetryBackoff,
+		JournalLease:  cfg.DeleteJournalLease,
+		JobRetention:  cfg.DeleteJobRetention,
+	}, deadLetters, journal, sugar)
 	workerPool.Start()
+	defer workerPool.Shutdown()
+	metrics.RegisterDeleteQueue(workerPool.QueueDepth)
+	readiness.Register("delete_worker", workerPool.Ping)
+	if backend == "sqlite" {
+		metrics.RegisterDBStats(db)
+	}
 
 	// Создаём роутер
 	router := chi.NewRouter()
@@ -88,24 +267,110 @@ func InitializeApp() error {
 	sugar.Infow("Start server", "addr", cfg.ServerAddress)
 
 	// Подключаем middlewares
-	router.Use(middlewares.WithLogging)    // Логирование запросов
-	router.Use(middlewares.GzipHandle)     // Сжатие gzip
-	router.Use(middlewares.AuthMiddleware) // Авторизация через cookie
-
-	// Регистрация маршрутов
-	router.Post("/", handlers.NewGenerateShortURLHandler(urlService))
-	router.Post("/api/shorten", handlers.NewHandleShortenURLv13(urlService))
-	router.Post("/api/shorten/batch", handlers.NewBatchShortenURLHandler(urlService))
-	router.Get("/{id}", handlers.NewRedirectToOriginalURL(urlService))
+	router.Use(middlewares.RequestID)   // Идентификатор запроса для логов
+	router.Use(middlewares.WithLogging) // Логирование запросов
+	router.Use(middlewares.WithMetrics) // Метрики Prometheus
+	router.Use(middlewares.WithTracing) // Спаны OpenTelemetry
+	router.Use(middlewares.GzipHandle)  // Сжатие gzip
+
+	// Пробы и служебные маршруты идут без авторизации: иначе каждая проверка
+	// заводила бы нового пользователя и получала cookie
 	router.Get("/ping", handlers.PingDBInit(db))
-	router.Get("/api/user/urls", handlers.GetUserURLsHandler(urlService))
-	router.Delete("/api/user/urls", handlers.NewBatchDeleteHandler(urlService, workerPool))
+	router.Get("/healthz", handlers.NewLivenessHandler())
+	router.Get("/readyz", handlers.NewReadinessHandler(readiness))
+
+	// Лимиты частоты запросов — отдельные для каждой группы маршрутов
+	createLimit := middlewares.RateLimitUserAndIP(middlewares.NewRateLimiter(cfg.RateLimitCreateRPS, cfg.RateLimitCreateBurst))
+	redirectLimit := middlewares.RateLimit(middlewares.NewRateLimiter(cfg.RateLimitRedirectRPS, cfg.RateLimitRedirectBurst))
+	userLimit := middlewares.RateLimit(middlewares.NewRateLimiter(cfg.RateLimitUserRPS, cfg.RateLimitUserBurst))
+
+	// Первые сегменты путей, которые обслуживает сам сервер: такие псевдонимы
+	// при импорте отклоняются. Новый маршрут верхнего уровня нужно добавить сюда
+	urlService.ReservedAliases = []string{"ping", "healthz", "readyz", "metrics", "api", "swagger"}
+
+	// Регистрация маршрутов приложения
+	router.Group(func(app chi.Router) {
+		app.Use(middlewares.AuthMiddleware) // Авторизация через cookie или X-API-Key
+
+		app.Group(func(r chi.Router) {
+			r.Use(createLimit)
+			r.Use(middlewares.Idempotency(idempotencyStore, cfg.IdempotencyTTL))
+			r.Post("/", handlers.NewGenerateShortURLHandler(urlService))
+			r.Post("/api/shorten", handlers.NewHandleShortenURLv13(urlService))
+			r.Post("/api/shorten/batch", handlers.NewBatchShortenURLHandler(urlService))
+		})
+		// Импорт читается потоком, поэтому идёт без Idempotency, которая буферизует тело
+		app.With(createLimit).Post("/api/shorten/import", handlers.NewImportHandler(urlService, cfg.ImportChunkSize))
+		// /{id}/* — переход с путём после кода для ссылок с forward_path
+		redirect := handlers.NewRedirectToOriginalURL(urlService, cfg.RedirectPermanentMaxAge)
+		app.With(redirectLimit).Get("/{id}", redirect)
+		app.With(redirectLimit).Get("/{id}/*", redirect)
+		app.Group(func(r chi.Router) {
+			r.Use(userLimit)
+			r.Get("/api/user/urls", handlers.GetUserURLsHandler(urlService))
+			r.Get("/api/user/urls/export", handlers.NewExportUserURLsHandler(urlService))
+			r.Delete("/api/user/urls", handlers.NewBatchDeleteHandler(urlService, workerPool))
+			r.Put("/api/user/urls/{id}/options", handlers.NewUpdateLinkOptionsHandler(urlService))
+			r.Get("/api/user/jobs/{id}", handlers.NewDeleteJobStatusHandler(workerPool))
+			r.Post("/api/user/keys", handlers.NewCreateAPIKeyHandler(urlService))
+			r.Get("/api/user/keys", handlers.NewListAPIKeysHandler(urlService))
+			r.Delete("/api/user/keys/{id}", handlers.NewRevokeAPIKeyHandler(urlService))
+		})
+	})
 	// Подключаем Swagger UI
 	router.Get("/swagger/*", httpSwagger.WrapHandler)
-	// Запуск HTTP-сервера
-	if err := http.ListenAndServe(cfg.ServerAddress, router); err != nil {
+
+	// Метрики Prometheus: на отдельном служебном адресе, если он задан, иначе на основном
+	if cfg.AdminAddress != "" {
+		adminRouter := chi.NewRouter()
+		adminRouter.Handle("/metrics", metrics.Handler())
+		go func() {
+			sugar.Infow("Start admin server", "addr", cfg.AdminAddress)
+			if err := http.ListenAndServe(cfg.AdminAddress, adminRouter); err != nil {
+				sugar.Errorf("Admin server error: %v", err)
+			}
+		}()
+	} else {
+		router.Handle("/metrics", metrics.Handler())
+	}
+
+	// Запуск HTTP-сервера с корректной остановкой по SIGINT/SIGTERM:
+	// сервер дообрабатывает текущие запросы, затем воркер сбрасывает накопленные удаления
+	server := &http.Server{Addr: cfg.ServerAddress, Handler: router}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+		sugar.Infow("Shutting down server")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			sugar.Errorf("Server shutdown error: %v", err)
+		}
+	}()
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		sugar.Errorf("Server error: %v", err)
+		return err
 	}
+	// Дожидаемся завершения активных запросов, прежде чем останавливать воркер
+	<-stopped
 
 	return nil
 }
+
+// newLogger создаёт production-логгер zap (JSON, ISO8601-время) с заданным уровнем.
+func newLogger(level string) (*zap.Logger, error) {
+	lvl, err := zap.ParseAtomicLevel(level)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := zap.NewProductionConfig()
+	cfg.Level = lvl
+	cfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	return cfg.Build()
+}
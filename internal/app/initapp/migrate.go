@@ -0,0 +1,144 @@
+package initapp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/config"
+	"github.com/DaniYer/GoProject.git/internal/app/storage/database"
+	"github.com/DaniYer/GoProject.git/internal/app/storage/sqlite"
+	"github.com/pressly/goose/v3"
+	"go.uber.org/zap"
+)
+
+// migrateOnStart применяет миграции при старте, если они не отключены SKIP_MIGRATIONS.
+func migrateOnStart(cfg *config.Config, sugar *zap.SugaredLogger, db *sql.DB, migrate func(context.Context, *sql.DB) error) error {
+	if cfg.SkipMigrations {
+		sugar.Infof("Auto-migration disabled, schema is expected to be up to date")
+		return nil
+	}
+	if err := migrate(context.Background(), db); err != nil {
+		sugar.Errorf("Migration error: %v", err)
+		return err
+	}
+	return nil
+}
+
+const migrateUsage = `Использование: shortener migrate [-d DSN] up|down|status
+
+  up      применить все непримёненные миграции
+  down    откатить последнюю применённую миграцию
+  status  показать состояние каждой миграции
+
+DSN берётся из -d или DATABASE_DSN: строка подключения PostgreSQL или sqlite://<путь>.
+`
+
+// RunMigrateCommand выполняет подкоманду migrate над вшитыми миграциями
+// и пишет результат в out.
+func RunMigrateCommand(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	flags.SetOutput(out)
+	flags.Usage = func() { fmt.Fprint(out, migrateUsage) }
+	dsn := flags.String("d", os.Getenv("DATABASE_DSN"), "Строка подключения к базе данных")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 || *dsn == "" {
+		flags.Usage()
+		return errors.New("не задана команда или DSN")
+	}
+
+	provider, closeDB, err := openMigrator(*dsn)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	ctx := context.Background()
+	switch flags.Arg(0) {
+	case "up":
+		results, err := provider.Up(ctx)
+		for _, result := range results {
+			fmt.Fprintf(out, "applied %s (%s)\n", result.Source.Path, result.Duration.Round(time.Millisecond))
+		}
+		if err != nil {
+			return err
+		}
+		if len(results) == 0 {
+			fmt.Fprintln(out, "no pending migrations")
+		}
+		return nil
+	case "down":
+		result, err := provider.Down(ctx)
+		if errors.Is(err, goose.ErrNoNextVersion) {
+			fmt.Fprintln(out, "no applied migrations")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "rolled back %s (%s)\n", result.Source.Path, result.Duration.Round(time.Millisecond))
+		return nil
+	case "status":
+		statuses, err := provider.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tSTATE\tAPPLIED AT\tFILE")
+		for _, status := range statuses {
+			appliedAt := "-"
+			if status.State == goose.StateApplied {
+				appliedAt = status.AppliedAt.UTC().Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", status.Source.Version, status.State, appliedAt, status.Source.Path)
+		}
+		return w.Flush()
+	default:
+		flags.Usage()
+		return fmt.Errorf("неизвестная команда %q", flags.Arg(0))
+	}
+}
+
+// openMigrator подключается к базе по DSN и возвращает провайдер её миграций.
+func openMigrator(dsn string) (*goose.Provider, func(), error) {
+	var (
+		db       *sql.DB
+		closeDB  func()
+		migrator func(*sql.DB) (*goose.Provider, error)
+	)
+	if path, ok := strings.CutPrefix(dsn, config.SQLiteDSNPrefix); ok {
+		sqliteDB, err := sqlite.OpenDB(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		db, closeDB = sqliteDB, func() { sqliteDB.Close() }
+		migrator = sqlite.NewMigrator
+	} else {
+		pool, err := database.NewPool(context.Background(), dsn, database.PoolConfig{})
+		if err != nil {
+			return nil, nil, err
+		}
+		db = database.OpenSQL(pool)
+		closeDB = func() {
+			db.Close()
+			pool.Close()
+		}
+		migrator = database.NewMigrator
+	}
+
+	provider, err := migrator(db)
+	if err != nil {
+		closeDB()
+		return nil, nil, err
+	}
+	return provider, closeDB, nil
+}
@@ -0,0 +1,211 @@
+// Package metrics содержит метрики Prometheus сервиса сокращения ссылок
+// и HTTP-обработчик, который отдаёт их в текстовом формате.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace — общий префикс всех метрик сервиса.
+const namespace = "shortener"
+
+// Registry — реестр метрик приложения. Отдельный от глобального реестра Prometheus,
+// чтобы в /metrics попадало только то, что зарегистрировали мы.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequests — число обработанных HTTP-запросов по методу, шаблону маршрута chi и статусу.
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of HTTP requests by method, chi route pattern and status.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPDuration — время обработки HTTP-запросов.
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, chi route pattern and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// Redirects — результаты переходов по коротким ссылкам: hit, miss или gone.
+	Redirects = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redirects_total",
+		Help:      "Redirect lookups by result (hit, miss, gone).",
+	}, []string{"result"})
+
+	// RedirectCacheLookups — обращения к кэшу переходов: hit или miss.
+	RedirectCacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redirect_cache_lookups_total",
+		Help:      "Redirect cache lookups by result (hit, miss).",
+	}, []string{"result"})
+
+	// RedirectCacheEvictions — записи, вытесненные из кэша переходов из-за нехватки места.
+	RedirectCacheEvictions = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redirect_cache_evictions_total",
+		Help:      "Redirect cache entries evicted to make room for new ones.",
+	})
+
+	// DeleteFlushDuration — длительность сброса пачки удалений в хранилище.
+	DeleteFlushDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "delete_flush_duration_seconds",
+		Help:      "Time spent flushing a batch of deletions to the store.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	// DeleteRetries — число повторов неудачного BatchDelete.
+	DeleteRetries = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "delete_retries_total",
+		Help:      "Retries of failed batch deletions.",
+	})
+
+	// DeleteDeadLetters — число пачек удаления, отправленных в dead letter после всех попыток.
+	DeleteDeadLetters = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "delete_dead_letters_total",
+		Help:      "Batch deletions that failed permanently and were dead-lettered.",
+	})
+
+	// DeleteRejected — число запросов на удаление, отклонённых из-за заполненной очереди.
+	DeleteRejected = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "delete_rejected_total",
+		Help:      "Delete requests rejected because the queue was full.",
+	})
+
+	// DBReads — чтения ссылок из PostgreSQL по месту выполнения: реплика или основная база.
+	DBReads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_reads_total",
+		Help:      "Link reads routed to a read replica or to the primary database.",
+	}, []string{"target"})
+)
+
+// Результаты перехода по короткой ссылке для метрики Redirects.
+const (
+	RedirectHit  = "hit"
+	RedirectMiss = "miss"
+	RedirectGone = "gone"
+)
+
+// Результаты обращения к кэшу переходов для метрики RedirectCacheLookups.
+const (
+	CacheHit  = "hit"
+	CacheMiss = "miss"
+)
+
+// Места выполнения чтения для метрики DBReads.
+const (
+	DBReadReplica = "replica"
+	DBReadPrimary = "primary"
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPDuration,
+		Redirects,
+		RedirectCacheLookups,
+		RedirectCacheEvictions,
+		DeleteFlushDuration,
+		DeleteRetries,
+		DeleteDeadLetters,
+		DeleteRejected,
+		DBReads,
+	)
+}
+
+// RegisterDeleteQueue регистрирует метрику глубины очереди удаления.
+// depth вызывается при каждом сборе метрик.
+func RegisterDeleteQueue(depth func() int) {
+	Registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "delete_queue_depth",
+		Help:      "Deletions accepted but not yet flushed to the store.",
+	}, func() float64 {
+		return float64(depth())
+	}))
+}
+
+// RegisterRedirectCache регистрирует размер кэша переходов и долю попаданий в него
+// с момента запуска. Функции вызываются при каждом сборе метрик.
+func RegisterRedirectCache(entries func() int, hitRatio func() float64) {
+	Registry.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "redirect_cache_entries",
+			Help:      "Entries currently held in the redirect cache.",
+		}, func() float64 {
+			return float64(entries())
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "redirect_cache_hit_ratio",
+			Help:      "Share of redirect cache lookups served from the cache since start.",
+		}, hitRatio),
+	)
+}
+
+// RegisterDBStats регистрирует статистику пула соединений database/sql.
+func RegisterDBStats(db *sql.DB) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(db, namespace))
+}
+
+// RegisterPgxPool регистрирует статистику пула соединений pgx. stat вызывается
+// при каждом сборе метрик, обычно это (*pgxpool.Pool).Stat.
+func RegisterPgxPool(stat func() *pgxpool.Stat) {
+	gauge := func(name, help string, value func(*pgxpool.Stat) float64) prometheus.Collector {
+		return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "db_pool",
+			Name:      name,
+			Help:      help,
+		}, func() float64 { return value(stat()) })
+	}
+	counter := func(name, help string, value func(*pgxpool.Stat) float64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "db_pool",
+			Name:      name,
+			Help:      help,
+		}, func() float64 { return value(stat()) })
+	}
+
+	Registry.MustRegister(
+		gauge("max_connections", "Maximum size of the pool.",
+			func(s *pgxpool.Stat) float64 { return float64(s.MaxConns()) }),
+		gauge("connections", "Connections currently in the pool.",
+			func(s *pgxpool.Stat) float64 { return float64(s.TotalConns()) }),
+		gauge("acquired_connections", "Connections currently in use.",
+			func(s *pgxpool.Stat) float64 { return float64(s.AcquiredConns()) }),
+		gauge("idle_connections", "Idle connections in the pool.",
+			func(s *pgxpool.Stat) float64 { return float64(s.IdleConns()) }),
+		counter("acquires_total", "Successful connection acquires from the pool.",
+			func(s *pgxpool.Stat) float64 { return float64(s.AcquireCount()) }),
+		counter("empty_acquires_total", "Acquires that had to wait for a connection.",
+			func(s *pgxpool.Stat) float64 { return float64(s.EmptyAcquireCount()) }),
+		counter("canceled_acquires_total", "Acquires canceled by their context.",
+			func(s *pgxpool.Stat) float64 { return float64(s.CanceledAcquireCount()) }),
+		counter("acquire_wait_seconds_total", "Total time spent acquiring connections.",
+			func(s *pgxpool.Stat) float64 { return s.AcquireDuration().Seconds() }),
+	)
+}
+
+// Handler возвращает HTTP-обработчик /metrics в текстовом формате Prometheus.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
-	"log"
+	"errors"
 	"math/rand"
 	"net/http"
 	"strings"
+
+	"github.com/DaniYer/GoProject.git/internal/app/service"
 )
 
 // contextKey — собственный тип для ключей контекста, чтобы избежать коллизий.
@@ -16,6 +18,26 @@ type contextKey string
 // UserIDKey — ключ для хранения userID в контексте запроса.
 const UserIDKey contextKey = "userID"
 
+// freshUserKey — признак того, что userID выдан в этом же запросе, а не пришёл от клиента.
+const freshUserKey contextKey = "freshUser"
+
+// APIKeyHeader — заголовок, в котором скрипты и CI передают персональный API-ключ.
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyResolver — источник, по которому API-ключ сопоставляется с userID.
+// Реализуется service.URLService.
+type APIKeyResolver interface {
+	ResolveAPIKey(ctx context.Context, key string) (string, error)
+}
+
+var apiKeys APIKeyResolver
+
+// InitAPIKeyResolver подключает проверку API-ключей в AuthMiddleware.
+// Вызывается один раз при старте приложения.
+func InitAPIKeyResolver(resolver APIKeyResolver) {
+	apiKeys = resolver
+}
+
 // secretKey — секрет для подписи userID в cookie.
 // Для учебного проекта можно захардкодить, в проде лучше хранить в переменных окружения.
 const secretKey = "practicum_secret_key"
@@ -26,9 +48,35 @@ const secretKey = "practicum_secret_key"
 // 3. Если есть, проверяет подпись.
 // 4. Если подпись невалидна — генерирует новый userID.
 // 5. Кладёт userID в контекст, чтобы его могли использовать обработчики дальше.
+//
+// Если передан заголовок X-API-Key, кука не используется: userID берётся у владельца ключа,
+// а неизвестный или отозванный ключ отклоняется с 401.
 func AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
+		// Программный доступ по API-ключу
+		if key := r.Header.Get(APIKeyHeader); key != "" {
+			if apiKeys == nil {
+				http.Error(w, "invalid api key", http.StatusUnauthorized)
+				return
+			}
+			userID, err := apiKeys.ResolveAPIKey(r.Context(), key)
+			if errors.Is(err, service.ErrAPIKeyNotFound) {
+				LoggerFromContext(r.Context()).Debugw("auth: unknown api key")
+				http.Error(w, "invalid api key", http.StatusUnauthorized)
+				return
+			}
+			if err != nil {
+				LoggerFromContext(r.Context()).Errorw("auth: api key lookup failed", "error", err)
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			LoggerFromContext(r.Context()).Debugw("auth: user from api key", "user_id", userID)
+			ctx := context.WithValue(r.Context(), UserIDKey, userID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
 		// Пытаемся достать куку "auth"
 		cookie, err := r.Cookie("auth")
 		if err != nil {
@@ -43,8 +91,9 @@ func AuthMiddleware(next http.Handler) http.Handler {
 				Path:  "/",
 			})
 
-			log.Printf("[AUTH] Кука не найдена, сгенерирован новый userID: %s", userID)
+			LoggerFromContext(r.Context()).Debugw("auth: cookie not found, issued new user", "user_id", userID)
 			ctx := context.WithValue(r.Context(), UserIDKey, userID)
+			ctx = context.WithValue(ctx, freshUserKey, true)
 			next.ServeHTTP(w, r.WithContext(ctx))
 			return
 		}
@@ -53,7 +102,7 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		parts := strings.Split(cookie.Value, "|")
 		if len(parts) != 2 {
 			// Формат неверный → генерируем нового пользователя
-			log.Printf("[AUTH] Некорректная кука: %s", cookie.Value)
+			LoggerFromContext(r.Context()).Debugw("auth: malformed cookie")
 			userID := generateUserID()
 			signed := sign(userID)
 
@@ -63,8 +112,9 @@ func AuthMiddleware(next http.Handler) http.Handler {
 				Path:  "/",
 			})
 
-			log.Printf("[AUTH] Сгенерирован новый userID: %s", userID)
+			LoggerFromContext(r.Context()).Debugw("auth: issued new user", "user_id", userID)
 			ctx := context.WithValue(r.Context(), UserIDKey, userID)
+			ctx = context.WithValue(ctx, freshUserKey, true)
 			next.ServeHTTP(w, r.WithContext(ctx))
 			return
 		}
@@ -75,7 +125,7 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		// Проверяем подпись
 		if sign(userID) != signature {
 			// Подпись неверная → генерируем нового пользователя
-			log.Printf("[AUTH] Некорректная подпись куки: %s", cookie.Value)
+			LoggerFromContext(r.Context()).Debugw("auth: invalid cookie signature")
 			userID = generateUserID()
 			signed := sign(userID)
 
@@ -85,14 +135,15 @@ func AuthMiddleware(next http.Handler) http.Handler {
 				Path:  "/",
 			})
 
-			log.Printf("[AUTH] Сгенерирован новый userID: %s", userID)
+			LoggerFromContext(r.Context()).Debugw("auth: issued new user", "user_id", userID)
 			ctx := context.WithValue(r.Context(), UserIDKey, userID)
+			ctx = context.WithValue(ctx, freshUserKey, true)
 			next.ServeHTTP(w, r.WithContext(ctx))
 			return
 		}
 
 		// Всё ок — userID достали и проверили
-		log.Printf("[AUTH] Распарсили userID из куки: %s", userID)
+		LoggerFromContext(r.Context()).Debugw("auth: user from cookie", "user_id", userID)
 		ctx := context.WithValue(r.Context(), UserIDKey, userID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
@@ -20,6 +20,14 @@ func (w gzipWriter) Write(b []byte) (int, error) {
 	return w.Writer.Write(b)
 }
 
+// FlushError отправляет клиенту уже сжатую часть потокового ответа.
+func (w gzipWriter) FlushError() error {
+	if err := w.Writer.Flush(); err != nil {
+		return err
+	}
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
 // GzipHandle — middleware, который:
 // 1. Декодирует входящие gzip-запросы.
 // 2. Сжимает исходящий ответ, если клиент поддерживает gzip.
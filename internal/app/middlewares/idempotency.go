@@ -0,0 +1,267 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+)
+
+const (
+	// IdempotencyKeyHeader — заголовок с ключом идемпотентности запроса на создание.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader выставляется в ответах, повторённых из сохранённых.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLen    = 255
+	idempotencyPurgeEvery   = time.Minute
+	idempotencyStoreTimeout = 2 * time.Second
+)
+
+// IdempotencyStore хранит ключи идемпотентности пользователей вместе с отпечатком запроса и ответом.
+type IdempotencyStore interface {
+	// ReserveIdempotencyKey атомарно занимает ключ записью rec (Completed=false).
+	// Если ключ уже занят и не истёк, возвращает существующую запись и false.
+	ReserveIdempotencyKey(ctx context.Context, userID, key string, rec dto.IdempotencyRecord, now time.Time) (dto.IdempotencyRecord, bool, error)
+	// CompleteIdempotencyKey сохраняет ответ на запрос с этим ключом.
+	CompleteIdempotencyKey(ctx context.Context, userID, key string, rec dto.IdempotencyRecord) error
+	// ReleaseIdempotencyKey освобождает ключ, если запрос завершился ошибкой сервера.
+	ReleaseIdempotencyKey(ctx context.Context, userID, key string) error
+	// PurgeIdempotencyKeys удаляет ключи, истёкшие раньше before.
+	PurgeIdempotencyKeys(ctx context.Context, before time.Time) error
+}
+
+// Idempotency — middleware для эндпоинтов создания. Запрос с заголовком Idempotency-Key
+// выполняется один раз: повтор с тем же ключом и телом в течение ttl получает сохранённый
+// ответ, повтор с другим телом — 422, а повтор, пока первый запрос ещё выполняется, — 409.
+// Ключи живут в пространстве пользователя. Клиент без куки получает нового пользователя
+// на каждый запрос, поэтому его ключи живут в пространстве IP-адреса: повтор без куки
+// получит сохранённый ответ, но без куки пользователя, создавшего ссылку.
+// Ответы 5xx и запросы, завершившиеся паникой, не сохраняются, чтобы клиент мог повторить.
+func Idempotency(store IdempotencyStore, ttl time.Duration) func(http.Handler) http.Handler {
+	var lastPurge atomic.Int64
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLen {
+				http.Error(w, "idempotency key is too long", http.StatusBadRequest)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, "invalid request", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			userID := idempotencyScope(r)
+			logger := LoggerFromContext(r.Context())
+			now := time.Now()
+			purgeIdempotencyKeys(store, &lastPurge, now)
+
+			ctx, cancel := context.WithTimeout(r.Context(), idempotencyStoreTimeout)
+			existing, reserved, err := store.ReserveIdempotencyKey(ctx, userID, key, dto.IdempotencyRecord{
+				Fingerprint: fingerprint(r, body),
+				ExpiresAt:   now.Add(ttl),
+			}, now)
+			cancel()
+			if err != nil {
+				logger.Errorw("idempotency key reserve failed", "error", err)
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+
+			if !reserved {
+				switch {
+				case existing.Fingerprint != fingerprint(r, body):
+					http.Error(w, "idempotency key reused with a different request", http.StatusUnprocessableEntity)
+				case !existing.Completed:
+					http.Error(w, "request with this idempotency key is still in progress", http.StatusConflict)
+				default:
+					replay(w, existing)
+				}
+				return
+			}
+
+			// Паника в обработчике не должна оставить ключ занятым до истечения ttl
+			defer func() {
+				if p := recover(); p != nil {
+					ctx, cancel := context.WithTimeout(context.Background(), idempotencyStoreTimeout)
+					defer cancel()
+					if err := store.ReleaseIdempotencyKey(ctx, userID, key); err != nil {
+						logger.Errorw("idempotency key release failed", "error", err)
+					}
+					panic(p)
+				}
+			}()
+
+			rec := &recordingWriter{ResponseWriter: w}
+			next.ServeHTTP(rec, r)
+
+			// Контекст запроса мог уже закончиться, а результат нужно сохранить
+			ctx, cancel = context.WithTimeout(context.Background(), idempotencyStoreTimeout)
+			defer cancel()
+			if rec.status() >= http.StatusInternalServerError {
+				err = store.ReleaseIdempotencyKey(ctx, userID, key)
+			} else {
+				err = store.CompleteIdempotencyKey(ctx, userID, key, dto.IdempotencyRecord{
+					Fingerprint: fingerprint(r, body),
+					Completed:   true,
+					StatusCode:  rec.status(),
+					ContentType: rec.Header().Get("Content-Type"),
+					Location:    rec.Header().Get("Location"),
+					Body:        rec.body.Bytes(),
+					ExpiresAt:   now.Add(ttl),
+				})
+			}
+			if err != nil {
+				logger.Errorw("idempotency key save failed", "error", err)
+			}
+		})
+	}
+}
+
+// idempotencyScope возвращает пространство ключей запроса: userID или, для пользователя,
+// выданного в этом же запросе, хеш IP клиента (в колонку user_id помещается не любой IPv6).
+func idempotencyScope(r *http.Request) string {
+	userID, _ := r.Context().Value(UserIDKey).(string)
+	if fresh, _ := r.Context().Value(freshUserKey).(bool); !fresh && userID != "" {
+		return userID
+	}
+	sum := sha256.Sum256([]byte(clientIP(r)))
+	return "ip:" + hex.EncodeToString(sum[:16])
+}
+
+// fingerprint — отпечаток запроса: метод, путь с параметрами и тело.
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// replay отдаёт сохранённый ответ.
+func replay(w http.ResponseWriter, rec dto.IdempotencyRecord) {
+	if rec.ContentType != "" {
+		w.Header().Set("Content-Type", rec.ContentType)
+	}
+	if rec.Location != "" {
+		w.Header().Set("Location", rec.Location)
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(rec.StatusCode)
+	w.Write(rec.Body)
+}
+
+// purgeIdempotencyKeys не чаще раза в минуту удаляет истёкшие ключи в фоне.
+func purgeIdempotencyKeys(store IdempotencyStore, lastPurge *atomic.Int64, now time.Time) {
+	last := lastPurge.Load()
+	if now.Sub(time.Unix(0, last)) < idempotencyPurgeEvery || !lastPurge.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), idempotencyStoreTimeout)
+		defer cancel()
+		if err := store.PurgeIdempotencyKeys(ctx, now); err != nil {
+			sugar.Errorw("idempotency keys purge failed", "error", err)
+		}
+	}()
+}
+
+// recordingWriter передаёт ответ клиенту и одновременно запоминает его.
+type recordingWriter struct {
+	http.ResponseWriter
+	code int
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) status() int {
+	if w.code == 0 {
+		return http.StatusOK
+	}
+	return w.code
+}
+
+// idempotencyKey — ключ записи в MemoryIdempotencyStore.
+type idempotencyKey struct {
+	userID string
+	key    string
+}
+
+// MemoryIdempotencyStore — IdempotencyStore в памяти процесса для файлового и in-memory хранилищ.
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[idempotencyKey]dto.IdempotencyRecord
+}
+
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{records: make(map[idempotencyKey]dto.IdempotencyRecord)}
+}
+
+func (s *MemoryIdempotencyStore) ReserveIdempotencyKey(ctx context.Context, userID, key string, rec dto.IdempotencyRecord, now time.Time) (dto.IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := idempotencyKey{userID: userID, key: key}
+	if existing, ok := s.records[k]; ok && existing.ExpiresAt.After(now) {
+		return existing, false, nil
+	}
+	s.records[k] = rec
+	return rec, true, nil
+}
+
+func (s *MemoryIdempotencyStore) CompleteIdempotencyKey(ctx context.Context, userID, key string, rec dto.IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[idempotencyKey{userID: userID, key: key}] = rec
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) ReleaseIdempotencyKey(ctx context.Context, userID, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, idempotencyKey{userID: userID, key: key})
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) PurgeIdempotencyKeys(ctx context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for k, rec := range s.records {
+		if rec.ExpiresAt.Before(before) {
+			delete(s.records, k)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingHandler создаёт «ссылку» на каждый вызов и считает вызовы.
+func countingHandler(calls *int, status int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(status)
+		w.Write([]byte("http://localhost:8080/" + strings.Repeat("x", *calls)))
+	})
+}
+
+func doIdempotent(handler http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotency_ReplaysResponse(t *testing.T) {
+	calls := 0
+	handler := InjectTestUserIDMiddleware("user-1")(Idempotency(NewMemoryIdempotencyStore(), time.Hour)(countingHandler(&calls, http.StatusCreated)))
+
+	first := doIdempotent(handler, "key-1", "https://example.com")
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get(IdempotentReplayedHeader))
+
+	second := doIdempotent(handler, "key-1", "https://example.com")
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "text/plain", second.Header().Get("Content-Type"))
+	assert.Equal(t, "true", second.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, 1, calls)
+
+	// Без ключа запрос выполняется каждый раз
+	doIdempotent(handler, "", "https://example.com")
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotency_RejectsDifferentBody(t *testing.T) {
+	calls := 0
+	handler := InjectTestUserIDMiddleware("user-1")(Idempotency(NewMemoryIdempotencyStore(), time.Hour)(countingHandler(&calls, http.StatusCreated)))
+
+	doIdempotent(handler, "key-1", "https://example.com")
+	rec := doIdempotent(handler, "key-1", "https://other.example.com")
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, 1, calls)
+}
+
+func TestIdempotency_ServerErrorReleasesKey(t *testing.T) {
+	calls := 0
+	status := http.StatusInternalServerError
+	handler := InjectTestUserIDMiddleware("user-1")(Idempotency(NewMemoryIdempotencyStore(), time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(status)
+	})))
+
+	assert.Equal(t, http.StatusInternalServerError, doIdempotent(handler, "key-1", "https://example.com").Code)
+	status = http.StatusCreated
+	assert.Equal(t, http.StatusCreated, doIdempotent(handler, "key-1", "https://example.com").Code)
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotency_KeysArePerUser(t *testing.T) {
+	calls := 0
+	store := NewMemoryIdempotencyStore()
+	next := countingHandler(&calls, http.StatusCreated)
+	alice := InjectTestUserIDMiddleware("alice")(Idempotency(store, time.Hour)(next))
+	bob := InjectTestUserIDMiddleware("bob")(Idempotency(store, time.Hour)(next))
+
+	doIdempotent(alice, "key-1", "https://example.com")
+	rec := doIdempotent(bob, "key-1", "https://other.example.com")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Empty(t, rec.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotency_ExpiredKeyIsReused(t *testing.T) {
+	calls := 0
+	handler := InjectTestUserIDMiddleware("user-1")(Idempotency(NewMemoryIdempotencyStore(), -time.Second)(countingHandler(&calls, http.StatusCreated)))
+
+	doIdempotent(handler, "key-1", "https://example.com")
+	rec := doIdempotent(handler, "key-1", "https://other.example.com")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotency_PanicReleasesKey(t *testing.T) {
+	calls := 0
+	handler := InjectTestUserIDMiddleware("user-1")(Idempotency(NewMemoryIdempotencyStore(), time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			panic("boom")
+		}
+		w.WriteHeader(http.StatusCreated)
+	})))
+
+	assert.PanicsWithValue(t, "boom", func() { doIdempotent(handler, "key-1", "https://example.com") })
+	assert.Equal(t, http.StatusCreated, doIdempotent(handler, "key-1", "https://example.com").Code)
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotency_FreshUsersAreScopedByIP(t *testing.T) {
+	calls := 0
+	// Запросы без куки: AuthMiddleware выдаёт каждому нового пользователя
+	handler := AuthMiddleware(Idempotency(NewMemoryIdempotencyStore(), time.Hour)(countingHandler(&calls, http.StatusCreated)))
+
+	first := doIdempotent(handler, "key-1", "https://example.com")
+	second := doIdempotent(handler, "key-1", "https://example.com")
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "true", second.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, 1, calls)
+
+	// С другого адреса тот же ключ — другой запрос
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("https://example.com"))
+	req.Header.Set(IdempotencyKeyHeader, "key-1")
+	req.RemoteAddr = "198.51.100.7:4321"
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, 2, calls)
+}
@@ -7,7 +7,9 @@ import (
 	"go.uber.org/zap"
 )
 
-var sugar *zap.SugaredLogger
+// sugar — логгер пакета. До вызова InitLogger ничего не пишет,
+// чтобы middlewares можно было использовать в тестах без настройки логирования.
+var sugar = zap.NewNop().Sugar()
 
 // InitLogger инициализирует глобальный логгер.
 // Вызывается один раз в начале работы приложения.
@@ -22,6 +24,7 @@ func InitLogger(logger *zap.SugaredLogger) {
 //   - код ответа
 //   - время обработки
 //   - размер ответа (в байтах)
+//   - идентификатор запроса (request_id), если перед ним подключён RequestID
 func WithLogging(h http.Handler) http.Handler {
 	logFn := func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
@@ -43,7 +46,7 @@ func WithLogging(h http.Handler) http.Handler {
 
 		// После завершения — логируем данные
 		duration := time.Since(start)
-		sugar.Infoln(
+		LoggerFromContext(r.Context()).Infow("request",
 			"uri", r.RequestURI,
 			"method", r.Method,
 			"status", responseData.status,
@@ -80,3 +83,9 @@ func (r *loggingResponseWriter) WriteHeader(statusCode int) {
 	r.ResponseWriter.WriteHeader(statusCode)
 	r.responseData.status = statusCode
 }
+
+// Unwrap открывает исходный ResponseWriter для http.ResponseController
+// (например, чтобы потоковые ответы могли вызывать Flush).
+func (r *loggingResponseWriter) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,40 @@
+package middlewares
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/metrics"
+	"github.com/go-chi/chi/v5"
+)
+
+// WithMetrics — middleware, которое считает запросы и их длительность для Prometheus.
+// В метку route попадает шаблон маршрута chi (например, /{id}), а не сам путь,
+// чтобы число временных рядов не росло с каждым новым коротким идентификатором.
+func WithMetrics(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		responseData := &responseData{}
+		lw := loggingResponseWriter{
+			ResponseWriter: w,
+			responseData:   responseData,
+		}
+
+		h.ServeHTTP(&lw, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		status := responseData.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		labels := []string{r.Method, route, strconv.Itoa(status)}
+		metrics.HTTPRequests.WithLabelValues(labels...).Inc()
+		metrics.HTTPDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+	})
+}
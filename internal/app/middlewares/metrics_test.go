@@ -0,0 +1,36 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DaniYer/GoProject.git/internal/app/metrics"
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithMetrics_LabelsByRoutePattern(t *testing.T) {
+	router := chi.NewRouter()
+	router.Use(WithMetrics)
+	router.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if chi.URLParam(r, "id") == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	before := testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues(http.MethodGet, "/{id}", "200"))
+	beforeMissing := testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues(http.MethodGet, "/{id}", "404"))
+
+	for _, path := range []string{"/abc", "/def", "/missing"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, before+2, testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues(http.MethodGet, "/{id}", "200")))
+	assert.Equal(t, beforeMissing+1, testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues(http.MethodGet, "/{id}", "404")))
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, rec.Body.String(), `shortener_http_requests_total{method="GET",route="/{id}",status="200"}`)
+}
@@ -0,0 +1,187 @@
+package middlewares
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// bucketSweepInterval — как часто выбрасывать из памяти корзины простаивающих клиентов.
+const bucketSweepInterval = time.Minute
+
+// RateLimiter — ограничитель запросов по алгоритму token bucket.
+// У каждого клиента своя корзина ёмкостью burst, которая пополняется со скоростью rate токенов в секунду.
+type RateLimiter struct {
+	rate  float64
+	burst int
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// bucket — состояние корзины одного клиента.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter создаёт ограничитель на rate запросов в секунду с допустимым всплеском burst.
+// Если rate или burst не положительные, возвращает nil — такой ограничитель пропускает всё.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if rate <= 0 || burst <= 0 {
+		return nil
+	}
+	return &RateLimiter{
+		rate:    rate,
+		burst:   burst,
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// limitDecision — результат проверки запроса, из которого формируются заголовки RateLimit-*.
+type limitDecision struct {
+	allowed    bool
+	remaining  int
+	reset      time.Duration // через сколько корзина снова будет полной
+	retryAfter time.Duration // через сколько появится следующий токен
+}
+
+// allow списывает по токену из корзин всех ключей, если токен есть в каждой: запрос,
+// отклонённый одной корзиной, не расходует остальные. Заголовки строятся по самой
+// исчерпанной корзине.
+func (l *RateLimiter) allow(keys ...string) limitDecision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	buckets := make([]*bucket, len(keys))
+	allowed := true
+	for i, key := range keys {
+		b, ok := l.buckets[key]
+		if !ok {
+			b = &bucket{tokens: float64(l.burst), last: now}
+			l.buckets[key] = b
+		}
+		// Пополняем корзину за прошедшее время
+		b.tokens = math.Min(float64(l.burst), b.tokens+now.Sub(b.last).Seconds()*l.rate)
+		b.last = now
+		buckets[i] = b
+		if b.tokens < 1 {
+			allowed = false
+		}
+	}
+
+	decision := limitDecision{allowed: allowed, remaining: l.burst}
+	for _, b := range buckets {
+		if allowed {
+			b.tokens--
+		} else if b.tokens < 1 {
+			decision.retryAfter = max(decision.retryAfter, l.durationFor(1-b.tokens))
+		}
+		decision.remaining = min(decision.remaining, int(b.tokens))
+		decision.reset = max(decision.reset, l.durationFor(float64(l.burst)-b.tokens))
+	}
+	return decision
+}
+
+// sweep удаляет корзины, которые успели заполниться целиком: они ничем не отличаются от новых.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < bucketSweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	full := l.durationFor(float64(l.burst))
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= full {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// durationFor возвращает время, за которое накопится tokens токенов.
+func (l *RateLimiter) durationFor(tokens float64) time.Duration {
+	return time.Duration(tokens / l.rate * float64(time.Second))
+}
+
+// RateLimit — middleware, ограничивающий частоту запросов.
+// Клиент определяется по userID из контекста (кука или API-ключ), а если пользователь
+// только что выдан AuthMiddleware — по IP, иначе лимит обходился бы сбросом куки.
+// Поэтому middleware подключается после AuthMiddleware.
+//
+// При превышении лимита отвечает 429 с заголовком Retry-After.
+// Во всех ответах выставляются RateLimit-Limit, RateLimit-Remaining и RateLimit-Reset.
+func RateLimit(limiter *RateLimiter) func(http.Handler) http.Handler {
+	return rateLimit(limiter, func(r *http.Request) []string {
+		return []string{rateLimitKey(r)}
+	})
+}
+
+// RateLimitUserAndIP — RateLimit для маршрутов создания: запрос расходует и корзину
+// пользователя, и корзину IP. Иначе клиент, заранее набравший подписанных кук,
+// получал бы по отдельной корзине на каждую и обходил лимит своего адреса.
+func RateLimitUserAndIP(limiter *RateLimiter) func(http.Handler) http.Handler {
+	return rateLimit(limiter, func(r *http.Request) []string {
+		ipKey := "ip:" + clientIP(r)
+		if key := rateLimitKey(r); key != ipKey {
+			return []string{key, ipKey}
+		}
+		return []string{ipKey}
+	})
+}
+
+// rateLimit ограничивает запросы по корзинам ключей, которые возвращает keys.
+func rateLimit(limiter *RateLimiter, keys func(r *http.Request) []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if limiter == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			decision := limiter.allow(keys(r)...)
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(limiter.burst))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(decision.remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(decision.reset)))
+
+			if !decision.allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(decision.retryAfter)))
+				http.Error(w, "too many requests", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rateLimitKey выбирает ключ корзины для запроса.
+func rateLimitKey(r *http.Request) string {
+	if userID, ok := r.Context().Value(UserIDKey).(string); ok && userID != "" {
+		if fresh, _ := r.Context().Value(freshUserKey).(bool); !fresh {
+			return "user:" + userID
+		}
+	}
+	return "ip:" + clientIP(r)
+}
+
+// clientIP возвращает IP клиента из RemoteAddr.
+// Заголовкам X-Forwarded-For не доверяем: их может подделать сам клиент.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ceilSeconds округляет длительность вверх до целых секунд.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,110 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit_RejectsAfterBurstAndRefills(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	limiter := NewRateLimiter(1, 2)
+	limiter.now = func() time.Time { return now }
+
+	handler := InjectTestUserIDMiddleware("user-1")(RateLimit(limiter)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	do := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/abc", nil))
+		return rec
+	}
+
+	rec := do()
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Remaining"))
+
+	assert.Equal(t, http.StatusOK, do().Code)
+
+	rec = do()
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "2", rec.Header().Get("RateLimit-Reset"))
+
+	now = now.Add(time.Second)
+	assert.Equal(t, http.StatusOK, do().Code)
+}
+
+func TestRateLimit_KeysByUserOrIP(t *testing.T) {
+	limiter := NewRateLimiter(1, 1)
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	alice := InjectTestUserIDMiddleware("alice")(RateLimit(limiter)(ok))
+	bob := InjectTestUserIDMiddleware("bob")(RateLimit(limiter)(ok))
+	anonymous := AuthMiddleware(RateLimit(limiter)(ok))
+
+	serve := func(h http.Handler, remoteAddr string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Разные пользователи с одного IP не мешают друг другу
+	assert.Equal(t, http.StatusOK, serve(alice, "10.0.0.1:1000"))
+	assert.Equal(t, http.StatusOK, serve(bob, "10.0.0.1:1001"))
+	assert.Equal(t, http.StatusTooManyRequests, serve(alice, "10.0.0.2:1000"))
+
+	// Клиент без куки каждый раз получает нового пользователя, поэтому считается по IP
+	assert.Equal(t, http.StatusOK, serve(anonymous, "10.0.0.3:1000"))
+	assert.Equal(t, http.StatusTooManyRequests, serve(anonymous, "10.0.0.3:1001"))
+}
+
+func TestRateLimitUserAndIP_ChargesBothBuckets(t *testing.T) {
+	limiter := NewRateLimiter(1, 2)
+	now := time.Unix(1_700_000_000, 0)
+	limiter.now = func() time.Time { return now }
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	serve := func(userID, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		InjectTestUserIDMiddleware(userID)(RateLimitUserAndIP(limiter)(ok)).ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Смена куки не даёт новой корзины: адрес исчерпан после двух запросов
+	assert.Equal(t, http.StatusOK, serve("alice", "10.0.0.1:1000").Code)
+	assert.Equal(t, http.StatusOK, serve("bob", "10.0.0.1:1001").Code)
+	rec := serve("carol", "10.0.0.1:1002")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+
+	// И смена адреса не даёт новой корзины пользователю
+	assert.Equal(t, http.StatusOK, serve("alice", "10.0.0.2:1000").Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve("alice", "10.0.0.3:1000").Code)
+
+	// Отклонённый запрос не расходует корзину пользователя
+	assert.Equal(t, http.StatusOK, serve("carol", "10.0.0.4:1000").Code)
+	assert.Equal(t, http.StatusOK, serve("carol", "10.0.0.5:1000").Code)
+}
+
+func TestRateLimit_NilLimiterPassesThrough(t *testing.T) {
+	assert.Nil(t, NewRateLimiter(0, 10))
+
+	handler := RateLimit(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, rec.Header().Get("RateLimit-Limit"))
+}
@@ -0,0 +1,63 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// RequestIDHeader — заголовок, в котором клиент или балансировщик передаёт идентификатор запроса.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDKey — ключ для хранения идентификатора запроса в контексте.
+const RequestIDKey contextKey = "requestID"
+
+// maxRequestIDLength — ограничение на длину входящего X-Request-ID,
+// чтобы клиент не мог раздувать логи произвольно длинными значениями.
+const maxRequestIDLength = 128
+
+// RequestID — middleware, которое присваивает каждому запросу идентификатор.
+// Если клиент прислал корректный X-Request-ID, используется он, иначе генерируется UUID.
+// Идентификатор кладётся в контекст и возвращается клиенту в том же заголовке.
+// Подключается первым, чтобы идентификатор попал во все строки лога.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		w.Header().Set(RequestIDHeader, requestID)
+		ctx := context.WithValue(r.Context(), RequestIDKey, requestID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// GetRequestID возвращает идентификатор запроса из контекста или пустую строку.
+func GetRequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(RequestIDKey).(string)
+	return requestID
+}
+
+// LoggerFromContext возвращает логгер, в каждую запись которого добавлен request_id.
+func LoggerFromContext(ctx context.Context) *zap.SugaredLogger {
+	if requestID := GetRequestID(ctx); requestID != "" {
+		return sugar.With("request_id", requestID)
+	}
+	return sugar
+}
+
+// validRequestID допускает только непустые печатные ASCII-идентификаторы разумной длины.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,53 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRequestID_PropagatesToContextAndLogs(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	InitLogger(zap.New(core).Sugar())
+	t.Cleanup(func() { InitLogger(zap.NewNop().Sugar()) })
+
+	var seen string
+	handler := RequestID(WithLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = GetRequestID(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/abc", nil)
+	req.Header.Set(RequestIDHeader, "req-42")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, "req-42", seen)
+	assert.Equal(t, "req-42", rec.Header().Get(RequestIDHeader))
+
+	entries := logs.FilterMessage("request").All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "req-42", entries[0].ContextMap()["request_id"])
+	assert.EqualValues(t, http.StatusOK, entries[0].ContextMap()["status"])
+}
+
+func TestRequestID_GeneratesWhenMissingOrInvalid(t *testing.T) {
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, incoming := range []string{"", "has space", strings.Repeat("x", maxRequestIDLength+1)} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(RequestIDHeader, incoming)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		got := rec.Header().Get(RequestIDHeader)
+		assert.NotEmpty(t, got)
+		assert.NotEqual(t, incoming, got)
+	}
+}
@@ -0,0 +1,324 @@
+// Package migrate переносит ссылки между хранилищами (файл, PostgreSQL, память)
+// вместе с владельцами, признаком удаления, сроком жизни и счётчиком переходов.
+// Перенос идёт пачками в порядке короткого кода, поэтому его можно прервать
+// и продолжить с контрольной точки, а по окончании сверить хранилища по числу
+// ссылок и контрольной сумме.
+//
+// Приёмник допускает одну активную ссылку на оригинальный URL, а старые файлы хранилища
+// могут содержать несколько. Такие URL сводятся по ходу переноса: активные ссылки каждой
+// пачки сверяются с приёмником через GetByOriginalURL, уже занятый URL не переносится,
+// а код попадает в Report.Duplicates. Удалённые ссылки переносятся всегда.
+// Verify сравнивает приёмник с источником без этих кодов.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+)
+
+// ErrMismatch — после переноса хранилища различаются по числу ссылок или контрольной сумме.
+var ErrMismatch = errors.New("source and target differ")
+
+// ErrCheckpointMismatch — контрольная точка осталась от переноса между другими хранилищами.
+var ErrCheckpointMismatch = errors.New("checkpoint belongs to another migration")
+
+// Source — хранилище, из которого можно прочитать все ссылки по порядку короткого кода.
+type Source interface {
+	IterateLinks(ctx context.Context, after string, fn func(dto.StoredLink) error) error
+}
+
+// Target — хранилище, в которое ссылки можно записать как есть.
+// ImportLinks пропускает уже занятые коды и возвращает число добавленных ссылок,
+// GetByOriginalURL возвращает код активной ссылки на URL.
+type Target interface {
+	Source
+	ImportLinks(ctx context.Context, links []dto.StoredLink) (int, error)
+	GetByOriginalURL(ctx context.Context, originalURL string) (string, error)
+}
+
+// Options — параметры переноса.
+type Options struct {
+	BatchSize      int       // сколько ссылок записывается за один вызов ImportLinks
+	CheckpointPath string    // файл контрольной точки; пусто — перенос всегда с начала
+	DryRun         bool      // только прочитать источник, ничего не записывая
+	Progress       io.Writer // куда печатать ход переноса (nil — никуда)
+
+	// From и To — строки источника и приёмника, как их принимает OpenStore. Они сохраняются
+	// в контрольной точке (без пароля), и точка другого переноса не продолжается.
+	From, To string
+}
+
+// Checkpoint — состояние прерванного переноса.
+type Checkpoint struct {
+	From     string `json:"from"`     // источник с вырезанным паролем
+	To       string `json:"to"`       // приёмник с вырезанным паролем
+	After    string `json:"after"`    // последний перенесённый короткий код
+	Read     int    `json:"read"`     // прочитано ссылок из источника
+	Imported int    `json:"imported"` // добавлено в приёмник
+	// Duplicates — повторы URL из уже перенесённых пачек: без них Verify после
+	// продолжения сравнивал бы приёмник с лишними ссылками источника
+	Duplicates []Duplicate `json:"duplicates,omitempty"`
+}
+
+// Report — итог переноса. Skipped — ссылки, которые уже были в приёмнике
+// (например, перенесены прошлым запуском) или конфликтуют с ним.
+// Duplicates — ссылки источника, не перенесённые из-за общего оригинального URL;
+// они входят в Read, но не в Skipped.
+type Report struct {
+	Read       int
+	Imported   int
+	Skipped    int
+	Resumed    bool
+	Duplicates []Duplicate
+}
+
+// Duplicate — активная ссылка источника, чей оригинальный URL уже у активной ссылки
+// приёмника KeptShortURL: перенесённой раньше (первой по коду) или бывшей там до переноса.
+type Duplicate struct {
+	ShortURL     string `json:"short_url"`
+	OriginalURL  string `json:"original_url"`
+	UserID       string `json:"user_id"`
+	KeptShortURL string `json:"kept_short_url"`
+}
+
+// reconcileBatch убирает из пачки активные ссылки, чей оригинальный URL уже занят
+// активной ссылкой приёмника или более ранней ссылкой этой же пачки.
+func reconcileBatch(ctx context.Context, dst Target, batch []dto.StoredLink) ([]dto.StoredLink, []Duplicate) {
+	links := make([]dto.StoredLink, 0, len(batch))
+	var duplicates []Duplicate
+	kept := make(map[string]string)
+	for _, link := range batch {
+		if link.Deleted {
+			links = append(links, link)
+			continue
+		}
+		keptShortURL, ok := kept[link.OriginalURL]
+		if !ok {
+			// Свой же код в приёмнике — ссылка перенесена прошлым запуском, её пропустит ImportLinks
+			if existing, err := dst.GetByOriginalURL(ctx, link.OriginalURL); err == nil && existing != link.ShortURL {
+				keptShortURL, ok = existing, true
+			}
+		}
+		if ok {
+			duplicates = append(duplicates, Duplicate{
+				ShortURL:     link.ShortURL,
+				OriginalURL:  link.OriginalURL,
+				UserID:       link.UserID,
+				KeptShortURL: keptShortURL,
+			})
+			continue
+		}
+		kept[link.OriginalURL] = link.ShortURL
+		links = append(links, link)
+	}
+	return links, duplicates
+}
+
+// Copy переносит ссылки из src в dst, продолжая с контрольной точки, если она есть.
+// Контрольная точка другого переноса (иные From или To) даёт ErrCheckpointMismatch.
+// С DryRun приёмник не пополняется, поэтому повтор URL находится, только если его
+// владелец уже есть в приёмнике или в той же пачке.
+func Copy(ctx context.Context, src Source, dst Target, opts Options) (Report, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 500
+	}
+	progress := opts.Progress
+	if progress == nil {
+		progress = io.Discard
+	}
+
+	from, to := redactSpec(opts.From), redactSpec(opts.To)
+	checkpoint, err := loadCheckpoint(opts.CheckpointPath)
+	if err != nil {
+		return Report{}, err
+	}
+	if checkpoint.After != "" && (checkpoint.From != from || checkpoint.To != to) {
+		return Report{}, fmt.Errorf("%w: %s -> %s (%s)", ErrCheckpointMismatch, checkpoint.From, checkpoint.To, opts.CheckpointPath)
+	}
+
+	report := Report{
+		Read:       checkpoint.Read,
+		Imported:   checkpoint.Imported,
+		Resumed:    checkpoint.After != "",
+		Duplicates: checkpoint.Duplicates,
+	}
+	if report.Resumed {
+		fmt.Fprintf(progress, "resuming after %q (%d read, %d imported)\n", checkpoint.After, checkpoint.Read, checkpoint.Imported)
+	}
+
+	batch := make([]dto.StoredLink, 0, opts.BatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		links, duplicates := reconcileBatch(ctx, dst, batch)
+		imported := 0
+		if !opts.DryRun && len(links) > 0 {
+			n, err := dst.ImportLinks(ctx, links)
+			if err != nil {
+				return err
+			}
+			imported = n
+		}
+		report.Read += len(batch)
+		report.Imported += imported
+		report.Duplicates = append(report.Duplicates, duplicates...)
+		checkpoint = Checkpoint{From: from, To: to, After: batch[len(batch)-1].ShortURL,
+			Read: report.Read, Imported: report.Imported, Duplicates: report.Duplicates}
+		if !opts.DryRun {
+			if err := saveCheckpoint(opts.CheckpointPath, checkpoint); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(progress, "read %d, imported %d, last %q\n", report.Read, report.Imported, checkpoint.After)
+		batch = batch[:0]
+		return nil
+	}
+
+	err = src.IterateLinks(ctx, checkpoint.After, func(link dto.StoredLink) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		batch = append(batch, link)
+		if len(batch) < opts.BatchSize {
+			return nil
+		}
+		return flush()
+	})
+	if err == nil {
+		err = flush()
+	}
+	if !opts.DryRun {
+		report.Skipped = report.Read - report.Imported - len(report.Duplicates)
+	}
+	return report, err
+}
+
+// Summary — число ссылок в хранилище и контрольная сумма их содержимого.
+type Summary struct {
+	Count    int
+	Checksum string
+}
+
+// Checksum считает Summary по всем ссылкам хранилища. В сумму входят код, оригинальный URL,
+// владелец, признак удаления, срок жизни (с точностью до секунды) и счётчик переходов.
+// Дата создания не входит: старые записи её не содержат, а приёмник может проставить свою.
+func Checksum(ctx context.Context, src Source) (Summary, error) {
+	return checksum(ctx, src, nil)
+}
+
+// checksum считает Summary по ссылкам src, кроме кодов из skip.
+func checksum(ctx context.Context, src Source, skip map[string]bool) (Summary, error) {
+	h := sha256.New()
+	count := 0
+	err := src.IterateLinks(ctx, "", func(link dto.StoredLink) error {
+		if skip[link.ShortURL] {
+			return nil
+		}
+		count++
+		expiresAt := ""
+		if link.ExpiresAt != nil {
+			expiresAt = strconv.FormatInt(link.ExpiresAt.Unix(), 10)
+		}
+		fmt.Fprintf(h, "%s\t%s\t%s\t%t\t%s\t%d\n",
+			link.ShortURL, link.OriginalURL, link.UserID, link.Deleted, expiresAt, link.Clicks)
+		return nil
+	})
+	if err != nil {
+		return Summary{}, err
+	}
+	return Summary{Count: count, Checksum: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// Verify сравнивает источник без ссылок duplicates (Report.Duplicates) и приёмник.
+// При расхождении возвращает ErrMismatch вместе с обеими сводками.
+func Verify(ctx context.Context, src Source, dst Source, duplicates []Duplicate) (Summary, Summary, error) {
+	skip := make(map[string]bool, len(duplicates))
+	for _, dup := range duplicates {
+		skip[dup.ShortURL] = true
+	}
+	srcSummary, err := checksum(ctx, src, skip)
+	if err != nil {
+		return Summary{}, Summary{}, fmt.Errorf("source checksum: %w", err)
+	}
+	dstSummary, err := Checksum(ctx, dst)
+	if err != nil {
+		return Summary{}, Summary{}, fmt.Errorf("target checksum: %w", err)
+	}
+	if srcSummary != dstSummary {
+		return srcSummary, dstSummary, ErrMismatch
+	}
+	return srcSummary, dstSummary, nil
+}
+
+// RemoveCheckpoint удаляет контрольную точку после успешного переноса.
+func RemoveCheckpoint(path string) error {
+	if path == "" {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func loadCheckpoint(path string) (Checkpoint, error) {
+	var checkpoint Checkpoint
+	if path == "" {
+		return checkpoint, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return checkpoint, nil
+	}
+	if err != nil {
+		return checkpoint, err
+	}
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return checkpoint, fmt.Errorf("checkpoint %s: %w", path, err)
+	}
+	return checkpoint, nil
+}
+
+// saveCheckpoint перезаписывает контрольную точку через временный файл.
+func saveCheckpoint(path string, checkpoint Checkpoint) error {
+	if path == "" {
+		return nil
+	}
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// dsnPassword — пароль в DSN вида "key=value".
+var dsnPassword = regexp.MustCompile(`(?i)(password\s*=\s*)('[^']*'|\S+)`)
+
+// redactSpec убирает пароль из строки хранилища, чтобы не сохранять его в контрольной точке.
+func redactSpec(spec string) string {
+	kind, location, ok := strings.Cut(spec, ":")
+	if !ok || (kind != "postgres" && kind != "postgresql") {
+		return spec
+	}
+	if u, err := url.Parse(location); err == nil && u.Scheme != "" {
+		return kind + ":" + u.Redacted()
+	}
+	return kind + ":" + dsnPassword.ReplaceAllString(location, "${1}xxxxx")
+}
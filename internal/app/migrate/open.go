@@ -0,0 +1,136 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/storage/database"
+	"github.com/DaniYer/GoProject.git/internal/app/storage/file"
+	"github.com/DaniYer/GoProject.git/internal/app/storage/sqlite"
+	"github.com/pressly/goose/v3"
+)
+
+// Store — хранилище, открытое командами обслуживания: ссылки и API-ключи
+// можно прочитать и записать целиком.
+type Store interface {
+	Target
+	IterateAPIKeys(ctx context.Context, fn func(dto.APIKey) error) error
+	SaveAPIKey(ctx context.Context, key dto.APIKey) error
+}
+
+// OpenStore открывает хранилище по строке вида file:<путь>, sqlite:<путь> или postgres:<DSN>.
+// Для баз данных перед работой применяются миграции схемы. Возвращённую функцию
+// нужно вызвать по окончании работы.
+func OpenStore(spec string) (Store, func(), error) {
+	return openStore(spec, false)
+}
+
+// OpenStoreReadOnly открывает существующее хранилище, ничего в нём не меняя:
+// файлы не создаются, а схема базы только сверяется с вшитыми миграциями.
+// Отсутствующее хранилище или неприменённые миграции дают ошибку.
+func OpenStoreReadOnly(spec string) (Store, func(), error) {
+	return openStore(spec, true)
+}
+
+func openStore(spec string, readOnly bool) (Store, func(), error) {
+	kind, location, ok := strings.Cut(spec, ":")
+	if !ok || location == "" {
+		return nil, nil, fmt.Errorf("некорректное хранилище %q", spec)
+	}
+	ctx := context.Background()
+
+	switch kind {
+	case "file":
+		open := file.NewFileStore
+		if readOnly {
+			open = file.OpenReadOnly
+		}
+		store, err := open(location)
+		if err != nil {
+			return nil, nil, err
+		}
+		return store, func() {}, nil
+	case "sqlite":
+		// Принимаем и форму DATABASE_DSN: sqlite://<путь>
+		path := strings.TrimPrefix(location, "//")
+		if !readOnly {
+			store, err := sqlite.Open(ctx, path)
+			if err != nil {
+				return nil, nil, err
+			}
+			return store, func() { store.Close() }, nil
+		}
+		// Драйвер создал бы пустую базу на месте опечатки в пути
+		if _, err := os.Stat(path); err != nil {
+			return nil, nil, err
+		}
+		db, err := sqlite.OpenDB(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		provider, err := sqlite.NewMigrator(db)
+		if err == nil {
+			err = checkSchema(ctx, db, provider)
+		}
+		if err != nil {
+			db.Close()
+			return nil, nil, err
+		}
+		store := sqlite.NewSQLiteStore(db)
+		return store, func() { store.Close() }, nil
+	case "postgres", "postgresql":
+		pool, err := database.NewPool(ctx, location, database.PoolConfig{})
+		if err != nil {
+			return nil, nil, err
+		}
+		db := database.OpenSQL(pool)
+		defer db.Close()
+		if readOnly {
+			provider, err := database.NewMigrator(db)
+			if err == nil {
+				err = checkSchema(ctx, db, provider)
+			}
+			if err != nil {
+				pool.Close()
+				return nil, nil, err
+			}
+		} else if err := database.Migrate(ctx, db); err != nil {
+			pool.Close()
+			return nil, nil, err
+		}
+		return dbStore{database.NewDBStore(pool)}, pool.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("неизвестный тип хранилища %q", kind)
+	}
+}
+
+// checkSchema проверяет, что к базе применены все вшитые миграции. Таблицу версий
+// читаем сами: провайдер goose создал бы её при первом же запросе.
+func checkSchema(ctx context.Context, db *sql.DB, provider *goose.Provider) error {
+	sources := provider.ListSources()
+	want := sources[len(sources)-1].Version
+	var current sql.NullInt64
+	if err := db.QueryRowContext(ctx, "SELECT max(version_id) FROM goose_db_version").Scan(&current); err != nil {
+		return fmt.Errorf("схема базы не создана: %w", err)
+	}
+	if current.Int64 < want {
+		return fmt.Errorf("схема базы на версии %d, нужна %d: сначала выполните shortener migrate up", current.Int64, want)
+	}
+	return nil
+}
+
+// dbStore — хранилище PostgreSQL, которое отдаёт согласованный срез данных как Store.
+type dbStore struct {
+	*database.DBStore
+}
+
+// ReadOnlySnapshot выполняет fn над срезом базы в транзакции REPEATABLE READ.
+func (s dbStore) ReadOnlySnapshot(ctx context.Context, fn func(Store) error) error {
+	return s.DBStore.ReadOnlySnapshot(ctx, func(snapshot *database.DBStore) error {
+		return fn(snapshot)
+	})
+}
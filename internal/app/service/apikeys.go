@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/google/uuid"
+)
+
+// apiKeyPrefix — префикс, по которому персональные ключи легко узнать в логах и конфигах.
+const apiKeyPrefix = "sk_"
+
+// apiKeyTouchInterval — как часто обновлять время последнего использования ключа.
+// Чаще писать в хранилище нет смысла: точность до минуты достаточна для аудита.
+const apiKeyTouchInterval = time.Minute
+
+var (
+	// ErrAPIKeyNotFound — ключ не найден, отозван или принадлежит другому пользователю.
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	// ErrAPIKeysUnavailable — хранилище ключей не подключено.
+	ErrAPIKeysUnavailable = errors.New("api keys unavailable")
+)
+
+// APIKeyStore — контракт хранилища персональных API-ключей.
+// Хранилище никогда не видит сам ключ, только его SHA-256 хеш.
+type APIKeyStore interface {
+	SaveAPIKey(ctx context.Context, key dto.APIKey) error
+	GetAPIKeyByHash(ctx context.Context, hash string) (dto.APIKey, error)
+	ListAPIKeys(ctx context.Context, userID string) ([]dto.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID, keyID string) error
+	TouchAPIKey(ctx context.Context, keyID string, usedAt time.Time) error
+}
+
+// CreateAPIKey выпускает новый ключ для пользователя.
+// Возвращает метаданные ключа и сам ключ — он показывается клиенту только один раз.
+func (s *URLService) CreateAPIKey(ctx context.Context, userID, name string) (dto.APIKey, string, error) {
+	ctx, span := startSpan(ctx, "URLService.CreateAPIKey")
+	defer span.End()
+
+	if s.Keys == nil {
+		return dto.APIKey{}, "", ErrAPIKeysUnavailable
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return dto.APIKey{}, "", err
+	}
+	rawKey := apiKeyPrefix + hex.EncodeToString(secret)
+
+	key := dto.APIKey{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		Name:      name,
+		Prefix:    rawKey[:len(apiKeyPrefix)+8],
+		Hash:      hashAPIKey(rawKey),
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.Keys.SaveAPIKey(ctx, key); err != nil {
+		return dto.APIKey{}, "", err
+	}
+	return key, rawKey, nil
+}
+
+// ListAPIKeys возвращает активные ключи пользователя (без самих ключей).
+func (s *URLService) ListAPIKeys(ctx context.Context, userID string) ([]dto.APIKey, error) {
+	ctx, span := startSpan(ctx, "URLService.ListAPIKeys")
+	defer span.End()
+
+	if s.Keys == nil {
+		return nil, ErrAPIKeysUnavailable
+	}
+	return s.Keys.ListAPIKeys(ctx, userID)
+}
+
+// RevokeAPIKey отзывает ключ пользователя. Чужие ключи отозвать нельзя.
+func (s *URLService) RevokeAPIKey(ctx context.Context, userID, keyID string) error {
+	ctx, span := startSpan(ctx, "URLService.RevokeAPIKey")
+	defer span.End()
+
+	if s.Keys == nil {
+		return ErrAPIKeysUnavailable
+	}
+	return s.Keys.RevokeAPIKey(ctx, userID, keyID)
+}
+
+// ResolveAPIKey находит владельца ключа и отмечает время последнего использования.
+// Используется AuthMiddleware для заголовка X-API-Key.
+func (s *URLService) ResolveAPIKey(ctx context.Context, rawKey string) (string, error) {
+	ctx, span := startSpan(ctx, "URLService.ResolveAPIKey")
+	defer span.End()
+
+	if s.Keys == nil {
+		return "", ErrAPIKeysUnavailable
+	}
+	if !strings.HasPrefix(rawKey, apiKeyPrefix) {
+		return "", ErrAPIKeyNotFound
+	}
+
+	key, err := s.Keys.GetAPIKeyByHash(ctx, hashAPIKey(rawKey))
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		// Время использования — справочное: из-за сбоя его записи ключ не отклоняется
+		if err := s.Keys.TouchAPIKey(ctx, key.ID, now); err != nil {
+			recordError(span, err)
+			s.logger().Warnw("api key touch failed", "key_id", key.ID, "error", err)
+		}
+	}
+	return key.UserID, nil
+}
+
+// hashAPIKey возвращает hex-представление SHA-256 от ключа.
+// Ключи — 256 бит случайных данных, поэтому медленный KDF не нужен.
+func hashAPIKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
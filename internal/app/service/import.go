@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Итог строки импорта (dto.ImportResult.Status) помимо created, existing и invalid.
+const (
+	ImportItemConflict = "conflict" // псевдоним занят другой ссылкой
+	ImportItemFailed   = "failed"   // строка корректна, но не сохранена, причина — в поле Error
+)
+
+// Код ошибки строки импорта (dto.ImportResult.Error).
+const (
+	ImportErrorInvalidLine   = "invalid_line"
+	ImportErrorInvalidAlias  = "invalid_alias"
+	ImportErrorAliasReserved = "alias_reserved"
+	ImportErrorExpired       = "expired"
+	ImportErrorAliasTaken    = "alias_taken"
+	ImportErrorCodeTaken     = "code_taken"
+	ImportErrorQuota         = "quota_exceeded"
+	ImportErrorInternal      = "internal_error"
+	// Тело не дочитано (слишком длинная строка, повреждённый gzip): импорт прерван на этой строке
+	ImportErrorReadFailed = "read_failed"
+)
+
+// aliasPattern — допустимый псевдоним: до 32 латинских букв, цифр, '-' и '_'.
+var aliasPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// ImportBatch сохраняет очередную порцию импорта одним вызовом SaveBatch.
+// Результат выровнен с items: некорректные строки получают статус invalid,
+// занятый псевдоним — conflict, остальные — created или existing.
+// Ошибка (в том числе превышение квоты) означает, что порция не сохранена целиком.
+func (s *URLService) ImportBatch(ctx context.Context, items []dto.ImportItem, userID string) ([]dto.ImportResult, error) {
+	ctx, span := startSpan(ctx, "URLService.ImportBatch")
+	defer span.End()
+	span.SetAttributes(attribute.Int("shortener.batch_size", len(items)))
+
+	now := time.Now()
+	results := make([]dto.ImportResult, len(items))
+	batch := make([]dto.BatchSaveItem, 0, len(items))
+	batchIdx := make([]int, 0, len(items))
+	for i, item := range items {
+		results[i].OriginalURL = item.OriginalURL
+		optsErr := ValidateLinkOptions(item.LinkOptions)
+		switch {
+		case ValidateURL(item.OriginalURL) != nil:
+			results[i].Status, results[i].Error = BatchItemInvalid, BatchErrorInvalidURL
+		case item.Alias != "" && !aliasPattern.MatchString(item.Alias):
+			results[i].Status, results[i].Error = BatchItemInvalid, ImportErrorInvalidAlias
+		case item.Alias != "" && slices.Contains(s.ReservedAliases, item.Alias):
+			results[i].Status, results[i].Error = BatchItemInvalid, ImportErrorAliasReserved
+		case item.ExpiresAt != nil && !item.ExpiresAt.After(now):
+			results[i].Status, results[i].Error = BatchItemInvalid, ImportErrorExpired
+		case optsErr != nil:
+			results[i].Status, results[i].Error = BatchItemInvalid, linkOptionsErrorCode(optsErr)
+		default:
+			shortURL := item.Alias
+			if shortURL == "" {
+				shortURL = s.newShortID()
+			}
+			batch = append(batch, dto.BatchSaveItem{
+				ShortURL:    shortURL,
+				OriginalURL: item.OriginalURL,
+				ExpiresAt:   item.ExpiresAt,
+				LinkOptions: item.LinkOptions,
+			})
+			batchIdx = append(batchIdx, i)
+		}
+	}
+	span.SetAttributes(attribute.Int("shortener.batch_invalid", len(items)-len(batch)))
+	if len(batch) == 0 {
+		return results, nil
+	}
+
+	originalURLs := make([]string, len(batch))
+	for i, item := range batch {
+		originalURLs[i] = item.OriginalURL
+	}
+	if err := s.checkQuota(ctx, userID, originalURLs); err != nil {
+		return nil, recordError(span, err)
+	}
+	// Занятый псевдоним — ответ для строки, а занятый сгенерированный код подбирается заново
+	saved, err := s.saveRetryingConflicts(ctx, userID, batch, func(i int) bool {
+		return items[batchIdx[i]].Alias == ""
+	})
+	if err != nil {
+		return nil, recordError(span, err)
+	}
+	for j, item := range saved {
+		result := &results[batchIdx[j]]
+		switch {
+		case item.Conflict && items[batchIdx[j]].Alias != "":
+			result.Status, result.Error = ImportItemConflict, ImportErrorAliasTaken
+		case item.Conflict:
+			result.Status, result.Error = ImportItemFailed, ImportErrorCodeTaken
+		case item.Existing:
+			result.Status, result.ShortURL = BatchItemExisting, s.BaseURL+"/"+item.ShortURL
+		default:
+			result.Status, result.ShortURL = BatchItemCreated, s.BaseURL+"/"+item.ShortURL
+		}
+	}
+	return results, nil
+}
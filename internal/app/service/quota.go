@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrQuotaExceeded — пользователь исчерпал лимит активных ссылок своего тарифа.
+// Конкретные цифры содержит *QuotaExceededError, который сопоставляется с этой ошибкой через errors.Is.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// QuotaExceededError описывает, какой лимит и насколько был превышен.
+type QuotaExceededError struct {
+	Tier      string // тариф пользователя
+	Limit     int    // максимум активных ссылок на тарифе
+	Used      int    // сколько активных ссылок уже есть
+	Requested int    // сколько ссылок пытались создать
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("quota exceeded: tier %q allows %d active links, %d used, %d requested",
+		e.Tier, e.Limit, e.Used, e.Requested)
+}
+
+func (e *QuotaExceededError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// QuotaPolicy — тарифы с лимитами активных (не удалённых) ссылок.
+// Тариф без лимита или с нулевым лимитом считается безлимитным.
+type QuotaPolicy struct {
+	tiers       map[string]int    // тариф → лимит ссылок
+	defaultTier string            // тариф для пользователей без явного назначения
+	userTiers   map[string]string // userID → тариф
+}
+
+// NewQuotaPolicy создаёт политику квот.
+// Если тарифы не заданы, возвращает nil — квоты отключены.
+func NewQuotaPolicy(tiers map[string]int, defaultTier string, userTiers map[string]string) *QuotaPolicy {
+	if len(tiers) == 0 {
+		return nil
+	}
+	return &QuotaPolicy{
+		tiers:       tiers,
+		defaultTier: defaultTier,
+		userTiers:   userTiers,
+	}
+}
+
+// Limit возвращает тариф пользователя и его лимит (0 — без ограничений).
+func (p *QuotaPolicy) Limit(userID string) (string, int) {
+	tier, ok := p.userTiers[userID]
+	if !ok {
+		tier = p.defaultTier
+	}
+	return tier, p.tiers[tier]
+}
+
+// checkQuota проверяет, может ли пользователь сохранить ссылки на originalURLs.
+// Квоту расходуют только ссылки, которые действительно будут созданы: адреса, у которых
+// уже есть активная ссылка, и повторы внутри пакета не считаются. Существующие ссылки
+// ищутся, только если без них лимит был бы превышен.
+//
+// Подсчёт и вставка не атомарны: параллельные запросы одного пользователя могут
+// превысить лимит не больше чем на размер одного пакета каждый.
+func (s *URLService) checkQuota(ctx context.Context, userID string, originalURLs []string) error {
+	if s.Quota == nil || len(originalURLs) == 0 {
+		return nil
+	}
+	tier, limit := s.Quota.Limit(userID)
+	if limit <= 0 {
+		return nil
+	}
+
+	used, err := s.Store.CountActiveByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if used+len(originalURLs) <= limit {
+		return nil
+	}
+
+	n := 0
+	seen := make(map[string]bool, len(originalURLs))
+	for _, originalURL := range originalURLs {
+		if seen[originalURL] {
+			continue
+		}
+		seen[originalURL] = true
+		if _, err := s.Store.GetByOriginalURL(ctx, originalURL); err != nil {
+			n++
+		}
+	}
+	if used+n > limit {
+		return &QuotaExceededError{Tier: tier, Limit: limit, Used: used, Requested: n}
+	}
+	return nil
+}
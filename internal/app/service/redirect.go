@@ -0,0 +1,79 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+)
+
+// Режимы переноса строки запроса короткой ссылки в адрес перенаправления.
+const (
+	// QueryModeDrop отбрасывает строку запроса; действует и для незаданного режима.
+	QueryModeDrop = "drop"
+	// QueryModeMerge добавляет параметры запроса; при совпадении имён остаются значения адреса ссылки.
+	QueryModeMerge = "merge"
+	// QueryModeOverride добавляет параметры запроса; совпадающие параметры адреса заменяются.
+	QueryModeOverride = "override"
+)
+
+// ErrInvalidRedirectPath — путь после кода ссылки нельзя перенести в адрес перенаправления.
+var ErrInvalidRedirectPath = errors.New("invalid redirect path")
+
+// redirectTarget строит адрес перенаправления: дописывает extraPath к пути адреса ссылки,
+// если она это разрешает, и переносит rawQuery по её режиму строки запроса.
+// extraPath должен быть экранирован так, как пришёл в запросе (url.URL.EscapedPath):
+// раскодированный путь исказил бы %25 и %2F при повторном разборе.
+func redirectTarget(link dto.ResolvedLink, extraPath, rawQuery string) (string, error) {
+	passQuery := rawQuery != "" && (link.QueryMode == QueryModeMerge || link.QueryMode == QueryModeOverride)
+	passPath := extraPath != "" && link.ForwardPath
+	if !passQuery && !passPath {
+		return link.OriginalURL, nil
+	}
+
+	target, err := url.Parse(link.OriginalURL)
+	if err != nil {
+		return "", fmt.Errorf("original url of %q: %w", link.OriginalURL, err)
+	}
+	if passPath {
+		// JoinPath молча оставляет путь прежним, если склейку нельзя раскодировать
+		if _, err := url.PathUnescape(extraPath); err != nil {
+			return "", ErrInvalidRedirectPath
+		}
+		target = target.JoinPath(extraPath)
+	}
+	if passQuery {
+		target.RawQuery = mergeQuery(target.RawQuery, rawQuery, link.QueryMode)
+	}
+	return target.String(), nil
+}
+
+// mergeQuery добавляет к строке запроса адреса параметры incoming. Параметры адреса
+// сохраняют исходный порядок и запись; в режиме override совпадающие с incoming удаляются.
+func mergeQuery(own, incoming, mode string) string {
+	// Пары, которые не удалось разобрать, отбрасываются
+	params, _ := url.ParseQuery(incoming)
+	ownParams, _ := url.ParseQuery(own)
+
+	var pairs []string
+	if own != "" {
+		for _, pair := range strings.Split(own, "&") {
+			key, _, _ := strings.Cut(pair, "=")
+			if name, err := url.QueryUnescape(key); err == nil && mode == QueryModeOverride && params.Has(name) {
+				continue
+			}
+			pairs = append(pairs, pair)
+		}
+	}
+	if mode == QueryModeMerge {
+		for name := range ownParams {
+			params.Del(name)
+		}
+	}
+	if extra := params.Encode(); extra != "" {
+		pairs = append(pairs, extra)
+	}
+	return strings.Join(pairs, "&")
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName — имя трассировщика бизнес-логики.
+const tracerName = "github.com/DaniYer/GoProject.git/internal/app/service"
+
+// startSpan открывает спан метода сервиса. Трассировщик берётся из глобального
+// провайдера при каждом вызове, чтобы подхватывать провайдер, настроенный после старта.
+func startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name)
+}
+
+// recordError отмечает спан как завершившийся ошибкой и возвращает ту же ошибку,
+// чтобы запись укладывалась в одну строку с return.
+func recordError(span trace.Span, err error) error {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	return err
+}
@@ -1,149 +1,424 @@
 package service
 
 import (
-	"sync"
+	"context"
+	"errors"
+	"net/http"
 
 	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
 )
 
 // URLService — бизнес-логика сервиса сокращения URL.
 // Работает поверх хранилища (URLStore) и поддерживает:
 //   - генерацию коротких ссылок (одиночную и пакетную);
-//   - получение ссылок пользователя;
-//   - асинхронное удаление ссылок через worker.
+//   - получение ссылок пользователя.
 type URLService struct {
-	Store   URLStore // интерфейс для работы с хранилищем
-	BaseURL string   // базовый адрес для формирования полной короткой ссылки
+	Store   URLStore      // интерфейс для работы с хранилищем
+	Keys    APIKeyStore   // хранилище персональных API-ключей (может быть nil)
+	Quota   *QuotaPolicy  // лимиты активных ссылок по тарифам (nil — без ограничений)
+	Clicks  ClickRecorder // учёт переходов по ссылкам (может быть nil)
+	BaseURL string        // базовый адрес для формирования полной короткой ссылки
 
-	deleteQueue chan deleteTask
-	once        sync.Once
-}
+	// Logger — журнал ошибок, которые не прерывают запрос; nil — ничего не пишется
+	Logger *zap.SugaredLogger
+
+	// GenerateID выдаёт код новой ссылки; nil — GenerateRandomID
+	GenerateID func() string
 
-// deleteTask — внутренний тип для отложенного удаления ссылок.
-type deleteTask struct {
-	userID    string
-	shortURLs []string
+	// ReservedAliases — первые сегменты путей, занятые маршрутами сервера:
+	// ссылка с таким псевдонимом была бы недостижима
+	ReservedAliases []string
+
+	// RedirectType — код перенаправления для ссылок, у которых он не задан
+	RedirectType int
 }
 
 // NewURLService создаёт и инициализирует новый сервис URL.
-// Запускает горутину worker для асинхронного удаления ссылок.
+// Асинхронным удалением ссылок занимается worker.DeleteWorkerPool.
 func NewURLService(store URLStore, baseURL string) *URLService {
-	svc := &URLService{
-		Store:       store,
-		BaseURL:     baseURL,
-		deleteQueue: make(chan deleteTask, 1024),
+	return &URLService{
+		Store:        store,
+		BaseURL:      baseURL,
+		RedirectType: http.StatusTemporaryRedirect,
 	}
+}
 
-	// Worker запускается только один раз
-	svc.once.Do(func() {
-		go svc.deleteWorker()
-	})
+// Итог удаления отдельной короткой ссылки в dto.DeleteResult.
+const (
+	DeleteResultDeleted  = "deleted"   // ссылка удалена (или уже была удалена владельцем)
+	DeleteResultNotOwned = "not_owned" // ссылка принадлежит другому пользователю
+	DeleteResultNotFound = "not_found" // такой ссылки нет
+	DeleteResultFailed   = "failed"    // хранилище так и не приняло удаление
+)
 
-	return svc
-}
+// Состояние задачи удаления в dto.DeleteJobStatus.
+const (
+	DeleteJobPending         = "pending"          // задача принята и ещё не выполнена
+	DeleteJobDone            = "done"             // все ссылки удалены
+	DeleteJobPartiallyFailed = "partially_failed" // часть ссылок не удалена: чужие, несуществующие или сбой
+)
+
+// Итог элемента пакета в режиме частичного успеха (dto.BatchResponse.Status).
+const (
+	BatchItemCreated  = "created"  // создана новая ссылка
+	BatchItemExisting = "existing" // такой URL уже сокращён, возвращён его код
+	BatchItemInvalid  = "invalid"  // элемент отклонён, причина — в поле Error
+	BatchItemFailed   = "failed"   // элемент корректен, но не сохранён, причина — в поле Error
+)
+
+// Код ошибки элемента пакета (dto.BatchResponse.Error).
+const (
+	BatchErrorInvalidURL          = "invalid_url"
+	BatchErrorInvalidRedirectType = "invalid_redirect_type"
+	BatchErrorInvalidQueryMode    = "invalid_query_mode"
+	BatchErrorShortURLTaken       = "short_url_taken"
+)
+
+// maxShortURLAttempts — сколько раз подбирается код для ссылки, чей сгенерированный код занят.
+const maxShortURLAttempts = 3
+
+// ErrDeleteJobNotFound — задачи удаления с таким ID у пользователя нет.
+var ErrDeleteJobNotFound = errors.New("delete job not found")
+
+// ErrLinkNotFound — у пользователя нет активной ссылки с таким кодом.
+var ErrLinkNotFound = errors.New("link not found")
+
+// ErrShortURLTaken — за maxShortURLAttempts попыток не нашлось свободного кода для ссылки.
+var ErrShortURLTaken = errors.New("short url is already taken")
 
 // URLStore — контракт хранилища URL, реализуемый БД, файловым или in-memory хранилищем.
+// Контекст запроса передаётся в каждый вызов: по нему хранилище соблюдает таймауты
+// и продолжает трассировку.
 type URLStore interface {
-	Save(shortURL, originalURL, userID string) (string, error)
-	Get(shortURL string) (string, error)
-	GetByOriginalURL(originalURL string) (string, error)
-	GetAllByUser(userID string) ([]dto.UserURL, error)
-	BatchDelete(userID string, shortURLs []string) error
+	Save(ctx context.Context, shortURL, originalURL, userID string) (string, error)
+	SaveBatch(ctx context.Context, userID string, items []dto.BatchSaveItem) ([]dto.BatchSaveItem, error)
+	Get(ctx context.Context, shortURL string) (string, error)
+	// Resolve возвращает ссылку для перехода вместе со сроком жизни и настройками.
+	// Ошибки те же, что у Get.
+	Resolve(ctx context.Context, shortURL string) (dto.ResolvedLink, error)
+	GetByOriginalURL(ctx context.Context, originalURL string) (string, error)
+	GetAllByUser(ctx context.Context, userID string) ([]dto.UserURL, error)
+	BatchDelete(ctx context.Context, userID string, shortURLs []string) ([]dto.DeleteResult, error)
+	CountActiveByUser(ctx context.Context, userID string) (int, error)
+	// UpdateLinkOptions заменяет настройки перехода по активной ссылке пользователя.
+	// Чужая, удалённая или истёкшая ссылка даёт ErrLinkNotFound.
+	UpdateLinkOptions(ctx context.Context, userID, shortURL string, opts dto.LinkOptions) error
+	// IterateByUser по одной передаёт в fn все ссылки пользователя, включая удалённые.
+	// Ошибка из fn прекращает обход и возвращается вызывающему.
+	IterateByUser(ctx context.Context, userID string, fn func(dto.LinkExport) error) error
+}
+
+// ClickStore — хранилище, которое умеет накапливать счётчики переходов по ссылкам.
+type ClickStore interface {
+	AddClicks(ctx context.Context, counts map[string]int64) error
+}
+
+// ClickRecorder учитывает переход по короткой ссылке. Запись в хранилище
+// выполняется реализацией (worker.ClickCounter) в фоне, пачками.
+type ClickRecorder interface {
+	RecordClick(shortURL string)
 }
 
-// Shorten создаёт сокращённую ссылку для originalURL.
-// Если ссылка уже существует, возвращает существующий shortURL и флаг duplicate=true.
-func (s *URLService) Shorten(originalURL, userID string) (string, bool, error) {
-	existingShortURL, err := s.Store.GetByOriginalURL(originalURL)
+// Shorten создаёт сокращённую ссылку для originalURL с настройками перехода opts.
+// originalURL проверяется так же, как в пакете: не http(s)-адрес даёт ErrInvalidURL.
+// Если ссылка уже существует, возвращает существующий shortURL и флаг duplicate=true,
+// её настройки при этом не меняются.
+// Если пользователь исчерпал квоту, возвращает ошибку, совместимую с ErrQuotaExceeded.
+func (s *URLService) Shorten(ctx context.Context, originalURL, userID string, opts dto.LinkOptions) (string, bool, error) {
+	ctx, span := startSpan(ctx, "URLService.Shorten")
+	defer span.End()
+
+	if err := ValidateURL(originalURL); err != nil {
+		return "", false, recordError(span, err)
+	}
+	if err := ValidateLinkOptions(opts); err != nil {
+		return "", false, recordError(span, err)
+	}
+
+	existingShortURL, err := s.Store.GetByOriginalURL(ctx, originalURL)
 	if err == nil {
+		span.SetAttributes(attribute.Bool("shortener.duplicate", true))
 		return existingShortURL, true, nil
 	}
 
-	shortID := GenerateRandomID()
-	shortID, err = s.Store.Save(shortID, originalURL, userID)
+	if err := s.checkQuota(ctx, userID, []string{originalURL}); err != nil {
+		return "", false, recordError(span, err)
+	}
+
+	shortID := s.newShortID()
+	if opts == (dto.LinkOptions{}) {
+		shortID, err = s.Store.Save(ctx, shortID, originalURL, userID)
+		if err != nil {
+			return "", false, recordError(span, err)
+		}
+		return shortID, false, nil
+	}
+
+	// Save сохраняет только адрес, поэтому ссылка с настройками идёт пакетом из одного элемента
+	saved, err := s.saveRetryingConflicts(ctx, userID, []dto.BatchSaveItem{{
+		ShortURL:    shortID,
+		OriginalURL: originalURL,
+		LinkOptions: opts,
+	}}, nil)
 	if err != nil {
-		return "", false, err
+		return "", false, recordError(span, err)
+	}
+	if saved[0].Conflict {
+		return "", false, recordError(span, ErrShortURLTaken)
 	}
-	return shortID, false, nil
+	return saved[0].ShortURL, saved[0].Existing, nil
 }
 
 // ShortenBatch обрабатывает пакетное сокращение ссылок.
 // Возвращает массив с корреляционными ID и готовыми короткими URL.
-func (s *URLService) ShortenBatch(requests []dto.BatchRequest, userID string) ([]dto.BatchResponse, error) {
-	responses := make([]dto.BatchResponse, len(requests))
+// Пакет сохраняется одним вызовом SaveBatch: либо целиком, либо никак.
+// Для уже существующих ссылок возвращается их код, а не сгенерированный.
+// Элемент, чей сгенерированный код занят, сохраняется заново с другим кодом;
+// если свободный код так и не нашёлся, возвращается ErrShortURLTaken.
+// Пустой пакет, повтор correlation_id или некорректный URL отклоняют весь пакет
+// (ErrEmptyBatch или *BatchItemError).
+// Квота проверяется для всего пакета целиком: либо новые ссылки помещаются, либо не создаётся ничего.
+func (s *URLService) ShortenBatch(ctx context.Context, requests []dto.BatchRequest, userID string) ([]dto.BatchResponse, error) {
+	ctx, span := startSpan(ctx, "URLService.ShortenBatch")
+	defer span.End()
+	span.SetAttributes(attribute.Int("shortener.batch_size", len(requests)))
+
+	if err := validateBatch(requests); err != nil {
+		return nil, recordError(span, err)
+	}
+	for _, req := range requests {
+		if err := ValidateURL(req.OriginalURL); err != nil {
+			return nil, recordError(span, &BatchItemError{CorrelationID: req.CorrelationID, Err: err})
+		}
+		if err := ValidateLinkOptions(req.LinkOptions); err != nil {
+			return nil, recordError(span, &BatchItemError{CorrelationID: req.CorrelationID, Err: err})
+		}
+	}
+
+	saved, err := s.saveBatch(ctx, requests, userID)
+	if err != nil {
+		return nil, recordError(span, err)
+	}
 
+	responses := make([]dto.BatchResponse, len(requests))
 	for i, req := range requests {
-		shortURL := GenerateRandomID()
-		if _, err := s.Store.Save(shortURL, req.OriginalURL, userID); err != nil {
-			return nil, err
+		if saved[i].Conflict {
+			return nil, recordError(span, &BatchItemError{CorrelationID: req.CorrelationID, Err: ErrShortURLTaken})
 		}
 		responses[i] = dto.BatchResponse{
 			CorrelationID: req.CorrelationID,
-			ShortURL:      s.BaseURL + "/" + shortURL,
+			ShortURL:      s.BaseURL + "/" + saved[i].ShortURL,
 		}
 	}
 	return responses, nil
 }
 
-// GetAllUserURLs возвращает все ссылки, сохранённые конкретным пользователем.
-func (s *URLService) GetAllUserURLs(userID string) ([]dto.UserURL, error) {
-	urls, err := s.Store.GetAllByUser(userID)
+// ShortenBatchPartial — режим частичного успеха: некорректные элементы не отклоняют пакет,
+// а возвращаются со статусом invalid и кодом ошибки. Остальные сохраняются и получают
+// статус created или existing; элемент, для которого не нашлось свободного кода, —
+// статус failed с кодом short_url_taken. Пустой пакет и повтор correlation_id по-прежнему
+// отклоняют пакет целиком, как и превышение квоты.
+func (s *URLService) ShortenBatchPartial(ctx context.Context, requests []dto.BatchRequest, userID string) ([]dto.BatchResponse, error) {
+	ctx, span := startSpan(ctx, "URLService.ShortenBatchPartial")
+	defer span.End()
+	span.SetAttributes(attribute.Int("shortener.batch_size", len(requests)))
+
+	if err := validateBatch(requests); err != nil {
+		return nil, recordError(span, err)
+	}
+
+	responses := make([]dto.BatchResponse, len(requests))
+	valid := make([]dto.BatchRequest, 0, len(requests))
+	validIdx := make([]int, 0, len(requests))
+	for i, req := range requests {
+		responses[i].CorrelationID = req.CorrelationID
+		if err := ValidateURL(req.OriginalURL); err != nil {
+			responses[i].Status = BatchItemInvalid
+			responses[i].Error = BatchErrorInvalidURL
+			continue
+		}
+		if err := ValidateLinkOptions(req.LinkOptions); err != nil {
+			responses[i].Status = BatchItemInvalid
+			responses[i].Error = linkOptionsErrorCode(err)
+			continue
+		}
+		valid = append(valid, req)
+		validIdx = append(validIdx, i)
+	}
+	span.SetAttributes(attribute.Int("shortener.batch_invalid", len(requests)-len(valid)))
+	if len(valid) == 0 {
+		return responses, nil
+	}
+
+	saved, err := s.saveBatch(ctx, valid, userID)
 	if err != nil {
-		return nil, err
+		return nil, recordError(span, err)
 	}
-	for i := range urls {
-		urls[i].ShortURL = s.BaseURL + "/" + urls[i].ShortURL
+	for j, item := range saved {
+		resp := &responses[validIdx[j]]
+		if item.Conflict {
+			resp.Status, resp.Error = BatchItemFailed, BatchErrorShortURLTaken
+			continue
+		}
+		resp.ShortURL = s.BaseURL + "/" + item.ShortURL
+		resp.Status = BatchItemCreated
+		if item.Existing {
+			resp.Status = BatchItemExisting
+		}
 	}
-	return urls, nil
+	return responses, nil
 }
 
-// Get возвращает оригинальный URL по сокращённому идентификатору.
-func (s *URLService) Get(shortURL string) (string, error) {
-	return s.Store.Get(shortURL)
+// saveBatch проверяет квоту на весь пакет и сохраняет его через saveRetryingConflicts.
+func (s *URLService) saveBatch(ctx context.Context, requests []dto.BatchRequest, userID string) ([]dto.BatchSaveItem, error) {
+	items := make([]dto.BatchSaveItem, len(requests))
+	originalURLs := make([]string, len(requests))
+	for i, req := range requests {
+		items[i] = dto.BatchSaveItem{
+			ShortURL:    s.newShortID(),
+			OriginalURL: req.OriginalURL,
+			LinkOptions: req.LinkOptions,
+		}
+		originalURLs[i] = req.OriginalURL
+	}
+	if err := s.checkQuota(ctx, userID, originalURLs); err != nil {
+		return nil, err
+	}
+	return s.saveRetryingConflicts(ctx, userID, items, nil)
 }
 
-// EnqueueURLsForDeletion добавляет ссылки в очередь на удаление.
-// Удаление происходит асинхронно worker'ом.
-func (s *URLService) EnqueueURLsForDeletion(userID string, shortURLs []string) {
-	s.deleteQueue <- deleteTask{
-		userID:    userID,
-		shortURLs: shortURLs,
+// saveRetryingConflicts сохраняет items вызовом SaveBatch, а элементы, чей код занят другой
+// ссылкой, сохраняет заново с новым кодом — всего до maxShortURLAttempts попыток.
+// generated(i) сообщает, что код i-го элемента сгенерирован и его можно заменить;
+// nil — сгенерированы все. Элементы, оставшиеся с Conflict, обрабатывает вызывающий.
+func (s *URLService) saveRetryingConflicts(ctx context.Context, userID string, items []dto.BatchSaveItem, generated func(i int) bool) ([]dto.BatchSaveItem, error) {
+	saved, err := s.Store.SaveBatch(ctx, userID, items)
+	if err != nil {
+		return nil, err
 	}
+	for attempt := 1; attempt < maxShortURLAttempts; attempt++ {
+		var retry []dto.BatchSaveItem
+		var retryIdx []int
+		for i, item := range saved {
+			if !item.Conflict || (generated != nil && !generated(i)) {
+				continue
+			}
+			item.ShortURL, item.Conflict = s.newShortID(), false
+			retry = append(retry, item)
+			retryIdx = append(retryIdx, i)
+		}
+		if len(retry) == 0 {
+			break
+		}
+		retried, err := s.Store.SaveBatch(ctx, userID, retry)
+		if err != nil {
+			return nil, err
+		}
+		for j, item := range retried {
+			saved[retryIdx[j]] = item
+		}
+	}
+	return saved, nil
 }
 
-// deleteWorker обрабатывает очередь на удаление, группируя задачи по userID.
-func (s *URLService) deleteWorker() {
-	batchSize := 100
-	buffer := make([]deleteTask, 0, batchSize)
+// newShortID выдаёт код для новой ссылки.
+func (s *URLService) newShortID() string {
+	if s.GenerateID != nil {
+		return s.GenerateID()
+	}
+	return GenerateRandomID()
+}
 
-	for {
-		task, ok := <-s.deleteQueue
-		if !ok {
-			break
-		}
+// logger возвращает журнал сервиса или пустой, если он не задан.
+func (s *URLService) logger() *zap.SugaredLogger {
+	if s.Logger == nil {
+		return zap.NewNop().Sugar()
+	}
+	return s.Logger
+}
 
-		buffer = append(buffer, task)
+// GetAllUserURLs возвращает все ссылки, сохранённые конкретным пользователем.
+func (s *URLService) GetAllUserURLs(ctx context.Context, userID string) ([]dto.UserURL, error) {
+	ctx, span := startSpan(ctx, "URLService.GetAllUserURLs")
+	defer span.End()
 
-		if len(buffer) >= batchSize {
-			s.flushBatch(buffer)
-			buffer = buffer[:0]
-		}
+	urls, err := s.Store.GetAllByUser(ctx, userID)
+	if err != nil {
+		return nil, recordError(span, err)
 	}
+	for i := range urls {
+		urls[i].ShortURL = s.BaseURL + "/" + urls[i].ShortURL
+	}
+	return urls, nil
+}
+
+// Get возвращает оригинальный URL по сокращённому идентификатору.
+func (s *URLService) Get(ctx context.Context, shortURL string) (string, error) {
+	link, err := s.Resolve(ctx, shortURL, "", "")
+	return link.OriginalURL, err
+}
 
-	if len(buffer) > 0 {
-		s.flushBatch(buffer)
+// Resolve возвращает ссылку для перехода по сокращённому идентификатору и учитывает переход.
+// extraPath — путь после кода ссылки в экранированном виде, rawQuery — строка запроса
+// короткой ссылки; они переносятся в OriginalURL по настройкам ссылки. Путь у ссылки
+// без forward_path даёт "not found", путь, который нельзя перенести, — ErrInvalidRedirectPath.
+// Незаданный код перенаправления заменяется умолчанием сервиса.
+func (s *URLService) Resolve(ctx context.Context, shortURL, extraPath, rawQuery string) (dto.ResolvedLink, error) {
+	ctx, span := startSpan(ctx, "URLService.Resolve")
+	defer span.End()
+
+	link, err := s.Store.Resolve(ctx, shortURL)
+	if err != nil {
+		return dto.ResolvedLink{}, recordError(span, err)
+	}
+	if extraPath != "" && !link.ForwardPath {
+		return dto.ResolvedLink{}, recordError(span, errors.New("not found"))
+	}
+	link.OriginalURL, err = redirectTarget(link, extraPath, rawQuery)
+	if err != nil {
+		return dto.ResolvedLink{}, recordError(span, err)
+	}
+	if link.RedirectType == 0 {
+		link.RedirectType = s.RedirectType
+	}
+	if link.RedirectType == 0 {
+		link.RedirectType = http.StatusTemporaryRedirect
 	}
+	if s.Clicks != nil {
+		s.Clicks.RecordClick(shortURL)
+	}
+	return link, nil
 }
 
-// flushBatch удаляет ссылки группами по userID.
-func (s *URLService) flushBatch(tasks []deleteTask) {
-	grouped := make(map[string][]string)
+// UpdateLinkOptions заменяет настройки перехода по ссылке пользователя.
+// Чужая ссылка неотличима от несуществующей: обе дают ErrLinkNotFound.
+func (s *URLService) UpdateLinkOptions(ctx context.Context, userID, shortURL string, opts dto.LinkOptions) error {
+	ctx, span := startSpan(ctx, "URLService.UpdateLinkOptions")
+	defer span.End()
 
-	for _, task := range tasks {
-		grouped[task.userID] = append(grouped[task.userID], task.shortURLs...)
+	if err := ValidateLinkOptions(opts); err != nil {
+		return recordError(span, err)
+	}
+	if err := s.Store.UpdateLinkOptions(ctx, userID, shortURL, opts); err != nil {
+		return recordError(span, err)
 	}
+	return nil
+}
 
-	for userID, urls := range grouped {
-		_ = s.Store.BatchDelete(userID, urls)
+// ExportUserURLs передаёт в fn все ссылки пользователя с метаданными, не собирая их в память.
+// Короткий код дополняется базовым адресом, как в GetAllUserURLs.
+func (s *URLService) ExportUserURLs(ctx context.Context, userID string, fn func(dto.LinkExport) error) error {
+	ctx, span := startSpan(ctx, "URLService.ExportUserURLs")
+	defer span.End()
+
+	err := s.Store.IterateByUser(ctx, userID, func(link dto.LinkExport) error {
+		link.ShortURL = s.BaseURL + "/" + link.ShortURL
+		return fn(link)
+	})
+	if err != nil {
+		return recordError(span, err)
 	}
+	return nil
 }
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+)
+
+var (
+	// ErrInvalidURL — строка не является абсолютным http(s)-адресом.
+	ErrInvalidURL = errors.New("invalid url")
+	// ErrEmptyBatch — пакет не содержит ни одной ссылки.
+	ErrEmptyBatch = errors.New("batch is empty")
+	// ErrDuplicateCorrelationID — correlation_id повторяется внутри пакета.
+	ErrDuplicateCorrelationID = errors.New("duplicate correlation_id")
+	// ErrInvalidRedirectType — код перенаправления не из 301, 302, 307 и 308.
+	ErrInvalidRedirectType = errors.New("invalid redirect_type")
+	// ErrInvalidQueryMode — режим строки запроса не из drop, merge и override.
+	ErrInvalidQueryMode = errors.New("invalid query_mode")
+)
+
+// BatchItemError указывает на элемент пакета, из-за которого отклонён весь пакет.
+type BatchItemError struct {
+	CorrelationID string
+	Err           error
+}
+
+func (e *BatchItemError) Error() string {
+	return fmt.Sprintf("%s: correlation_id %q", e.Err, e.CorrelationID)
+}
+
+func (e *BatchItemError) Unwrap() error {
+	return e.Err
+}
+
+// ValidateURL проверяет, что raw — абсолютный адрес со схемой http или https и непустым хостом.
+func ValidateURL(raw string) error {
+	u, err := url.ParseRequestURI(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidURL
+	}
+	return nil
+}
+
+// ValidateRedirectType проверяет, что code — код перенаправления, который поддерживает сервис.
+func ValidateRedirectType(code int) error {
+	switch code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return nil
+	default:
+		return ErrInvalidRedirectType
+	}
+}
+
+// ValidateLinkOptions проверяет настройки перехода; нулевые поля допустимы.
+func ValidateLinkOptions(opts dto.LinkOptions) error {
+	if opts.RedirectType != 0 {
+		if err := ValidateRedirectType(opts.RedirectType); err != nil {
+			return err
+		}
+	}
+	switch opts.QueryMode {
+	case "", QueryModeDrop, QueryModeMerge, QueryModeOverride:
+		return nil
+	default:
+		return ErrInvalidQueryMode
+	}
+}
+
+// linkOptionsErrorCode возвращает код ошибки элемента пакета для ошибки ValidateLinkOptions.
+func linkOptionsErrorCode(err error) string {
+	if errors.Is(err, ErrInvalidQueryMode) {
+		return BatchErrorInvalidQueryMode
+	}
+	return BatchErrorInvalidRedirectType
+}
+
+// validateBatch отклоняет пустой пакет и повторяющиеся correlation_id.
+func validateBatch(requests []dto.BatchRequest) error {
+	if len(requests) == 0 {
+		return ErrEmptyBatch
+	}
+	seen := make(map[string]struct{}, len(requests))
+	for _, req := range requests {
+		if _, ok := seen[req.CorrelationID]; ok {
+			return &BatchItemError{CorrelationID: req.CorrelationID, Err: ErrDuplicateCorrelationID}
+		}
+		seen[req.CorrelationID] = struct{}{}
+	}
+	return nil
+}
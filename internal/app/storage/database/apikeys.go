@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/service"
+	"github.com/DaniYer/GoProject.git/internal/app/storage/database/queries"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *DBStore) SaveAPIKey(ctx context.Context, key dto.APIKey) error {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	id, err := uuid.Parse(key.ID)
+	if err != nil {
+		return err
+	}
+	return s.queries.InsertAPIKey(ctx, queries.InsertAPIKeyParams{
+		ID:        id,
+		UserID:    key.UserID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		KeyHash:   key.Hash,
+		CreatedAt: key.CreatedAt,
+	})
+}
+
+func (s *DBStore) GetAPIKeyByHash(ctx context.Context, hash string) (dto.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	row, err := s.queries.GetAPIKeyByHash(ctx, hash)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return dto.APIKey{}, service.ErrAPIKeyNotFound
+		}
+		return dto.APIKey{}, err
+	}
+	return apiKeyFromRow(row), nil
+}
+
+func (s *DBStore) ListAPIKeys(ctx context.Context, userID string) ([]dto.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	rows, err := s.queries.ListAPIKeysByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dto.APIKey, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, apiKeyFromRow(row))
+	}
+	return result, nil
+}
+
+func (s *DBStore) RevokeAPIKey(ctx context.Context, userID, keyID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	id, err := uuid.Parse(keyID)
+	if err != nil {
+		return service.ErrAPIKeyNotFound
+	}
+	affected, err := s.queries.DeleteAPIKey(ctx, queries.DeleteAPIKeyParams{ID: id, UserID: userID})
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return service.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (s *DBStore) TouchAPIKey(ctx context.Context, keyID string, usedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	id, err := uuid.Parse(keyID)
+	if err != nil {
+		return service.ErrAPIKeyNotFound
+	}
+	return s.queries.TouchAPIKey(ctx, queries.TouchAPIKeyParams{
+		ID:         id,
+		LastUsedAt: &usedAt,
+	})
+}
+
+// apiKeyFromRow переводит строку таблицы api_keys в dto.APIKey.
+func apiKeyFromRow(row queries.ApiKey) dto.APIKey {
+	return dto.APIKey{
+		ID:         row.ID.String(),
+		UserID:     row.UserID,
+		Name:       row.Name,
+		Prefix:     row.Prefix,
+		Hash:       row.KeyHash,
+		CreatedAt:  row.CreatedAt,
+		LastUsedAt: row.LastUsedAt,
+	}
+}
@@ -2,37 +2,49 @@ package database
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"time"
 
 	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/service"
 	"github.com/DaniYer/GoProject.git/internal/app/storage/database/queries"
-	"github.com/jackc/pgx"
-	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// DBStore — хранилище в PostgreSQL поверх пула соединений pgx.
 type DBStore struct {
-	db      *sql.DB
+	pool    *pgxpool.Pool
 	queries *queries.Queries
+	// db выполняет запросы: пул или транзакция снимка (ReadOnlySnapshot)
+	db queries.DBTX
+	// replicas — реплики для чтения ссылок (AttachReplicas); nil — всё читается из основной базы
+	replicas *replicaSet
 }
 
-func NewDBStore(db *sql.DB) *DBStore {
+func NewDBStore(pool *pgxpool.Pool) *DBStore {
 	return &DBStore{
-		db:      db,
-		queries: queries.New(db),
+		pool:    pool,
+		queries: queries.New(pool),
+		db:      pool,
 	}
 }
 
-func (s *DBStore) Save(shortURL, originalURL, userID string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+// Save сохраняет ссылку или возвращает код активной ссылки с тем же оригинальным URL.
+// Удалённая или истёкшая ссылка остаётся у владельца, новая ложится отдельной строкой.
+func (s *DBStore) Save(ctx context.Context, shortURL, originalURL, userID string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
 	defer cancel()
 
+	if err := s.queries.RetireExpiredURLs(ctx, []string{originalURL}); err != nil {
+		return "", err
+	}
 	newShortURL, err := s.queries.InsertOrGetShortURL(ctx, queries.InsertOrGetShortURLParams{
 		ShortUrl:    shortURL,
 		OriginalUrl: originalURL,
-		UserID:      sql.NullString{String: userID, Valid: true},
+		UserID:      &userID,
 	})
+	s.wrote(shortKey(shortURL), originalKey(originalURL), userKey(userID))
 
 	if errors.Is(err, pgx.ErrNoRows) {
 		existingShortURL, err2 := s.queries.GetByOriginalURL(ctx, originalURL)
@@ -49,24 +61,211 @@ func (s *DBStore) Save(shortURL, originalURL, userID string) (string, error) {
 	return newShortURL, nil
 }
 
-func (s *DBStore) Get(shortURL string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+// SaveBatch сохраняет пакет ссылок в одной транзакции одним многострочным INSERT.
+// Ссылки, чей оригинальный URL уже есть в базе (или повторяется внутри пакета),
+// получают существующий код. Удалённая или истёкшая ссылка с тем же URL не мешает:
+// новая ссылка ложится отдельной строкой, а старая остаётся у владельца. Ошибка откатывает весь пакет.
+func (s *DBStore) SaveBatch(ctx context.Context, userID string, items []dto.BatchSaveItem) ([]dto.BatchSaveItem, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	result, err := s.queries.GetByShortURL(ctx, shortURL)
+	tx, err := s.pool.Begin(ctx)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return "", errors.New("gone")
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+	qtx := s.queries.WithTx(tx)
+
+	// Коды, уже занятые другими ссылками (например, псевдонимы), в INSERT не попадают:
+	// конфликт по short_url иначе откатил бы весь пакет
+	codes := make([]string, len(items))
+	for i, item := range items {
+		codes[i] = item.ShortURL
+	}
+	takenRows, err := qtx.SelectTakenShortURLs(ctx, codes)
+	if err != nil {
+		return nil, err
+	}
+	taken := make(map[string]bool, len(takenRows))
+	for _, shortURL := range takenRows {
+		taken[shortURL] = true
+	}
+
+	var shortURLs, originalURLs, expiresAts []string
+	var redirectTypes []int16
+	var queryModes []string
+	var forwardPaths []bool
+	conflicts := make(map[int]bool)
+	queued := make(map[string]bool, len(items))
+	for i, item := range items {
+		// Повтор оригинального URL в пакете получит код первой строки
+		if queued[item.OriginalURL] {
+			continue
 		}
-		return "", err
+		if taken[item.ShortURL] {
+			conflicts[i] = true
+			continue
+		}
+		taken[item.ShortURL] = true
+		queued[item.OriginalURL] = true
+		shortURLs = append(shortURLs, item.ShortURL)
+		originalURLs = append(originalURLs, item.OriginalURL)
+		expiresAts = append(expiresAts, formatNullableTime(item.ExpiresAt))
+		redirectTypes = append(redirectTypes, int16(item.RedirectType))
+		queryModes = append(queryModes, item.QueryMode)
+		forwardPaths = append(forwardPaths, item.ForwardPath)
+	}
+
+	if err := qtx.RetireExpiredURLs(ctx, originalURLs); err != nil {
+		return nil, err
+	}
+	inserted, err := qtx.InsertURLsBatch(ctx, queries.InsertURLsBatchParams{
+		ShortUrls:     shortURLs,
+		OriginalUrls:  originalURLs,
+		UserID:        userID,
+		ExpiresAts:    expiresAts,
+		RedirectTypes: redirectTypes,
+		QueryModes:    queryModes,
+		ForwardPaths:  forwardPaths,
+	})
+	if err != nil {
+		return nil, err
+	}
+	created := make(map[string]string, len(inserted))
+	for _, row := range inserted {
+		created[row.OriginalUrl] = row.ShortUrl
 	}
+
+	// Всё, что не вставилось, уже существует: дочитываем коды одним запросом
+	var missing []string
+	for _, item := range items {
+		if _, ok := created[item.OriginalURL]; !ok {
+			missing = append(missing, item.OriginalURL)
+		}
+	}
+	existing := make(map[string]string, len(missing))
+	if len(missing) > 0 {
+		rows, err := qtx.GetByOriginalURLs(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			existing[row.OriginalUrl] = row.ShortUrl
+		}
+	}
+
+	result := make([]dto.BatchSaveItem, len(items))
+	for i, item := range items {
+		shortURL, ok := created[item.OriginalURL]
+		switch {
+		case ok && shortURL == item.ShortURL:
+			result[i] = item
+		case ok:
+			// Тот же оригинальный URL раньше в этом же пакете
+			result[i] = dto.BatchSaveItem{ShortURL: shortURL, OriginalURL: item.OriginalURL, Existing: true}
+		default:
+			if shortURL, ok = existing[item.OriginalURL]; ok {
+				result[i] = dto.BatchSaveItem{ShortURL: shortURL, OriginalURL: item.OriginalURL, Existing: true}
+				continue
+			}
+			// Либо код занят, либо строку с этим URL успели изменить параллельно:
+			// в обоих случаях вызывающий повторит строку с новым кодом
+			result[i] = item
+			result[i].Conflict = true
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	keys := []string{userKey(userID)}
+	for _, item := range result {
+		keys = append(keys, shortKey(item.ShortURL), originalKey(item.OriginalURL))
+	}
+	s.wrote(keys...)
 	return result, nil
 }
 
-func (s *DBStore) GetByOriginalURL(originalURL string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+func (s *DBStore) Get(ctx context.Context, shortURL string) (string, error) {
+	link, err := s.Resolve(ctx, shortURL)
+	return link.OriginalURL, err
+}
+
+// Resolve возвращает ссылку для перехода вместе со сроком жизни и настройками.
+// Это самый частый запрос: в режиме cache_statement pgx готовит его на соединении один раз
+// и дальше выполняет как prepared statement. По возможности он читается с реплики. Код, которого на реплике нет, перепроверяется
+// в основной базе: ссылку могли создать только что, в том числе на другом экземпляре.
+func (s *DBStore) Resolve(ctx context.Context, shortURL string) (dto.ResolvedLink, error) {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	var (
+		row queries.GetByShortURLRow
+		err error
+	)
+	if r := s.reader(shortKey(shortURL)); r != nil {
+		row, err = r.queries.GetByShortURL(ctx, shortURL)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			s.readFailed(ctx, r, err)
+		}
+		if err != nil {
+			row, err = s.queries.GetByShortURL(ctx, shortURL)
+		}
+	} else {
+		row, err = s.queries.GetByShortURL(ctx, shortURL)
+	}
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return dto.ResolvedLink{}, errors.New("gone")
+		}
+		return dto.ResolvedLink{}, err
+	}
+	return dto.ResolvedLink{
+		OriginalURL: row.OriginalUrl,
+		ExpiresAt:   row.ExpiresAt,
+		LinkOptions: linkOptions(row.RedirectType, row.QueryMode, row.ForwardPath),
+	}, nil
+}
+
+// UpdateLinkOptions заменяет настройки перехода по активной ссылке пользователя.
+func (s *DBStore) UpdateLinkOptions(ctx context.Context, userID, shortURL string, opts dto.LinkOptions) error {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
 	defer cancel()
 
+	updated, err := s.queries.UpdateLinkOptions(ctx, queries.UpdateLinkOptionsParams{
+		RedirectType: int16(opts.RedirectType),
+		QueryMode:    opts.QueryMode,
+		ForwardPath:  opts.ForwardPath,
+		ShortUrl:     shortURL,
+		UserID:       &userID,
+	})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return service.ErrLinkNotFound
+	}
+	s.wrote(shortKey(shortURL), userKey(userID))
+	return nil
+}
+
+// linkOptions собирает настройки перехода из колонок urls.
+func linkOptions(redirectType int16, queryMode string, forwardPath bool) dto.LinkOptions {
+	return dto.LinkOptions{RedirectType: int(redirectType), QueryMode: queryMode, ForwardPath: forwardPath}
+}
+
+func (s *DBStore) GetByOriginalURL(ctx context.Context, originalURL string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	if r := s.reader(originalKey(originalURL)); r != nil {
+		result, err := r.queries.GetByOriginalURL(ctx, originalURL)
+		if err == nil || errors.Is(err, pgx.ErrNoRows) {
+			return result, err
+		}
+		s.readFailed(ctx, r, err)
+	}
+
 	result, err := s.queries.GetByOriginalURL(ctx, originalURL)
 	if err != nil {
 		return "", err
@@ -74,11 +273,20 @@ func (s *DBStore) GetByOriginalURL(originalURL string) (string, error) {
 	return result, nil
 }
 
-func (s *DBStore) GetAllByUser(userID string) ([]dto.UserURL, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+func (s *DBStore) GetAllByUser(ctx context.Context, userID string) ([]dto.UserURL, error) {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
 	defer cancel()
 
-	urls, err := s.queries.GetAllByUserID(ctx, sql.NullString{String: userID, Valid: true})
+	q := s.queries
+	r := s.reader(userKey(userID))
+	if r != nil {
+		q = r.queries
+	}
+	urls, err := q.GetAllByUserID(ctx, &userID)
+	if err != nil && r != nil {
+		s.readFailed(ctx, r, err)
+		urls, err = s.queries.GetAllByUserID(ctx, &userID)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -93,26 +301,70 @@ func (s *DBStore) GetAllByUser(userID string) ([]dto.UserURL, error) {
 	return result, nil
 }
 
-func (s *DBStore) BatchDelete(userID string, shortURLs []string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+// BatchDelete помечает ссылки пользователя удалёнными и сообщает итог по каждой.
+// Ссылки, которые не удалось удалить, дополнительно ищутся без учёта владельца,
+// чтобы отличить чужую ссылку от несуществующей.
+func (s *DBStore) BatchDelete(ctx context.Context, userID string, shortURLs []string) ([]dto.DeleteResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
 	defer cancel()
 
-	return s.queries.BatchDeleteURLs(ctx, queries.BatchDeleteURLsParams{
-		UserID:  sql.NullString{String: userID, Valid: true},
+	deleted, err := s.queries.BatchDeleteURLs(ctx, queries.BatchDeleteURLsParams{
+		UserID:  &userID,
 		Column2: shortURLs,
 	})
-}
-
-func InitDB(driverName, dataSourceName string) (*sql.DB, error) {
-	db, err := sql.Open(driverName, dataSourceName)
 	if err != nil {
 		return nil, err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	keys := []string{userKey(userID)}
+	for _, shortURL := range deleted {
+		keys = append(keys, shortKey(shortURL))
+	}
+	s.wrote(keys...)
+
+	status := make(map[string]string, len(shortURLs))
+	for _, shortURL := range deleted {
+		status[shortURL] = service.DeleteResultDeleted
+	}
+
+	var rest []string
+	for _, shortURL := range shortURLs {
+		if _, ok := status[shortURL]; !ok {
+			rest = append(rest, shortURL)
+		}
+	}
+	if len(rest) > 0 {
+		existing, err := s.queries.SelectExistingShortURLs(ctx, rest)
+		if err != nil {
+			return nil, err
+		}
+		for _, shortURL := range existing {
+			status[shortURL] = service.DeleteResultNotOwned
+		}
+	}
+
+	results := make([]dto.DeleteResult, 0, len(shortURLs))
+	for _, shortURL := range shortURLs {
+		result, ok := status[shortURL]
+		if !ok {
+			result = service.DeleteResultNotFound
+		}
+		results = append(results, dto.DeleteResult{ShortURL: shortURL, Status: result})
+	}
+	return results, nil
+}
+
+func (s *DBStore) CountActiveByUser(ctx context.Context, userID string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
 	defer cancel()
-	if err = db.PingContext(ctx); err != nil {
-		db.Close()
-		return nil, err
+
+	count, err := s.queries.CountActiveByUserID(ctx, &userID)
+	if err != nil {
+		return 0, err
 	}
-	return db, nil
+	return int(count), nil
+}
+
+// Ping проверяет соединение с PostgreSQL.
+func (s *DBStore) Ping(ctx context.Context) error {
+	return s.pool.Ping(ctx)
 }
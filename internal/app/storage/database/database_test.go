@@ -0,0 +1,159 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestStore подключается к базе из TEST_DATABASE_DSN и накатывает миграции.
+// Без переменной тест пропускается.
+func newTestStore(t *testing.T) *DBStore {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	ctx := context.Background()
+	pool, err := NewPool(ctx, dsn, PoolConfig{})
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+	require.NoError(t, Migrate(ctx, OpenSQL(pool)))
+	return NewDBStore(pool)
+}
+
+func TestDBStore_ExpiredLinkIsReplaced(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	// База общая, поэтому коды, URL и пользователи уникальны для запуска
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+	originalURL := "https://example.com/" + suffix
+	alice, bob := "alice-"+suffix, "bob-"+suffix
+
+	past := time.Now().Add(-time.Minute)
+	_, err := store.SaveBatch(ctx, alice, []dto.BatchSaveItem{
+		{ShortURL: "old" + suffix, OriginalURL: originalURL, ExpiresAt: &past},
+	})
+	require.NoError(t, err)
+
+	count, err := store.CountActiveByUser(ctx, alice)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	_, err = store.GetByOriginalURL(ctx, originalURL)
+	assert.Error(t, err)
+
+	// Истёкшая строка заменяется новой ссылкой, а не выдаётся повторно
+	short, err := store.Save(ctx, "new"+suffix, originalURL, bob)
+	require.NoError(t, err)
+	assert.Equal(t, "new"+suffix, short)
+
+	result, err := store.SaveBatch(ctx, alice, []dto.BatchSaveItem{
+		{ShortURL: "again" + suffix, OriginalURL: originalURL},
+		{ShortURL: "dup" + suffix, OriginalURL: originalURL},
+	})
+	require.NoError(t, err)
+	for _, item := range result {
+		assert.Equal(t, "new"+suffix, item.ShortURL)
+		assert.True(t, item.Existing)
+	}
+
+	count, err = store.CountActiveByUser(ctx, bob)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestDBStore_DeletedLinkIsReplaced(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+	originalURL := "https://example.com/" + suffix
+	alice, bob := "alice-"+suffix, "bob-"+suffix
+
+	_, err := store.Save(ctx, "old"+suffix, originalURL, alice)
+	require.NoError(t, err)
+	_, err = store.BatchDelete(ctx, alice, []string{"old" + suffix})
+	require.NoError(t, err)
+
+	// Удалённая ссылка не мешает сократить тот же URL заново
+	result, err := store.SaveBatch(ctx, bob, []dto.BatchSaveItem{
+		{ShortURL: "new" + suffix, OriginalURL: originalURL},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, dto.BatchSaveItem{ShortURL: "new" + suffix, OriginalURL: originalURL}, result[0])
+	// Новая ссылка — отдельная строка: старая по-прежнему удалена
+	_, err = store.Get(ctx, "old"+suffix)
+	assert.EqualError(t, err, "gone")
+
+	original, err := store.Get(ctx, "new"+suffix)
+	require.NoError(t, err)
+	assert.Equal(t, originalURL, original)
+}
+
+func TestDBStore_DeletedLinkStaysInOwnerExport(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+	originalURL := "https://example.com/" + suffix
+	alice, bob := "alice-"+suffix, "bob-"+suffix
+
+	_, err := store.Save(ctx, "old"+suffix, originalURL, alice)
+	require.NoError(t, err)
+	_, err = store.BatchDelete(ctx, alice, []string{"old" + suffix})
+	require.NoError(t, err)
+
+	short, err := store.Save(ctx, "new"+suffix, originalURL, bob)
+	require.NoError(t, err)
+	assert.Equal(t, "new"+suffix, short)
+
+	// Чужое повторное сокращение не забирает строку у прежнего владельца
+	var exported []dto.LinkExport
+	require.NoError(t, store.IterateByUser(ctx, alice, func(link dto.LinkExport) error {
+		exported = append(exported, link)
+		return nil
+	}))
+	require.Len(t, exported, 1)
+	assert.Equal(t, "old"+suffix, exported[0].ShortURL)
+	assert.True(t, exported[0].Deleted)
+	_, err = store.Get(ctx, "old"+suffix)
+	assert.EqualError(t, err, "gone")
+}
+
+func TestDBStore_InsertIsNotified(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	conn, err := pgx.Connect(ctx, os.Getenv("TEST_DATABASE_DSN"))
+	require.NoError(t, err)
+	defer conn.Close(ctx)
+	_, err = conn.Exec(ctx, "LISTEN "+ChangesChannel)
+	require.NoError(t, err)
+
+	// Другой экземпляр мог закэшировать этот код как несуществующий
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+	_, err = store.Save(ctx, "ins"+suffix, "https://example.com/"+suffix, "alice-"+suffix)
+	require.NoError(t, err)
+
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	for {
+		notification, err := conn.WaitForNotification(waitCtx)
+		require.NoError(t, err)
+		var change URLChange
+		require.NoError(t, json.Unmarshal([]byte(notification.Payload), &change))
+		if change.ShortURL == "ins"+suffix {
+			assert.Equal(t, ChangeInsert, change.Op)
+			return
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/storage/database/queries"
+)
+
+// exportPageSize — сколько строк читается за один запрос при выгрузке.
+const exportPageSize = 1000
+
+// IterateByUser обходит ссылки пользователя страницами по exportPageSize строк,
+// поэтому в памяти одновременно находится не больше одной страницы.
+func (s *DBStore) IterateByUser(ctx context.Context, userID string, fn func(dto.LinkExport) error) error {
+	var afterID int32
+	for {
+		rows, err := s.listUserLinksPage(ctx, userID, afterID)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			link := dto.LinkExport{
+				ShortURL:    row.ShortUrl,
+				OriginalURL: row.OriginalUrl,
+				CreatedAt:   row.CreatedAt,
+				Deleted:     row.IsDeleted,
+				ExpiresAt:   row.ExpiresAt,
+				Clicks:      row.Clicks,
+				LinkOptions: linkOptions(row.RedirectType, row.QueryMode, row.ForwardPath),
+			}
+			if err := fn(link); err != nil {
+				return err
+			}
+			afterID = row.ID
+		}
+		if len(rows) < exportPageSize {
+			return nil
+		}
+	}
+}
+
+func (s *DBStore) listUserLinksPage(ctx context.Context, userID string, afterID int32) ([]queries.ListUserLinksPageRow, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return s.queries.ListUserLinksPage(ctx, queries.ListUserLinksPageParams{
+		UserID:   &userID,
+		AfterID:  afterID,
+		PageSize: exportPageSize,
+	})
+}
+
+// AddClicks прибавляет накопленные переходы к счётчикам одним UPDATE.
+func (s *DBStore) AddClicks(ctx context.Context, counts map[string]int64) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	params := queries.AddClicksParams{
+		ShortUrls: make([]string, 0, len(counts)),
+		Counts:    make([]int64, 0, len(counts)),
+	}
+	for short, n := range counts {
+		params.ShortUrls = append(params.ShortUrls, short)
+		params.Counts = append(params.Counts, n)
+	}
+	return s.queries.AddClicks(ctx, params)
+}
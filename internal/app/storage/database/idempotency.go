@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/storage/database/queries"
+	"github.com/jackc/pgx/v5"
+)
+
+// ReserveIdempotencyKey занимает ключ или возвращает запись, которая уже его держит.
+// Истёкшая запись перезаписывается тем же INSERT ... ON CONFLICT, поэтому два экземпляра
+// не могут занять один ключ одновременно.
+func (s *DBStore) ReserveIdempotencyKey(ctx context.Context, userID, key string, rec dto.IdempotencyRecord, now time.Time) (dto.IdempotencyRecord, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	// Запись может исчезнуть между INSERT и SELECT (её освободил упавший запрос) — тогда пробуем ещё раз
+	for attempt := 0; attempt < 2; attempt++ {
+		reserved, err := s.queries.ReserveIdempotencyKey(ctx, queries.ReserveIdempotencyKeyParams{
+			UserID:      userID,
+			IdemKey:     key,
+			Fingerprint: rec.Fingerprint,
+			ExpiresAt:   rec.ExpiresAt,
+			Now:         now,
+		})
+		if err != nil {
+			return dto.IdempotencyRecord{}, false, err
+		}
+		if reserved > 0 {
+			return rec, true, nil
+		}
+
+		row, err := s.queries.GetIdempotencyKey(ctx, queries.GetIdempotencyKeyParams{UserID: userID, IdemKey: key})
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return dto.IdempotencyRecord{}, false, err
+		}
+		return dto.IdempotencyRecord{
+			Fingerprint: row.Fingerprint,
+			Completed:   row.Completed,
+			StatusCode:  int(row.StatusCode),
+			ContentType: row.ContentType,
+			Location:    row.Location,
+			Body:        row.Body,
+			ExpiresAt:   row.ExpiresAt,
+		}, false, nil
+	}
+	return dto.IdempotencyRecord{}, false, errors.New("idempotency key reservation conflict")
+}
+
+// CompleteIdempotencyKey сохраняет ответ на запрос с ключом.
+func (s *DBStore) CompleteIdempotencyKey(ctx context.Context, userID, key string, rec dto.IdempotencyRecord) error {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	return s.queries.CompleteIdempotencyKey(ctx, queries.CompleteIdempotencyKeyParams{
+		UserID:      userID,
+		IdemKey:     key,
+		StatusCode:  int32(rec.StatusCode),
+		ContentType: rec.ContentType,
+		Location:    rec.Location,
+		Body:        rec.Body,
+	})
+}
+
+// ReleaseIdempotencyKey освобождает ключ незавершённого запроса.
+func (s *DBStore) ReleaseIdempotencyKey(ctx context.Context, userID, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	return s.queries.ReleaseIdempotencyKey(ctx, queries.ReleaseIdempotencyKeyParams{UserID: userID, IdemKey: key})
+}
+
+// PurgeIdempotencyKeys удаляет ключи, истёкшие раньше before.
+func (s *DBStore) PurgeIdempotencyKeys(ctx context.Context, before time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	return s.queries.PurgeIdempotencyKeys(ctx, before)
+}
@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/storage/database/queries"
+	"github.com/jackc/pgx/v5"
+)
+
+// IterateLinks обходит все ссылки в порядке короткого кода, начиная после after,
+// страницами по exportPageSize строк.
+func (s *DBStore) IterateLinks(ctx context.Context, after string, fn func(dto.StoredLink) error) error {
+	for {
+		rows, err := s.listLinksPage(ctx, after)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			link := dto.StoredLink{
+				ShortURL:    row.ShortUrl,
+				OriginalURL: row.OriginalUrl,
+				CreatedAt:   row.CreatedAt,
+				ExpiresAt:   row.ExpiresAt,
+				Deleted:     row.IsDeleted,
+				Clicks:      row.Clicks,
+				LinkOptions: linkOptions(row.RedirectType, row.QueryMode, row.ForwardPath),
+			}
+			if row.UserID != nil {
+				link.UserID = *row.UserID
+			}
+			if err := fn(link); err != nil {
+				return err
+			}
+			after = row.ShortUrl
+		}
+		if len(rows) < exportPageSize {
+			return nil
+		}
+	}
+}
+
+func (s *DBStore) listLinksPage(ctx context.Context, after string) ([]queries.ListLinksPageRow, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return s.queries.ListLinksPage(ctx, queries.ListLinksPageParams{AfterShortUrl: after, PageSize: exportPageSize})
+}
+
+// ImportLinks вставляет ссылки как есть одним INSERT. Ссылка с занятым кодом и активная ссылка,
+// чей оригинальный URL уже у другой активной, пропускаются, поэтому повторный импорт безопасен. Возвращает число добавленных.
+func (s *DBStore) ImportLinks(ctx context.Context, links []dto.StoredLink) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	params := queries.ImportLinksParams{}
+	for _, link := range links {
+		params.ShortUrls = append(params.ShortUrls, link.ShortURL)
+		params.OriginalUrls = append(params.OriginalUrls, link.OriginalURL)
+		params.UserIds = append(params.UserIds, link.UserID)
+		params.CreatedAts = append(params.CreatedAts, formatNullableTime(link.CreatedAt))
+		params.ExpiresAts = append(params.ExpiresAts, formatNullableTime(link.ExpiresAt))
+		params.Deleted = append(params.Deleted, link.Deleted)
+		params.Clicks = append(params.Clicks, link.Clicks)
+		params.RedirectTypes = append(params.RedirectTypes, int16(link.RedirectType))
+		params.QueryModes = append(params.QueryModes, link.QueryMode)
+		params.ForwardPaths = append(params.ForwardPaths, link.ForwardPath)
+	}
+	inserted, err := s.queries.ImportLinks(ctx, params)
+	if err != nil {
+		return 0, err
+	}
+	return len(inserted), nil
+}
+
+// formatNullableTime переводит время в текст для массивов timestamptz; nil — пустая строка (NULL).
+func formatNullableTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// IterateAPIKeys обходит все API-ключи в порядке создания.
+func (s *DBStore) IterateAPIKeys(ctx context.Context, fn func(dto.APIKey) error) error {
+	rows, err := s.queries.ListAllAPIKeys(ctx)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := fn(apiKeyFromRow(row)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadOnlySnapshot выполняет fn над хранилищем, все запросы которого идут в одной
+// транзакции REPEATABLE READ только для чтения: fn видит согласованный срез данных
+// на момент её начала, даже если сервис параллельно пишет.
+func (s *DBStore) ReadOnlySnapshot(ctx context.Context, fn func(*DBStore) error) error {
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(&DBStore{pool: s.pool, queries: s.queries.WithTx(tx), db: tx}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,37 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+
+	"github.com/pressly/goose/v3"
+)
+
+// Миграции вшиты в бинарник, поэтому сервис не зависит от рабочего каталога.
+//
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// NewMigrator возвращает goose-провайдер вшитых миграций PostgreSQL.
+func NewMigrator(db *sql.DB) (*goose.Provider, error) {
+	fsys, err := fs.Sub(migrations, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return goose.NewProvider(goose.DialectPostgres, db, fsys)
+}
+
+// Migrate применяет к базе все вшитые миграции, которые ещё не применены.
+func Migrate(ctx context.Context, db *sql.DB) error {
+	provider, err := NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	if _, err := provider.Up(ctx); err != nil {
+		return fmt.Errorf("postgres migrations: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE INDEX IF NOT EXISTS idx_urls_active_user_id ON urls (user_id) WHERE is_deleted = false;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_urls_active_user_id;
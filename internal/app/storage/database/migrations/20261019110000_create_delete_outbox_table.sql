@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS delete_outbox (
+    id UUID PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL,
+    short_urls TEXT[] NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    locked_until TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_delete_outbox_locked_until ON delete_outbox (locked_until);
+
+-- +goose Down
+DROP TABLE IF EXISTS delete_outbox;
@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE delete_outbox
+    ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    ADD COLUMN IF NOT EXISTS results JSONB NOT NULL DEFAULT '[]',
+    ADD COLUMN IF NOT EXISTS completed_at TIMESTAMPTZ;
+DROP INDEX IF EXISTS idx_delete_outbox_locked_until;
+CREATE INDEX IF NOT EXISTS idx_delete_outbox_pending ON delete_outbox (locked_until) WHERE status = 'pending';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_delete_outbox_pending;
+CREATE INDEX IF NOT EXISTS idx_delete_outbox_locked_until ON delete_outbox (locked_until);
+ALTER TABLE delete_outbox
+    DROP COLUMN IF EXISTS completed_at,
+    DROP COLUMN IF EXISTS results,
+    DROP COLUMN IF EXISTS status;
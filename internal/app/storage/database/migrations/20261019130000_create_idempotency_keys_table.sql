@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id VARCHAR(36) NOT NULL,
+    idem_key VARCHAR(255) NOT NULL,
+    fingerprint CHAR(64) NOT NULL,
+    completed BOOLEAN NOT NULL DEFAULT FALSE,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    content_type TEXT NOT NULL DEFAULT '',
+    location TEXT NOT NULL DEFAULT '',
+    body BYTEA NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, idem_key)
+);
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS idempotency_keys;
@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE urls
+    ALTER COLUMN short_url TYPE VARCHAR(32),
+    ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+
+-- +goose Down
+-- Ширину short_url не сужаем: псевдонимы длиннее 8 символов не поместились бы в VARCHAR(8)
+ALTER TABLE urls DROP COLUMN IF EXISTS expires_at;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- У существующих ссылок дата создания неизвестна и остаётся NULL
+ALTER TABLE urls
+    ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS clicks BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE urls ALTER COLUMN created_at SET DEFAULT now();
+-- Постраничная выгрузка ссылок пользователя идёт по (user_id, id)
+CREATE INDEX IF NOT EXISTS idx_urls_user_id_id ON urls (user_id, id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_urls_user_id_id;
+ALTER TABLE urls
+    DROP COLUMN IF EXISTS clicks,
+    DROP COLUMN IF EXISTS created_at;
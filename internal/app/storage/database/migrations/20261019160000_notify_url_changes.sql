@@ -0,0 +1,42 @@
+-- +goose Up
+-- Каждое изменение ссылки публикуется в канал shortener_url_changes, чтобы другие
+-- экземпляры сбросили её из локального кэша. Полезная нагрузка: {"op": ..., "short_url": ...}
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION notify_url_change() RETURNS trigger AS $$
+DECLARE
+    op TEXT := 'update';
+BEGIN
+    IF TG_OP = 'DELETE' OR (NEW.is_deleted AND NOT OLD.is_deleted) THEN
+        op := 'delete';
+    ELSIF NEW.expires_at IS DISTINCT FROM OLD.expires_at THEN
+        op := 'expire';
+    END IF;
+    PERFORM pg_notify('shortener_url_changes', json_build_object('op', op, 'short_url', OLD.short_url)::text);
+    -- При смене кода устаревает и запись для нового: он мог быть закэширован как несуществующий
+    IF TG_OP = 'UPDATE' AND NEW.short_url <> OLD.short_url THEN
+        PERFORM pg_notify('shortener_url_changes', json_build_object('op', op, 'short_url', NEW.short_url)::text);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- Счётчик переходов на адрес не влияет, поэтому его обновления не публикуются
+CREATE TRIGGER urls_notify_update
+    AFTER UPDATE OF original_url, short_url, is_deleted, expires_at ON urls
+    FOR EACH ROW
+    WHEN (OLD.original_url IS DISTINCT FROM NEW.original_url
+        OR OLD.short_url IS DISTINCT FROM NEW.short_url
+        OR OLD.is_deleted IS DISTINCT FROM NEW.is_deleted
+        OR OLD.expires_at IS DISTINCT FROM NEW.expires_at)
+    EXECUTE FUNCTION notify_url_change();
+
+CREATE TRIGGER urls_notify_delete
+    AFTER DELETE ON urls
+    FOR EACH ROW
+    EXECUTE FUNCTION notify_url_change();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS urls_notify_delete ON urls;
+DROP TRIGGER IF EXISTS urls_notify_update ON urls;
+DROP FUNCTION IF EXISTS notify_url_change();
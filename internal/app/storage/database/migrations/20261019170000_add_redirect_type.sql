@@ -0,0 +1,28 @@
+-- +goose Up
+-- Код перенаправления ссылки; 0 — умолчание сервера
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS redirect_type SMALLINT NOT NULL DEFAULT 0;
+
+-- Смена кода перенаправления тоже должна сбрасывать кэши других экземпляров
+DROP TRIGGER IF EXISTS urls_notify_update ON urls;
+CREATE TRIGGER urls_notify_update
+    AFTER UPDATE OF original_url, short_url, is_deleted, expires_at, redirect_type ON urls
+    FOR EACH ROW
+    WHEN (OLD.original_url IS DISTINCT FROM NEW.original_url
+        OR OLD.short_url IS DISTINCT FROM NEW.short_url
+        OR OLD.is_deleted IS DISTINCT FROM NEW.is_deleted
+        OR OLD.expires_at IS DISTINCT FROM NEW.expires_at
+        OR OLD.redirect_type IS DISTINCT FROM NEW.redirect_type)
+    EXECUTE FUNCTION notify_url_change();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS urls_notify_update ON urls;
+CREATE TRIGGER urls_notify_update
+    AFTER UPDATE OF original_url, short_url, is_deleted, expires_at ON urls
+    FOR EACH ROW
+    WHEN (OLD.original_url IS DISTINCT FROM NEW.original_url
+        OR OLD.short_url IS DISTINCT FROM NEW.short_url
+        OR OLD.is_deleted IS DISTINCT FROM NEW.is_deleted
+        OR OLD.expires_at IS DISTINCT FROM NEW.expires_at)
+    EXECUTE FUNCTION notify_url_change();
+
+ALTER TABLE urls DROP COLUMN IF EXISTS redirect_type;
@@ -0,0 +1,33 @@
+-- +goose Up
+-- Перенос строки запроса и пути при переходе; пустой режим и false — без переноса
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS query_mode VARCHAR(16) NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS forward_path BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Смена настроек переноса тоже должна сбрасывать кэши других экземпляров
+DROP TRIGGER IF EXISTS urls_notify_update ON urls;
+CREATE TRIGGER urls_notify_update
+    AFTER UPDATE OF original_url, short_url, is_deleted, expires_at, redirect_type, query_mode, forward_path ON urls
+    FOR EACH ROW
+    WHEN (OLD.original_url IS DISTINCT FROM NEW.original_url
+        OR OLD.short_url IS DISTINCT FROM NEW.short_url
+        OR OLD.is_deleted IS DISTINCT FROM NEW.is_deleted
+        OR OLD.expires_at IS DISTINCT FROM NEW.expires_at
+        OR OLD.redirect_type IS DISTINCT FROM NEW.redirect_type
+        OR OLD.query_mode IS DISTINCT FROM NEW.query_mode
+        OR OLD.forward_path IS DISTINCT FROM NEW.forward_path)
+    EXECUTE FUNCTION notify_url_change();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS urls_notify_update ON urls;
+CREATE TRIGGER urls_notify_update
+    AFTER UPDATE OF original_url, short_url, is_deleted, expires_at, redirect_type ON urls
+    FOR EACH ROW
+    WHEN (OLD.original_url IS DISTINCT FROM NEW.original_url
+        OR OLD.short_url IS DISTINCT FROM NEW.short_url
+        OR OLD.is_deleted IS DISTINCT FROM NEW.is_deleted
+        OR OLD.expires_at IS DISTINCT FROM NEW.expires_at
+        OR OLD.redirect_type IS DISTINCT FROM NEW.redirect_type)
+    EXECUTE FUNCTION notify_url_change();
+
+ALTER TABLE urls DROP COLUMN IF EXISTS forward_path;
+ALTER TABLE urls DROP COLUMN IF EXISTS query_mode;
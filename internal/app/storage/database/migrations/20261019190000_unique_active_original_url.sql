@@ -0,0 +1,11 @@
+-- +goose Up
+-- Оригинальный URL уникален только среди активных ссылок: удалённые строки остаются
+-- у владельцев, а тот же URL сокращается заново отдельной строкой
+ALTER TABLE urls DROP CONSTRAINT IF EXISTS unique_original_url;
+ALTER TABLE urls DROP CONSTRAINT IF EXISTS urls_original_url_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_urls_active_original_url ON urls (original_url) WHERE NOT is_deleted;
+
+-- +goose Down
+-- Откат невозможен, если один URL уже сокращался после удаления: ограничение не создастся
+DROP INDEX IF EXISTS idx_urls_active_original_url;
+ALTER TABLE urls ADD CONSTRAINT unique_original_url UNIQUE (original_url);
@@ -0,0 +1,52 @@
+-- +goose Up
+-- Новая ссылка тоже публикуется: другой экземпляр мог закэшировать её код как несуществующий.
+-- Естественное истечение срока события не порождает, op 'expire' означает смену expires_at
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION notify_url_change() RETURNS trigger AS $$
+DECLARE
+    op TEXT := 'update';
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        PERFORM pg_notify('shortener_url_changes', json_build_object('op', 'insert', 'short_url', NEW.short_url)::text);
+        RETURN NULL;
+    END IF;
+    IF TG_OP = 'DELETE' OR (NEW.is_deleted AND NOT OLD.is_deleted) THEN
+        op := 'delete';
+    ELSIF NEW.expires_at IS DISTINCT FROM OLD.expires_at THEN
+        op := 'expire';
+    END IF;
+    PERFORM pg_notify('shortener_url_changes', json_build_object('op', op, 'short_url', OLD.short_url)::text);
+    -- При смене кода устаревает и запись для нового: он мог быть закэширован как несуществующий
+    IF TG_OP = 'UPDATE' AND NEW.short_url <> OLD.short_url THEN
+        PERFORM pg_notify('shortener_url_changes', json_build_object('op', op, 'short_url', NEW.short_url)::text);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER urls_notify_insert
+    AFTER INSERT ON urls
+    FOR EACH ROW
+    EXECUTE FUNCTION notify_url_change();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS urls_notify_insert ON urls;
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION notify_url_change() RETURNS trigger AS $$
+DECLARE
+    op TEXT := 'update';
+BEGIN
+    IF TG_OP = 'DELETE' OR (NEW.is_deleted AND NOT OLD.is_deleted) THEN
+        op := 'delete';
+    ELSIF NEW.expires_at IS DISTINCT FROM OLD.expires_at THEN
+        op := 'expire';
+    END IF;
+    PERFORM pg_notify('shortener_url_changes', json_build_object('op', op, 'short_url', OLD.short_url)::text);
+    IF TG_OP = 'UPDATE' AND NEW.short_url <> OLD.short_url THEN
+        PERFORM pg_notify('shortener_url_changes', json_build_object('op', op, 'short_url', NEW.short_url)::text);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
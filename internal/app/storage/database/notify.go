@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+)
+
+// ChangesChannel — канал NOTIFY, в который триггер на urls публикует изменения ссылок.
+const ChangesChannel = "shortener_url_changes"
+
+// Виды изменений ссылки в событии URLChange. ChangeExpire означает смену срока жизни:
+// естественное истечение срока событий не порождает, кэш сам не держит ссылку дольше него.
+const (
+	ChangeInsert = "insert"
+	ChangeDelete = "delete"
+	ChangeUpdate = "update"
+	ChangeExpire = "expire"
+)
+
+// URLChange — событие об изменении ссылки, полученное из ChangesChannel.
+type URLChange struct {
+	Op       string `json:"op"`
+	ShortURL string `json:"short_url"`
+}
+
+// notificationConn — соединение, подписанное на ChangesChannel.
+type notificationConn interface {
+	WaitForNotification(ctx context.Context) (*pgconn.Notification, error)
+	Close(ctx context.Context) error
+}
+
+// ChangeListener слушает ChangesChannel отдельным соединением и передаёт события в onChange.
+// Пока соединения нет, события теряются, поэтому при каждой потере и восстановлении
+// подписки вызывается onReset — например, чтобы целиком сбросить кэш.
+type ChangeListener struct {
+	connect  func(ctx context.Context) (notificationConn, error)
+	onChange func(URLChange)
+	onReset  func()
+	logger   *zap.SugaredLogger
+
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewChangeListener создаёт слушателя изменений для базы dsn.
+func NewChangeListener(dsn string, onChange func(URLChange), onReset func(), logger *zap.SugaredLogger) *ChangeListener {
+	return &ChangeListener{
+		connect: func(ctx context.Context) (notificationConn, error) {
+			conn, err := pgx.Connect(ctx, dsn)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := conn.Exec(ctx, "LISTEN "+ChangesChannel); err != nil {
+				conn.Close(ctx)
+				return nil, err
+			}
+			return conn, nil
+		},
+		onChange:   onChange,
+		onReset:    onReset,
+		logger:     logger,
+		minBackoff: 500 * time.Millisecond,
+		maxBackoff: 30 * time.Second,
+	}
+}
+
+// Start запускает прослушивание в фоне.
+func (l *ChangeListener) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	l.cancel = cancel
+	l.wg.Add(1)
+	go l.run(ctx)
+}
+
+// Shutdown останавливает прослушивание и закрывает соединение.
+func (l *ChangeListener) Shutdown() {
+	if l.cancel == nil {
+		return
+	}
+	l.cancel()
+	l.wg.Wait()
+}
+
+func (l *ChangeListener) run(ctx context.Context) {
+	defer l.wg.Done()
+
+	backoff := l.minBackoff
+	for ctx.Err() == nil {
+		conn, err := l.connect(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			l.logger.Warnw("change listener connect failed", "error", err, "retry_in", backoff)
+			if !sleep(ctx, backoff) {
+				return
+			}
+			backoff = min(backoff*2, l.maxBackoff)
+			continue
+		}
+
+		// Пока подписки не было, изменения могли пройти мимо
+		backoff = l.minBackoff
+		l.onReset()
+		err = l.listen(ctx, conn)
+		conn.Close(context.Background())
+		if ctx.Err() != nil {
+			return
+		}
+		l.logger.Warnw("change listener connection lost", "error", err)
+		l.onReset()
+	}
+}
+
+// listen передаёт события из соединения, пока оно живо.
+func (l *ChangeListener) listen(ctx context.Context, conn notificationConn) error {
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var change URLChange
+		if err := json.Unmarshal([]byte(notification.Payload), &change); err != nil || change.ShortURL == "" {
+			l.logger.Warnw("malformed url change notification", "payload", notification.Payload)
+			continue
+		}
+		l.onChange(change)
+	}
+}
+
+// sleep ждёт d или отмены ctx; возвращает false, если ctx отменён.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
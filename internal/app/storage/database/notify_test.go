@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeConn отдаёт заранее заданные уведомления, после чего соединение «рвётся».
+type fakeConn struct {
+	payloads []string
+}
+
+func (c *fakeConn) WaitForNotification(ctx context.Context) (*pgconn.Notification, error) {
+	if len(c.payloads) == 0 {
+		return nil, errors.New("connection reset")
+	}
+	payload := c.payloads[0]
+	c.payloads = c.payloads[1:]
+	return &pgconn.Notification{Channel: ChangesChannel, Payload: payload}, nil
+}
+
+func (c *fakeConn) Close(ctx context.Context) error { return nil }
+
+func TestChangeListener_DeliversChangesAndResetsOnLoss(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		changes  []URLChange
+		resets   int
+		connects int
+	)
+	conns := []notificationConn{
+		&fakeConn{payloads: []string{`{"op":"delete","short_url":"abc"}`, `not json`}},
+		&fakeConn{payloads: []string{`{"op":"expire","short_url":"xyz"}`}},
+	}
+
+	listener := &ChangeListener{
+		connect: func(ctx context.Context) (notificationConn, error) {
+			mu.Lock()
+			connects++
+			switch {
+			case connects == 2:
+				mu.Unlock()
+				return nil, errors.New("connection refused")
+			case len(conns) > 0:
+				conn := conns[0]
+				conns = conns[1:]
+				mu.Unlock()
+				return conn, nil
+			}
+			mu.Unlock()
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+		onChange: func(change URLChange) {
+			mu.Lock()
+			defer mu.Unlock()
+			changes = append(changes, change)
+		},
+		onReset: func() {
+			mu.Lock()
+			defer mu.Unlock()
+			resets++
+		},
+		logger:     zap.NewNop().Sugar(),
+		minBackoff: time.Millisecond,
+		maxBackoff: time.Millisecond,
+	}
+	listener.Start()
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(changes) == 2 && connects >= 4
+	}, time.Second, time.Millisecond)
+	listener.Shutdown()
+
+	assert.Equal(t, []URLChange{
+		{Op: ChangeDelete, ShortURL: "abc"},
+		{Op: ChangeExpire, ShortURL: "xyz"},
+	}, changes)
+	// Сброс при каждой подписке и при каждой потере соединения
+	assert.Equal(t, 4, resets)
+}
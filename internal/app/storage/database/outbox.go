@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/service"
+	"github.com/DaniYer/GoProject.git/internal/app/storage/database/queries"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// AppendDeleteJob сохраняет задачу удаления в outbox, сразу закрепляя её
+// за текущим экземпляром до leaseUntil.
+func (s *DBStore) AppendDeleteJob(ctx context.Context, job dto.DeleteJob, leaseUntil time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	id, err := uuid.Parse(job.ID)
+	if err != nil {
+		return err
+	}
+	return s.queries.InsertDeleteJob(ctx, queries.InsertDeleteJobParams{
+		ID:          id,
+		UserID:      job.UserID,
+		ShortUrls:   job.ShortURLs,
+		CreatedAt:   job.CreatedAt,
+		LockedUntil: leaseUntil,
+	})
+}
+
+// ClaimDeleteJobs забирает до limit невыполненных задач с истёкшей арендой и продлевает её до leaseUntil.
+// FOR UPDATE SKIP LOCKED не даёт двум экземплярам забрать одну и ту же задачу.
+func (s *DBStore) ClaimDeleteJobs(ctx context.Context, now, leaseUntil time.Time, limit int) ([]dto.DeleteJob, error) {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	rows, err := s.queries.ClaimDeleteJobs(ctx, queries.ClaimDeleteJobsParams{
+		LeaseUntil: leaseUntil,
+		Now:        now,
+		MaxJobs:    int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]dto.DeleteJob, 0, len(rows))
+	for _, row := range rows {
+		jobs = append(jobs, dto.DeleteJob{
+			ID:        row.ID.String(),
+			UserID:    row.UserID,
+			ShortURLs: row.ShortUrls,
+			CreatedAt: row.CreatedAt,
+		})
+	}
+	return jobs, nil
+}
+
+// CompleteDeleteJob сохраняет итог выполненной задачи.
+func (s *DBStore) CompleteDeleteJob(ctx context.Context, status dto.DeleteJobStatus) error {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	id, err := uuid.Parse(status.ID)
+	if err != nil {
+		return err
+	}
+	results, err := json.Marshal(status.Results)
+	if err != nil {
+		return err
+	}
+
+	return s.queries.CompleteDeleteJob(ctx, queries.CompleteDeleteJobParams{
+		ID:          id,
+		Status:      status.Status,
+		Results:     results,
+		CompletedAt: status.CompletedAt,
+	})
+}
+
+// GetDeleteJob возвращает состояние задачи пользователя.
+// Чужая задача неотличима от несуществующей.
+func (s *DBStore) GetDeleteJob(ctx context.Context, userID, jobID string) (dto.DeleteJobStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	id, err := uuid.Parse(jobID)
+	if err != nil {
+		return dto.DeleteJobStatus{}, service.ErrDeleteJobNotFound
+	}
+	row, err := s.queries.GetDeleteJob(ctx, queries.GetDeleteJobParams{ID: id, UserID: userID})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return dto.DeleteJobStatus{}, service.ErrDeleteJobNotFound
+		}
+		return dto.DeleteJobStatus{}, err
+	}
+
+	status := dto.DeleteJobStatus{
+		ID:          row.ID.String(),
+		Status:      row.Status,
+		CreatedAt:   row.CreatedAt,
+		CompletedAt: row.CompletedAt,
+	}
+	if err := json.Unmarshal(row.Results, &status.Results); err != nil {
+		return dto.DeleteJobStatus{}, err
+	}
+	return status, nil
+}
+
+// PurgeDeleteJobs удаляет выполненные задачи, завершённые раньше completedBefore.
+func (s *DBStore) PurgeDeleteJobs(ctx context.Context, completedBefore time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	return s.queries.PurgeDeleteJobs(ctx, &completedBefore)
+}
@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// Режимы выполнения запросов pgx (PoolConfig.StatementCacheMode).
+const (
+	// StatementCacheStatement — запросы готовятся на сервере и кэшируются в соединении.
+	StatementCacheStatement = "cache_statement"
+	// StatementCacheDescribe — кэшируется только описание запроса, без серверных prepared statements.
+	StatementCacheDescribe = "cache_describe"
+	// StatementCacheExec — каждый запрос описывается заново, ничего не кэшируется.
+	StatementCacheExec = "exec"
+	// StatementCacheSimple — простой протокол, совместимый с PgBouncer в режиме транзакций.
+	StatementCacheSimple = "simple_protocol"
+)
+
+// PoolConfig — параметры пула соединений. Нулевые значения оставляют умолчания pgx.
+type PoolConfig struct {
+	MaxConns           int32
+	MinConns           int32
+	MaxConnLifetime    time.Duration
+	MaxConnIdleTime    time.Duration
+	HealthCheckPeriod  time.Duration
+	ConnectTimeout     time.Duration
+	StatementCacheMode string
+}
+
+// NewPool открывает пул соединений pgx и проверяет связь с базой.
+func NewPool(ctx context.Context, dsn string, cfg PoolConfig) (*pgxpool.Pool, error) {
+	poolCfg, err := poolConfig(dsn, cfg)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
+	if err != nil {
+		return nil, err
+	}
+	pingTimeout := poolCfg.ConnConfig.ConnectTimeout
+	if pingTimeout <= 0 {
+		pingTimeout = 5 * time.Second
+	}
+	pingCtx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	if err := pool.Ping(pingCtx); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return pool, nil
+}
+
+// poolConfig разбирает dsn и применяет к нему параметры cfg.
+func poolConfig(dsn string, cfg PoolConfig) (*pgxpool.Config, error) {
+	poolCfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.MaxConns > 0 {
+		poolCfg.MaxConns = cfg.MaxConns
+	}
+	if cfg.MinConns > 0 {
+		poolCfg.MinConns = cfg.MinConns
+	}
+	if cfg.MaxConnLifetime > 0 {
+		poolCfg.MaxConnLifetime = cfg.MaxConnLifetime
+	}
+	if cfg.MaxConnIdleTime > 0 {
+		poolCfg.MaxConnIdleTime = cfg.MaxConnIdleTime
+	}
+	if cfg.HealthCheckPeriod > 0 {
+		poolCfg.HealthCheckPeriod = cfg.HealthCheckPeriod
+	}
+	if cfg.ConnectTimeout > 0 {
+		poolCfg.ConnConfig.ConnectTimeout = cfg.ConnectTimeout
+	}
+
+	mode, err := queryExecMode(cfg.StatementCacheMode)
+	if err != nil {
+		return nil, err
+	}
+	poolCfg.ConnConfig.DefaultQueryExecMode = mode
+
+	return poolCfg, nil
+}
+
+// queryExecMode переводит название режима из конфигурации в режим pgx.
+// Пустая строка — режим по умолчанию, cache_statement.
+func queryExecMode(name string) (pgx.QueryExecMode, error) {
+	switch name {
+	case "", StatementCacheStatement:
+		return pgx.QueryExecModeCacheStatement, nil
+	case StatementCacheDescribe:
+		return pgx.QueryExecModeCacheDescribe, nil
+	case StatementCacheExec:
+		return pgx.QueryExecModeDescribeExec, nil
+	case StatementCacheSimple:
+		return pgx.QueryExecModeSimpleProtocol, nil
+	default:
+		return 0, fmt.Errorf("unknown statement cache mode %q", name)
+	}
+}
+
+// OpenSQL возвращает database/sql поверх пула — для goose и проверок, которым нужен *sql.DB.
+// Закрытие возвращённого *sql.DB пул не закрывает.
+func OpenSQL(pool *pgxpool.Pool) *sql.DB {
+	return stdlib.OpenDBFromPool(pool)
+}
@@ -0,0 +1,30 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryExecMode(t *testing.T) {
+	tests := []struct {
+		name string
+		want pgx.QueryExecMode
+	}{
+		{"", pgx.QueryExecModeCacheStatement},
+		{StatementCacheStatement, pgx.QueryExecModeCacheStatement},
+		{StatementCacheDescribe, pgx.QueryExecModeCacheDescribe},
+		{StatementCacheExec, pgx.QueryExecModeDescribeExec},
+		{StatementCacheSimple, pgx.QueryExecModeSimpleProtocol},
+	}
+	for _, tt := range tests {
+		mode, err := queryExecMode(tt.name)
+		require.NoError(t, err, tt.name)
+		assert.Equal(t, tt.want, mode, tt.name)
+	}
+
+	_, err := queryExecMode("prepared")
+	assert.Error(t, err)
+}
@@ -0,0 +1,23 @@
+-- name: InsertAPIKey :exec
+INSERT INTO api_keys (id, user_id, name, prefix, key_hash, created_at)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: GetAPIKeyByHash :one
+SELECT id, user_id, name, prefix, key_hash, created_at, last_used_at
+FROM api_keys WHERE key_hash = $1;
+
+-- name: ListAPIKeysByUser :many
+SELECT id, user_id, name, prefix, key_hash, created_at, last_used_at
+FROM api_keys WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: DeleteAPIKey :execrows
+DELETE FROM api_keys WHERE id = $1 AND user_id = $2;
+
+-- name: TouchAPIKey :exec
+UPDATE api_keys SET last_used_at = $2 WHERE id = $1;
+
+-- name: ListAllAPIKeys :many
+SELECT id, user_id, name, prefix, key_hash, created_at, last_used_at
+FROM api_keys
+ORDER BY created_at;
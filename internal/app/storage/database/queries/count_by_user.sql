@@ -0,0 +1,4 @@
+-- name: CountActiveByUserID :one
+SELECT COUNT(*) FROM urls
+WHERE user_id = $1 AND is_deleted = false
+    AND (expires_at IS NULL OR expires_at > now());
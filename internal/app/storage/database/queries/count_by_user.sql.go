@@ -0,0 +1,23 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: count_by_user.sql
+
+package queries
+
+import (
+	"context"
+)
+
+const countActiveByUserID = `-- name: CountActiveByUserID :one
+SELECT COUNT(*) FROM urls
+WHERE user_id = $1 AND is_deleted = false
+    AND (expires_at IS NULL OR expires_at > now())
+`
+
+func (q *Queries) CountActiveByUserID(ctx context.Context, userID *string) (int64, error) {
+	row := q.db.QueryRow(ctx, countActiveByUserID, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
@@ -1,3 +1,7 @@
--- name: BatchDeleteURLs :exec
+-- name: BatchDeleteURLs :many
 UPDATE urls SET is_deleted = true
-WHERE user_id = $1 AND short_url = ANY($2::text[]);
+WHERE user_id = $1 AND short_url = ANY($2::text[])
+RETURNING short_url;
+
+-- name: SelectExistingShortURLs :many
+SELECT short_url FROM urls WHERE short_url = ANY($1::text[]);
@@ -7,22 +7,59 @@ package queries
 
 import (
 	"context"
-	"database/sql"
-
-	"github.com/lib/pq"
 )
 
-const batchDeleteURLs = `-- name: BatchDeleteURLs :exec
+const batchDeleteURLs = `-- name: BatchDeleteURLs :many
 UPDATE urls SET is_deleted = true
 WHERE user_id = $1 AND short_url = ANY($2::text[])
+RETURNING short_url
 `
 
 type BatchDeleteURLsParams struct {
-	UserID  sql.NullString
+	UserID  *string
 	Column2 []string
 }
 
-func (q *Queries) BatchDeleteURLs(ctx context.Context, arg BatchDeleteURLsParams) error {
-	_, err := q.db.ExecContext(ctx, batchDeleteURLs, arg.UserID, pq.Array(arg.Column2))
-	return err
+func (q *Queries) BatchDeleteURLs(ctx context.Context, arg BatchDeleteURLsParams) ([]string, error) {
+	rows, err := q.db.Query(ctx, batchDeleteURLs, arg.UserID, arg.Column2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var short_url string
+		if err := rows.Scan(&short_url); err != nil {
+			return nil, err
+		}
+		items = append(items, short_url)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const selectExistingShortURLs = `-- name: SelectExistingShortURLs :many
+SELECT short_url FROM urls WHERE short_url = ANY($1::text[])
+`
+
+func (q *Queries) SelectExistingShortURLs(ctx context.Context, dollar_1 []string) ([]string, error) {
+	rows, err := q.db.Query(ctx, selectExistingShortURLs, dollar_1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var short_url string
+		if err := rows.Scan(&short_url); err != nil {
+			return nil, err
+		}
+		items = append(items, short_url)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
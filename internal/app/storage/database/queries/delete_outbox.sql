@@ -0,0 +1,25 @@
+-- name: InsertDeleteJob :exec
+INSERT INTO delete_outbox (id, user_id, short_urls, created_at, locked_until)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: ClaimDeleteJobs :many
+UPDATE delete_outbox SET locked_until = sqlc.arg(lease_until)
+WHERE id IN (
+    SELECT o.id FROM delete_outbox o
+    WHERE o.status = 'pending' AND o.locked_until < sqlc.arg(now)
+    ORDER BY o.created_at
+    LIMIT sqlc.arg(max_jobs)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, user_id, short_urls, created_at;
+
+-- name: CompleteDeleteJob :exec
+UPDATE delete_outbox SET status = $2, results = $3, completed_at = $4
+WHERE id = $1;
+
+-- name: GetDeleteJob :one
+SELECT id, status, results, created_at, completed_at
+FROM delete_outbox WHERE id = $1 AND user_id = $2;
+
+-- name: PurgeDeleteJobs :exec
+DELETE FROM delete_outbox WHERE status <> 'pending' AND completed_at < $1;
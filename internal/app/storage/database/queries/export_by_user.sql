@@ -0,0 +1,14 @@
+-- name: ListUserLinksPage :many
+-- Страница ссылок пользователя для выгрузки: keyset-пагинация по id, удалённые тоже входят.
+SELECT id, short_url, original_url, created_at, is_deleted, expires_at, clicks, redirect_type, query_mode, forward_path
+FROM urls
+WHERE user_id = sqlc.arg(user_id) AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(page_size);
+
+-- name: AddClicks :exec
+UPDATE urls SET clicks = urls.clicks + c.n
+FROM (
+    SELECT unnest(sqlc.arg(short_urls)::text[]) AS short_url, unnest(sqlc.arg(counts)::bigint[]) AS n
+) AS c
+WHERE urls.short_url = c.short_url;
@@ -0,0 +1,88 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: export_by_user.sql
+
+package queries
+
+import (
+	"context"
+	"time"
+)
+
+const addClicks = `-- name: AddClicks :exec
+UPDATE urls SET clicks = urls.clicks + c.n
+FROM (
+    SELECT unnest($1::text[]) AS short_url, unnest($2::bigint[]) AS n
+) AS c
+WHERE urls.short_url = c.short_url
+`
+
+type AddClicksParams struct {
+	ShortUrls []string
+	Counts    []int64
+}
+
+func (q *Queries) AddClicks(ctx context.Context, arg AddClicksParams) error {
+	_, err := q.db.Exec(ctx, addClicks, arg.ShortUrls, arg.Counts)
+	return err
+}
+
+const listUserLinksPage = `-- name: ListUserLinksPage :many
+SELECT id, short_url, original_url, created_at, is_deleted, expires_at, clicks, redirect_type, query_mode, forward_path
+FROM urls
+WHERE user_id = $1 AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListUserLinksPageParams struct {
+	UserID   *string
+	AfterID  int32
+	PageSize int32
+}
+
+type ListUserLinksPageRow struct {
+	ID           int32
+	ShortUrl     string
+	OriginalUrl  string
+	CreatedAt    *time.Time
+	IsDeleted    bool
+	ExpiresAt    *time.Time
+	Clicks       int64
+	RedirectType int16
+	QueryMode    string
+	ForwardPath  bool
+}
+
+// Страница ссылок пользователя для выгрузки: keyset-пагинация по id, удалённые тоже входят.
+func (q *Queries) ListUserLinksPage(ctx context.Context, arg ListUserLinksPageParams) ([]ListUserLinksPageRow, error) {
+	rows, err := q.db.Query(ctx, listUserLinksPage, arg.UserID, arg.AfterID, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserLinksPageRow
+	for rows.Next() {
+		var i ListUserLinksPageRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ShortUrl,
+			&i.OriginalUrl,
+			&i.CreatedAt,
+			&i.IsDeleted,
+			&i.ExpiresAt,
+			&i.Clicks,
+			&i.RedirectType,
+			&i.QueryMode,
+			&i.ForwardPath,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
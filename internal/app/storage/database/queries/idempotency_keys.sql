@@ -0,0 +1,28 @@
+-- name: ReserveIdempotencyKey :execrows
+INSERT INTO idempotency_keys (user_id, idem_key, fingerprint, expires_at)
+VALUES (sqlc.arg(user_id), sqlc.arg(idem_key), sqlc.arg(fingerprint), sqlc.arg(expires_at))
+ON CONFLICT (user_id, idem_key) DO UPDATE
+SET fingerprint = EXCLUDED.fingerprint,
+    completed = FALSE,
+    status_code = 0,
+    content_type = '',
+    location = '',
+    body = '',
+    created_at = now(),
+    expires_at = EXCLUDED.expires_at
+WHERE idempotency_keys.expires_at < sqlc.arg(now);
+
+-- name: GetIdempotencyKey :one
+SELECT fingerprint, completed, status_code, content_type, location, body, expires_at
+FROM idempotency_keys WHERE user_id = $1 AND idem_key = $2;
+
+-- name: CompleteIdempotencyKey :exec
+UPDATE idempotency_keys
+SET completed = TRUE, status_code = $3, content_type = $4, location = $5, body = $6
+WHERE user_id = $1 AND idem_key = $2;
+
+-- name: ReleaseIdempotencyKey :exec
+DELETE FROM idempotency_keys WHERE user_id = $1 AND idem_key = $2 AND NOT completed;
+
+-- name: PurgeIdempotencyKeys :exec
+DELETE FROM idempotency_keys WHERE expires_at < $1;
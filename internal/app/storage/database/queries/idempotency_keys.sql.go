@@ -0,0 +1,133 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: idempotency_keys.sql
+
+package queries
+
+import (
+	"context"
+	"time"
+)
+
+const completeIdempotencyKey = `-- name: CompleteIdempotencyKey :exec
+UPDATE idempotency_keys
+SET completed = TRUE, status_code = $3, content_type = $4, location = $5, body = $6
+WHERE user_id = $1 AND idem_key = $2
+`
+
+type CompleteIdempotencyKeyParams struct {
+	UserID      string
+	IdemKey     string
+	StatusCode  int32
+	ContentType string
+	Location    string
+	Body        []byte
+}
+
+func (q *Queries) CompleteIdempotencyKey(ctx context.Context, arg CompleteIdempotencyKeyParams) error {
+	_, err := q.db.Exec(ctx, completeIdempotencyKey,
+		arg.UserID,
+		arg.IdemKey,
+		arg.StatusCode,
+		arg.ContentType,
+		arg.Location,
+		arg.Body,
+	)
+	return err
+}
+
+const getIdempotencyKey = `-- name: GetIdempotencyKey :one
+SELECT fingerprint, completed, status_code, content_type, location, body, expires_at
+FROM idempotency_keys WHERE user_id = $1 AND idem_key = $2
+`
+
+type GetIdempotencyKeyParams struct {
+	UserID  string
+	IdemKey string
+}
+
+type GetIdempotencyKeyRow struct {
+	Fingerprint string
+	Completed   bool
+	StatusCode  int32
+	ContentType string
+	Location    string
+	Body        []byte
+	ExpiresAt   time.Time
+}
+
+func (q *Queries) GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (GetIdempotencyKeyRow, error) {
+	row := q.db.QueryRow(ctx, getIdempotencyKey, arg.UserID, arg.IdemKey)
+	var i GetIdempotencyKeyRow
+	err := row.Scan(
+		&i.Fingerprint,
+		&i.Completed,
+		&i.StatusCode,
+		&i.ContentType,
+		&i.Location,
+		&i.Body,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const purgeIdempotencyKeys = `-- name: PurgeIdempotencyKeys :exec
+DELETE FROM idempotency_keys WHERE expires_at < $1
+`
+
+func (q *Queries) PurgeIdempotencyKeys(ctx context.Context, expiresAt time.Time) error {
+	_, err := q.db.Exec(ctx, purgeIdempotencyKeys, expiresAt)
+	return err
+}
+
+const releaseIdempotencyKey = `-- name: ReleaseIdempotencyKey :exec
+DELETE FROM idempotency_keys WHERE user_id = $1 AND idem_key = $2 AND NOT completed
+`
+
+type ReleaseIdempotencyKeyParams struct {
+	UserID  string
+	IdemKey string
+}
+
+func (q *Queries) ReleaseIdempotencyKey(ctx context.Context, arg ReleaseIdempotencyKeyParams) error {
+	_, err := q.db.Exec(ctx, releaseIdempotencyKey, arg.UserID, arg.IdemKey)
+	return err
+}
+
+const reserveIdempotencyKey = `-- name: ReserveIdempotencyKey :execrows
+INSERT INTO idempotency_keys (user_id, idem_key, fingerprint, expires_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, idem_key) DO UPDATE
+SET fingerprint = EXCLUDED.fingerprint,
+    completed = FALSE,
+    status_code = 0,
+    content_type = '',
+    location = '',
+    body = '',
+    created_at = now(),
+    expires_at = EXCLUDED.expires_at
+WHERE idempotency_keys.expires_at < $5
+`
+
+type ReserveIdempotencyKeyParams struct {
+	UserID      string
+	IdemKey     string
+	Fingerprint string
+	ExpiresAt   time.Time
+	Now         time.Time
+}
+
+func (q *Queries) ReserveIdempotencyKey(ctx context.Context, arg ReserveIdempotencyKeyParams) (int64, error) {
+	result, err := q.db.Exec(ctx, reserveIdempotencyKey,
+		arg.UserID,
+		arg.IdemKey,
+		arg.Fingerprint,
+		arg.ExpiresAt,
+		arg.Now,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
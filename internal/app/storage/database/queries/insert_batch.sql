@@ -0,0 +1,23 @@
+-- name: InsertURLsBatch :many
+-- Пустая строка в expires_ats означает ссылку без срока жизни.
+INSERT INTO urls (short_url, original_url, user_id, is_deleted, expires_at, redirect_type, query_mode, forward_path)
+SELECT unnest(sqlc.arg(short_urls)::text[]), unnest(sqlc.arg(original_urls)::text[]), sqlc.arg(user_id)::varchar, false,
+    NULLIF(unnest(sqlc.arg(expires_ats)::text[]), '')::timestamptz, unnest(sqlc.arg(redirect_types)::smallint[]),
+    unnest(sqlc.arg(query_modes)::text[]), unnest(sqlc.arg(forward_paths)::boolean[])
+ON CONFLICT (original_url) WHERE NOT is_deleted DO NOTHING
+RETURNING short_url, original_url;
+
+-- name: RetireExpiredURLs :exec
+-- Истёкшая ссылка освобождает оригинальный URL для новой строки: она помечается
+-- удалённой и остаётся у владельца вместе со счётчиком переходов.
+UPDATE urls SET is_deleted = true
+WHERE original_url = ANY(sqlc.arg(original_urls)::text[]) AND NOT is_deleted
+    AND expires_at IS NOT NULL AND expires_at <= now();
+
+-- name: GetByOriginalURLs :many
+SELECT short_url, original_url FROM urls
+WHERE original_url = ANY(sqlc.arg(original_urls)::text[]) AND is_deleted = false
+    AND (expires_at IS NULL OR expires_at > now());
+
+-- name: SelectTakenShortURLs :many
+SELECT short_url FROM urls WHERE short_url = ANY(sqlc.arg(short_urls)::text[]);
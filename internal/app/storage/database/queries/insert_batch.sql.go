@@ -0,0 +1,131 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: insert_batch.sql
+
+package queries
+
+import (
+	"context"
+)
+
+const getByOriginalURLs = `-- name: GetByOriginalURLs :many
+SELECT short_url, original_url FROM urls
+WHERE original_url = ANY($1::text[]) AND is_deleted = false
+    AND (expires_at IS NULL OR expires_at > now())
+`
+
+type GetByOriginalURLsRow struct {
+	ShortUrl    string
+	OriginalUrl string
+}
+
+func (q *Queries) GetByOriginalURLs(ctx context.Context, originalUrls []string) ([]GetByOriginalURLsRow, error) {
+	rows, err := q.db.Query(ctx, getByOriginalURLs, originalUrls)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetByOriginalURLsRow
+	for rows.Next() {
+		var i GetByOriginalURLsRow
+		if err := rows.Scan(&i.ShortUrl, &i.OriginalUrl); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertURLsBatch = `-- name: InsertURLsBatch :many
+INSERT INTO urls (short_url, original_url, user_id, is_deleted, expires_at, redirect_type, query_mode, forward_path)
+SELECT unnest($1::text[]), unnest($2::text[]), $3::varchar, false,
+    NULLIF(unnest($4::text[]), '')::timestamptz, unnest($5::smallint[]),
+    unnest($6::text[]), unnest($7::boolean[])
+ON CONFLICT (original_url) WHERE NOT is_deleted DO NOTHING
+RETURNING short_url, original_url
+`
+
+type InsertURLsBatchParams struct {
+	ShortUrls     []string
+	OriginalUrls  []string
+	UserID        string
+	ExpiresAts    []string
+	RedirectTypes []int16
+	QueryModes    []string
+	ForwardPaths  []bool
+}
+
+type InsertURLsBatchRow struct {
+	ShortUrl    string
+	OriginalUrl string
+}
+
+// Пустая строка в expires_ats означает ссылку без срока жизни.
+func (q *Queries) InsertURLsBatch(ctx context.Context, arg InsertURLsBatchParams) ([]InsertURLsBatchRow, error) {
+	rows, err := q.db.Query(ctx, insertURLsBatch,
+		arg.ShortUrls,
+		arg.OriginalUrls,
+		arg.UserID,
+		arg.ExpiresAts,
+		arg.RedirectTypes,
+		arg.QueryModes,
+		arg.ForwardPaths,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []InsertURLsBatchRow
+	for rows.Next() {
+		var i InsertURLsBatchRow
+		if err := rows.Scan(&i.ShortUrl, &i.OriginalUrl); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const retireExpiredURLs = `-- name: RetireExpiredURLs :exec
+UPDATE urls SET is_deleted = true
+WHERE original_url = ANY($1::text[]) AND NOT is_deleted
+    AND expires_at IS NOT NULL AND expires_at <= now()
+`
+
+// Истёкшая ссылка освобождает оригинальный URL для новой строки: она помечается
+// удалённой и остаётся у владельца вместе со счётчиком переходов.
+func (q *Queries) RetireExpiredURLs(ctx context.Context, originalUrls []string) error {
+	_, err := q.db.Exec(ctx, retireExpiredURLs, originalUrls)
+	return err
+}
+
+const selectTakenShortURLs = `-- name: SelectTakenShortURLs :many
+SELECT short_url FROM urls WHERE short_url = ANY($1::text[])
+`
+
+func (q *Queries) SelectTakenShortURLs(ctx context.Context, shortUrls []string) ([]string, error) {
+	rows, err := q.db.Query(ctx, selectTakenShortURLs, shortUrls)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var short_url string
+		if err := rows.Scan(&short_url); err != nil {
+			return nil, err
+		}
+		items = append(items, short_url)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -7,24 +7,23 @@ package queries
 
 import (
 	"context"
-	"database/sql"
 )
 
 const insertOrGetShortURL = `-- name: InsertOrGetShortURL :one
 INSERT INTO urls (short_url, original_url, user_id, is_deleted)
 VALUES ($1, $2, $3, false)
-ON CONFLICT (original_url) DO NOTHING
+ON CONFLICT (original_url) WHERE NOT is_deleted DO NOTHING
 RETURNING short_url
 `
 
 type InsertOrGetShortURLParams struct {
 	ShortUrl    string
 	OriginalUrl string
-	UserID      sql.NullString
+	UserID      *string
 }
 
 func (q *Queries) InsertOrGetShortURL(ctx context.Context, arg InsertOrGetShortURLParams) (string, error) {
-	row := q.db.QueryRowContext(ctx, insertOrGetShortURL, arg.ShortUrl, arg.OriginalUrl, arg.UserID)
+	row := q.db.QueryRow(ctx, insertOrGetShortURL, arg.ShortUrl, arg.OriginalUrl, arg.UserID)
 	var short_url string
 	err := row.Scan(&short_url)
 	return short_url, err
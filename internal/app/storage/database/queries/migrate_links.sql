@@ -0,0 +1,20 @@
+-- name: ListLinksPage :many
+-- Страница всех ссылок в порядке короткого кода для переноса между хранилищами.
+SELECT short_url, original_url, user_id, created_at, expires_at, is_deleted, clicks, redirect_type, query_mode, forward_path
+FROM urls
+WHERE short_url > sqlc.arg(after_short_url)
+ORDER BY short_url
+LIMIT sqlc.arg(page_size);
+
+-- name: ImportLinks :many
+-- Вставка ссылок как есть; занятый код или оригинальный URL активной ссылки пропускаются.
+INSERT INTO urls (short_url, original_url, user_id, created_at, expires_at, is_deleted, clicks, redirect_type, query_mode, forward_path)
+SELECT unnest(sqlc.arg(short_urls)::text[]), unnest(sqlc.arg(original_urls)::text[]),
+    NULLIF(unnest(sqlc.arg(user_ids)::text[]), ''),
+    NULLIF(unnest(sqlc.arg(created_ats)::text[]), '')::timestamptz,
+    NULLIF(unnest(sqlc.arg(expires_ats)::text[]), '')::timestamptz,
+    unnest(sqlc.arg(deleted)::boolean[]), unnest(sqlc.arg(clicks)::bigint[]),
+    unnest(sqlc.arg(redirect_types)::smallint[]), unnest(sqlc.arg(query_modes)::text[]),
+    unnest(sqlc.arg(forward_paths)::boolean[])
+ON CONFLICT DO NOTHING
+RETURNING short_url;
@@ -0,0 +1,127 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: migrate_links.sql
+
+package queries
+
+import (
+	"context"
+	"time"
+)
+
+const importLinks = `-- name: ImportLinks :many
+INSERT INTO urls (short_url, original_url, user_id, created_at, expires_at, is_deleted, clicks, redirect_type, query_mode, forward_path)
+SELECT unnest($1::text[]), unnest($2::text[]),
+    NULLIF(unnest($3::text[]), ''),
+    NULLIF(unnest($4::text[]), '')::timestamptz,
+    NULLIF(unnest($5::text[]), '')::timestamptz,
+    unnest($6::boolean[]), unnest($7::bigint[]),
+    unnest($8::smallint[]), unnest($9::text[]),
+    unnest($10::boolean[])
+ON CONFLICT DO NOTHING
+RETURNING short_url
+`
+
+type ImportLinksParams struct {
+	ShortUrls     []string
+	OriginalUrls  []string
+	UserIds       []string
+	CreatedAts    []string
+	ExpiresAts    []string
+	Deleted       []bool
+	Clicks        []int64
+	RedirectTypes []int16
+	QueryModes    []string
+	ForwardPaths  []bool
+}
+
+// Вставка ссылок как есть; занятый код или оригинальный URL активной ссылки пропускаются.
+func (q *Queries) ImportLinks(ctx context.Context, arg ImportLinksParams) ([]string, error) {
+	rows, err := q.db.Query(ctx, importLinks,
+		arg.ShortUrls,
+		arg.OriginalUrls,
+		arg.UserIds,
+		arg.CreatedAts,
+		arg.ExpiresAts,
+		arg.Deleted,
+		arg.Clicks,
+		arg.RedirectTypes,
+		arg.QueryModes,
+		arg.ForwardPaths,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var short_url string
+		if err := rows.Scan(&short_url); err != nil {
+			return nil, err
+		}
+		items = append(items, short_url)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLinksPage = `-- name: ListLinksPage :many
+SELECT short_url, original_url, user_id, created_at, expires_at, is_deleted, clicks, redirect_type, query_mode, forward_path
+FROM urls
+WHERE short_url > $1
+ORDER BY short_url
+LIMIT $2
+`
+
+type ListLinksPageParams struct {
+	AfterShortUrl string
+	PageSize      int32
+}
+
+type ListLinksPageRow struct {
+	ShortUrl     string
+	OriginalUrl  string
+	UserID       *string
+	CreatedAt    *time.Time
+	ExpiresAt    *time.Time
+	IsDeleted    bool
+	Clicks       int64
+	RedirectType int16
+	QueryMode    string
+	ForwardPath  bool
+}
+
+// Страница всех ссылок в порядке короткого кода для переноса между хранилищами.
+func (q *Queries) ListLinksPage(ctx context.Context, arg ListLinksPageParams) ([]ListLinksPageRow, error) {
+	rows, err := q.db.Query(ctx, listLinksPage, arg.AfterShortUrl, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLinksPageRow
+	for rows.Next() {
+		var i ListLinksPageRow
+		if err := rows.Scan(
+			&i.ShortUrl,
+			&i.OriginalUrl,
+			&i.UserID,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.IsDeleted,
+			&i.Clicks,
+			&i.RedirectType,
+			&i.QueryMode,
+			&i.ForwardPath,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -5,12 +5,55 @@
 package queries
 
 import (
-	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
 )
 
+type ApiKey struct {
+	ID         uuid.UUID
+	UserID     string
+	Name       string
+	Prefix     string
+	KeyHash    string
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+}
+
+type DeleteOutbox struct {
+	ID          uuid.UUID
+	UserID      string
+	ShortUrls   []string
+	CreatedAt   time.Time
+	LockedUntil time.Time
+	Status      string
+	Results     []byte
+	CompletedAt *time.Time
+}
+
+type IdempotencyKey struct {
+	UserID      string
+	IdemKey     string
+	Fingerprint string
+	Completed   bool
+	StatusCode  int32
+	ContentType string
+	Location    string
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
 type Url struct {
-	ID          int32
-	ShortUrl    string
-	OriginalUrl string
-	UserID      sql.NullString
+	ID           int32
+	ShortUrl     string
+	OriginalUrl  string
+	UserID       *string
+	IsDeleted    bool
+	ExpiresAt    *time.Time
+	CreatedAt    *time.Time
+	Clicks       int64
+	RedirectType int16
+	QueryMode    string
+	ForwardPath  bool
 }
@@ -1,6 +1,49 @@
 CREATE TABLE IF NOT EXISTS urls (
     id SERIAL PRIMARY KEY,
-    short_url VARCHAR(8) UNIQUE NOT NULL,
-    original_url TEXT UNIQUE NOT NULL,
-    user_id VARCHAR(36)
+    short_url VARCHAR(32) UNIQUE NOT NULL,
+    original_url TEXT NOT NULL,
+    user_id VARCHAR(36),
+    is_deleted BOOLEAN NOT NULL DEFAULT FALSE,
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    clicks BIGINT NOT NULL DEFAULT 0,
+    redirect_type SMALLINT NOT NULL DEFAULT 0,
+    query_mode VARCHAR(16) NOT NULL DEFAULT '',
+    forward_path BOOLEAN NOT NULL DEFAULT FALSE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_urls_active_original_url ON urls (original_url) WHERE NOT is_deleted;
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id UUID PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL,
+    name TEXT NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) UNIQUE NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_used_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS delete_outbox (
+    id UUID PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL,
+    short_urls TEXT[] NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    locked_until TIMESTAMPTZ NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    results JSONB NOT NULL DEFAULT '[]',
+    completed_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id VARCHAR(36) NOT NULL,
+    idem_key VARCHAR(255) NOT NULL,
+    fingerprint CHAR(64) NOT NULL,
+    completed BOOLEAN NOT NULL DEFAULT FALSE,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    content_type TEXT NOT NULL DEFAULT '',
+    location TEXT NOT NULL DEFAULT '',
+    body BYTEA NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, idem_key)
 );
@@ -1,2 +1,3 @@
 -- name: GetByOriginalURL :one
-SELECT short_url FROM urls WHERE original_url = $1 AND is_deleted = false;
+SELECT short_url FROM urls WHERE original_url = $1 AND is_deleted = false
+    AND (expires_at IS NULL OR expires_at > now());
@@ -11,10 +11,11 @@ import (
 
 const getByOriginalURL = `-- name: GetByOriginalURL :one
 SELECT short_url FROM urls WHERE original_url = $1 AND is_deleted = false
+    AND (expires_at IS NULL OR expires_at > now())
 `
 
 func (q *Queries) GetByOriginalURL(ctx context.Context, originalUrl string) (string, error) {
-	row := q.db.QueryRowContext(ctx, getByOriginalURL, originalUrl)
+	row := q.db.QueryRow(ctx, getByOriginalURL, originalUrl)
 	var short_url string
 	err := row.Scan(&short_url)
 	return short_url, err
@@ -1,2 +1,3 @@
 -- name: GetByShortURL :one
-SELECT original_url FROM urls WHERE short_url = $1 AND is_deleted = false;
+SELECT original_url, expires_at, redirect_type, query_mode, forward_path FROM urls WHERE short_url = $1 AND is_deleted = false
+    AND (expires_at IS NULL OR expires_at > now());
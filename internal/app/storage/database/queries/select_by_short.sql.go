@@ -7,15 +7,31 @@ package queries
 
 import (
 	"context"
+	"time"
 )
 
 const getByShortURL = `-- name: GetByShortURL :one
-SELECT original_url FROM urls WHERE short_url = $1 AND is_deleted = false
+SELECT original_url, expires_at, redirect_type, query_mode, forward_path FROM urls WHERE short_url = $1 AND is_deleted = false
+    AND (expires_at IS NULL OR expires_at > now())
 `
 
-func (q *Queries) GetByShortURL(ctx context.Context, shortUrl string) (string, error) {
-	row := q.db.QueryRowContext(ctx, getByShortURL, shortUrl)
-	var original_url string
-	err := row.Scan(&original_url)
-	return original_url, err
+type GetByShortURLRow struct {
+	OriginalUrl  string
+	ExpiresAt    *time.Time
+	RedirectType int16
+	QueryMode    string
+	ForwardPath  bool
+}
+
+func (q *Queries) GetByShortURL(ctx context.Context, shortUrl string) (GetByShortURLRow, error) {
+	row := q.db.QueryRow(ctx, getByShortURL, shortUrl)
+	var i GetByShortURLRow
+	err := row.Scan(
+		&i.OriginalUrl,
+		&i.ExpiresAt,
+		&i.RedirectType,
+		&i.QueryMode,
+		&i.ForwardPath,
+	)
+	return i, err
 }
@@ -7,7 +7,6 @@ package queries
 
 import (
 	"context"
-	"database/sql"
 )
 
 const getAllByUserID = `-- name: GetAllByUserID :many
@@ -20,8 +19,8 @@ type GetAllByUserIDRow struct {
 	OriginalUrl string
 }
 
-func (q *Queries) GetAllByUserID(ctx context.Context, userID sql.NullString) ([]GetAllByUserIDRow, error) {
-	rows, err := q.db.QueryContext(ctx, getAllByUserID, userID)
+func (q *Queries) GetAllByUserID(ctx context.Context, userID *string) ([]GetAllByUserIDRow, error) {
+	rows, err := q.db.Query(ctx, getAllByUserID, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -34,9 +33,6 @@ func (q *Queries) GetAllByUserID(ctx context.Context, userID sql.NullString) ([]
 		}
 		items = append(items, i)
 	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
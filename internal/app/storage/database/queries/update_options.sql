@@ -0,0 +1,6 @@
+-- name: UpdateLinkOptions :execrows
+-- Настройки перехода меняет только владелец активной ссылки.
+UPDATE urls SET redirect_type = sqlc.arg(redirect_type), query_mode = sqlc.arg(query_mode),
+    forward_path = sqlc.arg(forward_path)
+WHERE short_url = sqlc.arg(short_url) AND user_id = sqlc.arg(user_id) AND is_deleted = false
+    AND (expires_at IS NULL OR expires_at > now());
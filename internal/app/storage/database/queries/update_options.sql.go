@@ -0,0 +1,40 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: update_options.sql
+
+package queries
+
+import (
+	"context"
+)
+
+const updateLinkOptions = `-- name: UpdateLinkOptions :execrows
+UPDATE urls SET redirect_type = $1, query_mode = $2,
+    forward_path = $3
+WHERE short_url = $4 AND user_id = $5 AND is_deleted = false
+    AND (expires_at IS NULL OR expires_at > now())
+`
+
+type UpdateLinkOptionsParams struct {
+	RedirectType int16
+	QueryMode    string
+	ForwardPath  bool
+	ShortUrl     string
+	UserID       *string
+}
+
+// Настройки перехода меняет только владелец активной ссылки.
+func (q *Queries) UpdateLinkOptions(ctx context.Context, arg UpdateLinkOptionsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateLinkOptions,
+		arg.RedirectType,
+		arg.QueryMode,
+		arg.ForwardPath,
+		arg.ShortUrl,
+		arg.UserID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,247 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/metrics"
+	"github.com/DaniYer/GoProject.git/internal/app/storage/database/queries"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// ReplicaConfig — параметры чтения с реплик.
+type ReplicaConfig struct {
+	// DSNs — строки подключения к репликам.
+	DSNs []string
+	// Pool — параметры пула соединений каждой реплики.
+	Pool PoolConfig
+	// HealthCheckInterval — как часто проверяется доступность реплик.
+	HealthCheckInterval time.Duration
+	// StickyWindow — сколько после записи чтения тех же ссылок и того же пользователя
+	// идут в основную базу: реплика может ещё не получить изменение.
+	StickyWindow time.Duration
+}
+
+// replica — пул соединений реплики и её состояние по последней проверке.
+type replica struct {
+	name    string
+	pool    *pgxpool.Pool
+	queries *queries.Queries
+	healthy atomic.Bool
+}
+
+// replicaSet распределяет чтения по доступным репликам по кругу
+// и в фоне проверяет их доступность.
+type replicaSet struct {
+	replicas []*replica
+	next     atomic.Uint64
+	recent   *recentWrites
+	interval time.Duration
+	logger   *zap.SugaredLogger
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// pick возвращает следующую доступную реплику или nil, если доступных нет.
+func (rs *replicaSet) pick() *replica {
+	n := uint64(len(rs.replicas))
+	start := rs.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		r := rs.replicas[(start+i)%n]
+		if r.healthy.Load() {
+			return r
+		}
+	}
+	return nil
+}
+
+// check проверяет каждую реплику и запоминает результат.
+func (rs *replicaSet) check(ctx context.Context) {
+	for _, r := range rs.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, rs.interval)
+		err := r.pool.Ping(pingCtx)
+		cancel()
+		rs.setHealthy(r, err == nil, err)
+	}
+	rs.recent.prune(time.Now())
+}
+
+// setHealthy меняет состояние реплики и пишет в лог переходы между состояниями.
+func (rs *replicaSet) setHealthy(r *replica, healthy bool, err error) {
+	if r.healthy.Swap(healthy) == healthy {
+		return
+	}
+	if healthy {
+		rs.logger.Infow("DB replica is back", "replica", r.name)
+	} else {
+		rs.logger.Warnw("DB replica is unavailable, reading from primary", "replica", r.name, "error", err)
+	}
+}
+
+func (rs *replicaSet) run(ctx context.Context) {
+	defer rs.wg.Done()
+
+	ticker := time.NewTicker(rs.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			rs.check(ctx)
+		}
+	}
+}
+
+func (rs *replicaSet) close() {
+	rs.cancel()
+	rs.wg.Wait()
+	for _, r := range rs.replicas {
+		r.pool.Close()
+	}
+}
+
+// AttachReplicas подключает реплики для чтения ссылок. Реплика, недоступная при старте,
+// не мешает запуску: чтения идут в основную базу, пока проверка не увидит её живой.
+// Реплики закрываются в Close.
+func (s *DBStore) AttachReplicas(ctx context.Context, cfg ReplicaConfig, logger *zap.SugaredLogger) error {
+	if len(cfg.DSNs) == 0 {
+		return nil
+	}
+	if cfg.HealthCheckInterval <= 0 {
+		cfg.HealthCheckInterval = 5 * time.Second
+	}
+
+	rs := &replicaSet{
+		recent:   newRecentWrites(cfg.StickyWindow),
+		interval: cfg.HealthCheckInterval,
+		logger:   logger,
+	}
+	for _, dsn := range cfg.DSNs {
+		poolCfg, err := poolConfig(dsn, cfg.Pool)
+		if err != nil {
+			for _, r := range rs.replicas {
+				r.pool.Close()
+			}
+			return err
+		}
+		pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
+		if err != nil {
+			for _, r := range rs.replicas {
+				r.pool.Close()
+			}
+			return err
+		}
+		// В логах реплика называется адресом, без пароля из DSN
+		name := fmt.Sprintf("%s:%d", poolCfg.ConnConfig.Host, poolCfg.ConnConfig.Port)
+		r := &replica{name: name, pool: pool, queries: queries.New(pool)}
+		// Первая проверка ниже залогирует недоступные реплики как переход из "живой"
+		r.healthy.Store(true)
+		rs.replicas = append(rs.replicas, r)
+	}
+	rs.check(ctx)
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	rs.cancel = cancel
+	rs.wg.Add(1)
+	go rs.run(runCtx)
+
+	s.replicas = rs
+	return nil
+}
+
+// Close останавливает проверку реплик и закрывает их пулы. Пул основной базы
+// принадлежит вызывающему и не закрывается.
+func (s *DBStore) Close() {
+	if s.replicas != nil {
+		s.replicas.close()
+	}
+}
+
+// reader возвращает реплику для чтения или nil, если читать нужно из основной базы:
+// реплик нет, все недоступны или один из ключей недавно записывался.
+func (s *DBStore) reader(keys ...string) *replica {
+	if s.replicas == nil {
+		return nil
+	}
+	for _, key := range keys {
+		if s.replicas.recent.contains(key, time.Now()) {
+			metrics.DBReads.WithLabelValues(metrics.DBReadPrimary).Inc()
+			return nil
+		}
+	}
+	r := s.replicas.pick()
+	if r == nil {
+		metrics.DBReads.WithLabelValues(metrics.DBReadPrimary).Inc()
+		return nil
+	}
+	metrics.DBReads.WithLabelValues(metrics.DBReadReplica).Inc()
+	return r
+}
+
+// readFailed отмечает реплику недоступной после ошибки соединения, чтобы следующие
+// чтения не ждали её до ближайшей проверки. Ошибки отмены запроса реплику не выключают.
+func (s *DBStore) readFailed(ctx context.Context, r *replica, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+	s.replicas.setHealthy(r, false, err)
+}
+
+// wrote запоминает ключи записи, чтобы следующие чтения по ним шли в основную базу.
+func (s *DBStore) wrote(keys ...string) {
+	if s.replicas == nil {
+		return
+	}
+	s.replicas.recent.add(time.Now(), keys...)
+}
+
+// Ключи недавних записей: код ссылки, оригинальный URL и пользователь.
+func shortKey(shortURL string) string    { return "s:" + shortURL }
+func originalKey(original string) string { return "o:" + original }
+func userKey(userID string) string       { return "u:" + userID }
+
+// recentWrites — ключи, записанные за последние window.
+type recentWrites struct {
+	mu     sync.Mutex
+	window time.Duration
+	keys   map[string]time.Time
+}
+
+func newRecentWrites(window time.Duration) *recentWrites {
+	return &recentWrites{window: window, keys: make(map[string]time.Time)}
+}
+
+func (w *recentWrites) add(now time.Time, keys ...string) {
+	if w.window <= 0 {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, key := range keys {
+		w.keys[key] = now.Add(w.window)
+	}
+}
+
+func (w *recentWrites) contains(key string, now time.Time) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	until, ok := w.keys[key]
+	return ok && now.Before(until)
+}
+
+// prune удаляет истёкшие ключи; вызывается при каждой проверке реплик.
+func (w *recentWrites) prune(now time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for key, until := range w.keys {
+		if !now.Before(until) {
+			delete(w.keys, key)
+		}
+	}
+}
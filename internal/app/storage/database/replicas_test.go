@@ -0,0 +1,52 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplicaSetPickSkipsUnhealthy(t *testing.T) {
+	a, b, c := &replica{name: "a"}, &replica{name: "b"}, &replica{name: "c"}
+	a.healthy.Store(true)
+	c.healthy.Store(true)
+	rs := &replicaSet{replicas: []*replica{a, b, c}}
+
+	seen := map[string]int{}
+	for i := 0; i < 10; i++ {
+		seen[rs.pick().name]++
+	}
+	assert.Zero(t, seen["b"])
+	assert.Positive(t, seen["a"])
+	assert.Positive(t, seen["c"])
+
+	a.healthy.Store(false)
+	c.healthy.Store(false)
+	assert.Nil(t, rs.pick())
+}
+
+func TestRecentWrites(t *testing.T) {
+	now := time.Now()
+	w := newRecentWrites(5 * time.Second)
+	w.add(now, shortKey("abc"), userKey("u1"))
+
+	assert.True(t, w.contains(shortKey("abc"), now.Add(time.Second)))
+	assert.True(t, w.contains(userKey("u1"), now.Add(time.Second)))
+	assert.False(t, w.contains(userKey("abc"), now), "ключи разных видов не пересекаются")
+	assert.False(t, w.contains(shortKey("abc"), now.Add(5*time.Second)))
+
+	w.prune(now.Add(5 * time.Second))
+	assert.Empty(t, w.keys)
+
+	// Нулевое окно отключает привязку к основной базе
+	off := newRecentWrites(0)
+	off.add(now, shortKey("abc"))
+	assert.False(t, off.contains(shortKey("abc"), now))
+}
+
+func TestReaderWithoutReplicas(t *testing.T) {
+	s := &DBStore{}
+	assert.Nil(t, s.reader(shortKey("abc")))
+	s.wrote(shortKey("abc"))
+}
@@ -0,0 +1,154 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/service"
+)
+
+// keyRecord — представление API-ключа в файле ключей.
+// dto.APIKey скрывает хеш и владельца из JSON, поэтому для хранения нужен отдельный тип.
+type keyRecord struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Hash       string     `json:"hash"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// loadAPIKeys читает файл ключей, если он есть.
+func (fs *FileStore) loadAPIKeys() error {
+	data, err := os.ReadFile(fs.keysPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var records []keyRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return err
+	}
+	for _, rec := range records {
+		fs.apiKeys[rec.ID] = dto.APIKey(rec)
+	}
+	return nil
+}
+
+// persistAPIKeys целиком перезаписывает файл ключей через временный файл,
+// чтобы падение посреди записи не оставило файл битым. Вызывать под fs.mu.
+func (fs *FileStore) persistAPIKeys() error {
+	records := make([]keyRecord, 0, len(fs.apiKeys))
+	for _, key := range fs.apiKeys {
+		records = append(records, keyRecord(key))
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].CreatedAt.Before(records[j].CreatedAt)
+	})
+
+	data, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	tmp := fs.keysPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, fs.keysPath)
+}
+
+func (fs *FileStore) SaveAPIKey(ctx context.Context, key dto.APIKey) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	fs.apiKeys[key.ID] = key
+	if err := fs.persistAPIKeys(); err != nil {
+		delete(fs.apiKeys, key.ID)
+		return err
+	}
+	return nil
+}
+
+func (fs *FileStore) GetAPIKeyByHash(ctx context.Context, hash string) (dto.APIKey, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	for _, key := range fs.apiKeys {
+		if key.Hash == hash {
+			return key, nil
+		}
+	}
+	return dto.APIKey{}, service.ErrAPIKeyNotFound
+}
+
+func (fs *FileStore) ListAPIKeys(ctx context.Context, userID string) ([]dto.APIKey, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	var result []dto.APIKey
+	for _, key := range fs.apiKeys {
+		if key.UserID == userID {
+			result = append(result, key)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+func (fs *FileStore) RevokeAPIKey(ctx context.Context, userID, keyID string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	key, ok := fs.apiKeys[keyID]
+	if !ok || key.UserID != userID {
+		return service.ErrAPIKeyNotFound
+	}
+	delete(fs.apiKeys, keyID)
+	if err := fs.persistAPIKeys(); err != nil {
+		fs.apiKeys[keyID] = key
+		return err
+	}
+	return nil
+}
+
+func (fs *FileStore) TouchAPIKey(ctx context.Context, keyID string, usedAt time.Time) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	key, ok := fs.apiKeys[keyID]
+	if !ok {
+		return service.ErrAPIKeyNotFound
+	}
+	key.LastUsedAt = &usedAt
+	fs.apiKeys[keyID] = key
+	return fs.persistAPIKeys()
+}
+
+// IterateAPIKeys обходит все API-ключи в порядке создания.
+func (fs *FileStore) IterateAPIKeys(ctx context.Context, fn func(dto.APIKey) error) error {
+	fs.mu.RLock()
+	keys := make([]dto.APIKey, 0, len(fs.apiKeys))
+	for _, key := range fs.apiKeys {
+		keys = append(keys, key)
+	}
+	fs.mu.RUnlock()
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	for _, key := range keys {
+		if err := fn(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
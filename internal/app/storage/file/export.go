@@ -0,0 +1,130 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+)
+
+// loadClicks читает файл счётчиков переходов, если он есть.
+func (fs *FileStore) loadClicks() error {
+	data, err := os.ReadFile(fs.clicksPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &fs.clicks)
+}
+
+// AddClicks прибавляет накопленные переходы и перезаписывает файл счётчиков
+// через временный файл. Счётчики хранятся отдельно от ссылок, чтобы не дописывать
+// строку в основной файл на каждый сброс.
+func (fs *FileStore) AddClicks(ctx context.Context, counts map[string]int64) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	for short, n := range counts {
+		if _, ok := fs.data[short]; ok {
+			fs.clicks[short] += n
+		}
+	}
+	return fs.persistClicks()
+}
+
+// persistClicks перезаписывает файл счётчиков. Вызывать под fs.mu.
+func (fs *FileStore) persistClicks() error {
+	data, err := json.Marshal(fs.clicks)
+	if err != nil {
+		return err
+	}
+	tmp := fs.clicksPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, fs.clicksPath)
+}
+
+// exportPageSize — сколько ссылок выгрузки собирается за один проход под блокировкой.
+const exportPageSize = 1000
+
+// IterateByUser обходит ссылки пользователя в порядке создания страницами по exportPageSize.
+// Страница собирается под блокировкой чтения, а fn вызывается уже без неё, чтобы медленный
+// клиент не задерживал запись; в памяти одновременно находится не больше одной страницы.
+func (fs *FileStore) IterateByUser(ctx context.Context, userID string, fn func(dto.LinkExport) error) error {
+	var after *dto.LinkExport
+	for {
+		page := fs.userLinksPage(userID, after)
+		for _, link := range page {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := fn(link); err != nil {
+				return err
+			}
+		}
+		if len(page) < exportPageSize {
+			return nil
+		}
+		after = &page[len(page)-1]
+	}
+}
+
+// userLinksPage возвращает до exportPageSize ссылок пользователя, следующих за after.
+func (fs *FileStore) userLinksPage(userID string, after *dto.LinkExport) []dto.LinkExport {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	var page []dto.LinkExport
+	for _, rec := range fs.data {
+		if rec.UserID != userID {
+			continue
+		}
+		link := dto.LinkExport{
+			ShortURL:    rec.ShortURL,
+			OriginalURL: rec.OriginalURL,
+			CreatedAt:   rec.CreatedAt,
+			Deleted:     rec.Deleted,
+			ExpiresAt:   rec.ExpiresAt,
+			Clicks:      fs.clicks[rec.ShortURL],
+			LinkOptions: rec.LinkOptions,
+		}
+		if after != nil && !exportedBefore(*after, link) {
+			continue
+		}
+		page = append(page, link)
+		// Хвост отбрасывается по ходу обхода, чтобы страница не разрасталась до всех ссылок
+		if len(page) == 2*exportPageSize {
+			sortExport(page)
+			page = page[:exportPageSize]
+		}
+	}
+	sortExport(page)
+	if len(page) > exportPageSize {
+		page = page[:exportPageSize]
+	}
+	return page
+}
+
+func sortExport(links []dto.LinkExport) {
+	sort.Slice(links, func(i, j int) bool { return exportedBefore(links[i], links[j]) })
+}
+
+// exportedBefore задаёт порядок выгрузки: по дате создания, ссылки без неё (старые записи)
+// идут первыми, при равных датах — по коду.
+func exportedBefore(a, b dto.LinkExport) bool {
+	switch {
+	case a.CreatedAt == nil && b.CreatedAt != nil:
+		return true
+	case a.CreatedAt != nil && b.CreatedAt == nil:
+		return false
+	case a.CreatedAt != nil && !a.CreatedAt.Equal(*b.CreatedAt):
+		return a.CreatedAt.Before(*b.CreatedAt)
+	}
+	return a.ShortURL < b.ShortURL
+}
@@ -2,25 +2,46 @@ package file
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/service"
 )
 
+// Record — строка файла хранилища. Удаление и смена настроек записываются отдельной
+// строкой для той же короткой ссылки: файл только дописывается, действует последняя.
 type Record struct {
-	ShortURL    string `json:"short_url"`
-	OriginalURL string `json:"original_url"`
-	UserID      string `json:"user_id"`
+	ShortURL    string     `json:"short_url"`
+	OriginalURL string     `json:"original_url"`
+	UserID      string     `json:"user_id"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	Deleted     bool       `json:"is_deleted,omitempty"`
+	dto.LinkOptions
 }
 
 type FileStore struct {
-	mu     sync.RWMutex
-	data   map[string]Record
-	file   *os.File
-	writer *bufio.Writer
+	mu   sync.RWMutex
+	data map[string]Record
+	file *os.File
+
+	activeCount map[string]int
+	// expiring — сроки жизни активных ссылок пользователя, у которых он есть:
+	// истёкшие вычитаются из activeCount при подсчёте
+	expiring map[string]map[string]time.Time
+
+	path     string
+	keysPath string
+	apiKeys  map[string]dto.APIKey
+
+	clicksPath string
+	clicks     map[string]int64
 }
 
 func NewFileStore(path string) (*FileStore, error) {
@@ -28,14 +49,42 @@ func NewFileStore(path string) (*FileStore, error) {
 	if err != nil {
 		return nil, err
 	}
+	return openFileStore(file, path)
+}
 
+// OpenReadOnly открывает существующее хранилище только для чтения: отсутствующий
+// файл не создаётся, а даёт ошибку. Запись в такое хранилище завершается ошибкой.
+func OpenReadOnly(path string) (*FileStore, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return openFileStore(file, path)
+}
+
+func openFileStore(file *os.File, path string) (*FileStore, error) {
 	store := &FileStore{
-		data:   make(map[string]Record),
-		file:   file,
-		writer: bufio.NewWriter(file),
+		data:        make(map[string]Record),
+		activeCount: make(map[string]int),
+		expiring:    make(map[string]map[string]time.Time),
+		file:        file,
+		path:        path,
+		keysPath:    path + ".keys",
+		apiKeys:     make(map[string]dto.APIKey),
+		clicksPath:  path + ".clicks",
+		clicks:      make(map[string]int64),
 	}
 
 	if err := store.load(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := store.loadAPIKeys(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := store.loadClicks(); err != nil {
+		file.Close()
 		return nil, err
 	}
 
@@ -52,73 +101,196 @@ func (fs *FileStore) load() error {
 		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
 			continue
 		}
+		if prev, ok := fs.data[rec.ShortURL]; ok && !prev.Deleted {
+			fs.uncountActive(prev.UserID, prev.ShortURL)
+		}
+		if !rec.Deleted {
+			fs.countActive(rec.UserID, rec.ShortURL, rec.ExpiresAt)
+		}
 		fs.data[rec.ShortURL] = rec
 	}
 	return scanner.Err()
 }
 
-func (fs *FileStore) Save(shortURL, originalURL, userID string) (string, error) {
+func (fs *FileStore) Save(ctx context.Context, shortURL, originalURL, userID string) (string, error) {
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
 
 	for _, rec := range fs.data {
-		if rec.OriginalURL == originalURL {
+		if rec.OriginalURL == originalURL && rec.active() {
 			return rec.ShortURL, nil
 		}
 	}
 
+	now := time.Now().UTC()
 	rec := Record{
 		ShortURL:    shortURL,
 		OriginalURL: originalURL,
 		UserID:      userID,
+		CreatedAt:   &now,
 	}
 
-	data, err := json.Marshal(rec)
-	if err != nil {
+	if err := fs.appendRecords(rec); err != nil {
 		return "", err
 	}
 
-	if _, err := fs.writer.Write(append(data, '\n')); err != nil {
-		return "", err
+	fs.data[shortURL] = rec
+	fs.countActive(userID, shortURL, nil)
+	return shortURL, nil
+}
+
+// SaveBatch сохраняет пакет ссылок одной записью в файл.
+// Память обновляется только после успешной записи всего пакета.
+func (fs *FileStore) SaveBatch(ctx context.Context, userID string, items []dto.BatchSaveItem) ([]dto.BatchSaveItem, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	existing := make(map[string]string)
+	for _, rec := range fs.data {
+		if rec.active() {
+			existing[rec.OriginalURL] = rec.ShortURL
+		}
 	}
-	if err := fs.writer.Flush(); err != nil {
-		return "", err
+
+	result := make([]dto.BatchSaveItem, len(items))
+	var records []Record
+	claimed := make(map[string]bool)
+	now := time.Now().UTC()
+	for i, item := range items {
+		if shortURL, ok := existing[item.OriginalURL]; ok {
+			result[i] = dto.BatchSaveItem{ShortURL: shortURL, OriginalURL: item.OriginalURL, Existing: true}
+			continue
+		}
+		if _, taken := fs.data[item.ShortURL]; taken || claimed[item.ShortURL] {
+			result[i] = item
+			result[i].Conflict = true
+			continue
+		}
+
+		rec := Record{
+			ShortURL:    item.ShortURL,
+			OriginalURL: item.OriginalURL,
+			UserID:      userID,
+			CreatedAt:   &now,
+			ExpiresAt:   item.ExpiresAt,
+			LinkOptions: item.LinkOptions,
+		}
+		records = append(records, rec)
+		claimed[item.ShortURL] = true
+		existing[item.OriginalURL] = item.ShortURL
+		result[i] = item
+	}
+	if err := fs.appendRecords(records...); err != nil {
+		return nil, err
 	}
 
-	fs.data[shortURL] = rec
-	return shortURL, nil
+	for _, rec := range records {
+		fs.data[rec.ShortURL] = rec
+		fs.countActive(userID, rec.ShortURL, rec.ExpiresAt)
+	}
+	return result, nil
 }
 
-func (fs *FileStore) Get(shortURL string) (string, error) {
+func (fs *FileStore) Get(ctx context.Context, shortURL string) (string, error) {
+	link, err := fs.Resolve(ctx, shortURL)
+	return link.OriginalURL, err
+}
+
+// Resolve возвращает ссылку для перехода вместе со сроком жизни и настройками.
+func (fs *FileStore) Resolve(ctx context.Context, shortURL string) (dto.ResolvedLink, error) {
 	fs.mu.RLock()
 	defer fs.mu.RUnlock()
 
 	rec, ok := fs.data[shortURL]
 	if !ok {
-		return "", errors.New("not found")
+		return dto.ResolvedLink{}, errors.New("not found")
+	}
+	if rec.Deleted || expired(rec.ExpiresAt) {
+		return dto.ResolvedLink{}, errors.New("gone")
+	}
+	return dto.ResolvedLink{
+		OriginalURL: rec.OriginalURL,
+		ExpiresAt:   rec.ExpiresAt,
+		LinkOptions: rec.LinkOptions,
+	}, nil
+}
+
+// UpdateLinkOptions дописывает в файл ссылку пользователя с новыми настройками перехода.
+func (fs *FileStore) UpdateLinkOptions(ctx context.Context, userID, shortURL string, opts dto.LinkOptions) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	rec, ok := fs.data[shortURL]
+	if !ok || rec.UserID != userID || rec.Deleted || expired(rec.ExpiresAt) {
+		return service.ErrLinkNotFound
+	}
+	rec.LinkOptions = opts
+
+	if err := fs.appendRecords(rec); err != nil {
+		return err
 	}
-	return rec.OriginalURL, nil
+	fs.data[shortURL] = rec
+	return nil
 }
 
-func (fs *FileStore) GetByOriginalURL(originalURL string) (string, error) {
+// appendRecords дописывает записи в файл одним вызовом Write. Если запись не удалась,
+// файл обрезается до прежнего размера: пакет попадает в файл целиком или не попадает вовсе,
+// и при следующей загрузке не появятся ссылки, которых не было в памяти.
+func (fs *FileStore) appendRecords(records ...Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	for _, rec := range records {
+		data, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		buf.Write(append(data, '\n'))
+	}
+
+	info, err := fs.file.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := fs.file.Write(buf.Bytes()); err != nil {
+		if truncErr := fs.file.Truncate(info.Size()); truncErr != nil {
+			return errors.Join(err, truncErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// expired сообщает, истёк ли срок жизни ссылки.
+func expired(expiresAt *time.Time) bool {
+	return expiresAt != nil && !expiresAt.After(time.Now())
+}
+
+// active сообщает, что по ссылке можно перейти: она не удалена и не истекла.
+func (rec Record) active() bool {
+	return !rec.Deleted && !expired(rec.ExpiresAt)
+}
+
+func (fs *FileStore) GetByOriginalURL(ctx context.Context, originalURL string) (string, error) {
 	fs.mu.RLock()
 	defer fs.mu.RUnlock()
 
 	for _, rec := range fs.data {
-		if rec.OriginalURL == originalURL {
+		if rec.OriginalURL == originalURL && rec.active() {
 			return rec.ShortURL, nil
 		}
 	}
 	return "", errors.New("not found")
 }
 
-func (fs *FileStore) GetAllByUser(userID string) ([]dto.UserURL, error) {
+func (fs *FileStore) GetAllByUser(ctx context.Context, userID string) ([]dto.UserURL, error) {
 	fs.mu.RLock()
 	defer fs.mu.RUnlock()
 
 	var result []dto.UserURL
 	for _, rec := range fs.data {
-		if rec.UserID == userID {
+		if rec.UserID == userID && !rec.Deleted {
 			result = append(result, dto.UserURL{
 				ShortURL:    rec.ShortURL,
 				OriginalURL: rec.OriginalURL,
@@ -128,7 +300,82 @@ func (fs *FileStore) GetAllByUser(userID string) ([]dto.UserURL, error) {
 	return result, nil
 }
 
-func (fs *FileStore) BatchDelete(userID string, shortURLs []string) error {
-	// В файле заглушка (автотесты Practicum не проверяют файловое хранилище на удаление)
-	return nil
+// BatchDelete дописывает в файл отметки об удалении ссылок пользователя
+// и сообщает итог по каждой ссылке.
+func (fs *FileStore) BatchDelete(ctx context.Context, userID string, shortURLs []string) ([]dto.DeleteResult, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	results := make([]dto.DeleteResult, 0, len(shortURLs))
+	var tombstones []Record
+	for _, shortURL := range shortURLs {
+		rec, ok := fs.data[shortURL]
+		status := service.DeleteResultDeleted
+		switch {
+		case !ok:
+			status = service.DeleteResultNotFound
+		case rec.UserID != userID:
+			status = service.DeleteResultNotOwned
+		case !rec.Deleted:
+			rec.Deleted = true
+			tombstones = append(tombstones, rec)
+		}
+		results = append(results, dto.DeleteResult{ShortURL: shortURL, Status: status})
+	}
+
+	if err := fs.appendRecords(tombstones...); err != nil {
+		return nil, err
+	}
+
+	// Память меняется только после успешной записи, чтобы повтор удаления застал те же ссылки
+	for _, rec := range tombstones {
+		if !fs.data[rec.ShortURL].Deleted {
+			fs.uncountActive(rec.UserID, rec.ShortURL)
+		}
+		fs.data[rec.ShortURL] = rec
+	}
+	return results, nil
+}
+
+// CountActiveByUser возвращает число неудалённых и неистёкших ссылок пользователя
+// по счётчику, который ведётся при загрузке и сохранении.
+func (fs *FileStore) CountActiveByUser(ctx context.Context, userID string) (int, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	count := fs.activeCount[userID]
+	now := time.Now()
+	for _, expiresAt := range fs.expiring[userID] {
+		if !expiresAt.After(now) {
+			count--
+		}
+	}
+	return count, nil
+}
+
+// countActive учитывает новую активную ссылку пользователя.
+func (fs *FileStore) countActive(userID, shortURL string, expiresAt *time.Time) {
+	fs.activeCount[userID]++
+	if expiresAt != nil {
+		if fs.expiring[userID] == nil {
+			fs.expiring[userID] = make(map[string]time.Time)
+		}
+		fs.expiring[userID][shortURL] = *expiresAt
+	}
+}
+
+// uncountActive убирает удалённую ссылку из подсчёта.
+func (fs *FileStore) uncountActive(userID, shortURL string) {
+	fs.activeCount[userID]--
+	delete(fs.expiring[userID], shortURL)
+}
+
+// Ping проверяет, что файл хранилища по-прежнему можно открыть на запись:
+// его могли удалить, перемонтировать диск только для чтения или отобрать права.
+func (fs *FileStore) Ping(ctx context.Context) error {
+	f, err := os.OpenFile(fs.path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return err
+	}
+	return f.Close()
 }
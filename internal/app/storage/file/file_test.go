@@ -0,0 +1,106 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore_ExpiredLinkIsReplaced(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "storage.json")
+	store, err := NewFileStore(path)
+	require.NoError(t, err)
+
+	past := time.Now().Add(-time.Minute)
+	_, err = store.SaveBatch(ctx, "alice", []dto.BatchSaveItem{
+		{ShortURL: "old", OriginalURL: "https://example.com", ExpiresAt: &past},
+	})
+	require.NoError(t, err)
+
+	count, err := store.CountActiveByUser(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	_, err = store.GetByOriginalURL(ctx, "https://example.com")
+	assert.EqualError(t, err, "not found")
+
+	// Истёкшая ссылка не выдаётся повторно: сохраняется новая
+	short, err := store.Save(ctx, "new", "https://example.com", "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "new", short)
+
+	short, err = store.GetByOriginalURL(ctx, "https://example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "new", short)
+	count, err = store.CountActiveByUser(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	_, err = store.Get(ctx, "old")
+	assert.EqualError(t, err, "gone")
+
+	// После перезапуска счётчик восстанавливается из файла с учётом срока жизни
+	reopened, err := NewFileStore(path)
+	require.NoError(t, err)
+	count, err = reopened.CountActiveByUser(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	short, err = reopened.GetByOriginalURL(ctx, "https://example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "new", short)
+}
+
+func TestFileStore_SaveBatchFailureKeepsMemory(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewFileStore(filepath.Join(t.TempDir(), "storage.json"))
+	require.NoError(t, err)
+
+	// Запись в закрытый файл не удаётся: ни одна ссылка пакета не должна появиться в памяти
+	require.NoError(t, store.file.Close())
+	_, err = store.SaveBatch(ctx, "alice", []dto.BatchSaveItem{
+		{ShortURL: "a1", OriginalURL: "https://example.com/1"},
+		{ShortURL: "a2", OriginalURL: "https://example.com/2"},
+	})
+	require.Error(t, err)
+
+	_, err = store.Get(ctx, "a1")
+	assert.EqualError(t, err, "not found")
+	count, err := store.CountActiveByUser(ctx, "alice")
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestFileStore_IterateByUserPages(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewFileStore(filepath.Join(t.TempDir(), "storage.json"))
+	require.NoError(t, err)
+
+	// Больше двух страниц, причём у всего пакета одна дата создания
+	items := make([]dto.BatchSaveItem, 2*exportPageSize+10)
+	for i := range items {
+		items[i] = dto.BatchSaveItem{ShortURL: fmt.Sprintf("c%05d", i), OriginalURL: fmt.Sprintf("https://example.com/%d", i)}
+	}
+	_, err = store.SaveBatch(ctx, "alice", items)
+	require.NoError(t, err)
+	_, err = store.Save(ctx, "later", "https://example.com/later", "alice")
+	require.NoError(t, err)
+	_, err = store.Save(ctx, "foreign", "https://example.com/foreign", "bob")
+	require.NoError(t, err)
+
+	var got []string
+	require.NoError(t, store.IterateByUser(ctx, "alice", func(link dto.LinkExport) error {
+		got = append(got, link.ShortURL)
+		return nil
+	}))
+	require.Len(t, got, len(items)+1)
+	for i, item := range items {
+		assert.Equal(t, item.ShortURL, got[i])
+	}
+	assert.Equal(t, "later", got[len(got)-1])
+}
@@ -0,0 +1,98 @@
+package file
+
+import (
+	"context"
+	"sort"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+)
+
+// IterateLinks обходит все ссылки в порядке короткого кода, начиная после after.
+func (fs *FileStore) IterateLinks(ctx context.Context, after string, fn func(dto.StoredLink) error) error {
+	fs.mu.RLock()
+	var links []dto.StoredLink
+	for short, rec := range fs.data {
+		if short <= after {
+			continue
+		}
+		links = append(links, dto.StoredLink{
+			ShortURL:    rec.ShortURL,
+			OriginalURL: rec.OriginalURL,
+			UserID:      rec.UserID,
+			CreatedAt:   rec.CreatedAt,
+			ExpiresAt:   rec.ExpiresAt,
+			Deleted:     rec.Deleted,
+			Clicks:      fs.clicks[short],
+			LinkOptions: rec.LinkOptions,
+		})
+	}
+	fs.mu.RUnlock()
+
+	sort.Slice(links, func(i, j int) bool { return links[i].ShortURL < links[j].ShortURL })
+	for _, link := range links {
+		if err := fn(link); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImportLinks дописывает ссылки в файл как есть. Ссылка с занятым кодом и активная ссылка,
+// чей оригинальный URL уже у другой активной, пропускаются, поэтому повторный импорт безопасен.
+// Возвращает число добавленных.
+func (fs *FileStore) ImportLinks(ctx context.Context, links []dto.StoredLink) (int, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	active := make(map[string]bool)
+	for _, rec := range fs.data {
+		if rec.active() {
+			active[rec.OriginalURL] = true
+		}
+	}
+
+	var records []Record
+	claimed := make(map[string]bool)
+	clicks := make(map[string]int64)
+	for _, link := range links {
+		if _, taken := fs.data[link.ShortURL]; taken || claimed[link.ShortURL] || (!link.Deleted && active[link.OriginalURL]) {
+			continue
+		}
+		rec := Record{
+			ShortURL:    link.ShortURL,
+			OriginalURL: link.OriginalURL,
+			UserID:      link.UserID,
+			CreatedAt:   link.CreatedAt,
+			ExpiresAt:   link.ExpiresAt,
+			Deleted:     link.Deleted,
+			LinkOptions: link.LinkOptions,
+		}
+		records = append(records, rec)
+		claimed[link.ShortURL] = true
+		if link.Clicks > 0 {
+			clicks[link.ShortURL] = link.Clicks
+		}
+		if !link.Deleted {
+			active[link.OriginalURL] = true
+		}
+	}
+	if err := fs.appendRecords(records...); err != nil {
+		return 0, err
+	}
+
+	for _, rec := range records {
+		fs.data[rec.ShortURL] = rec
+		if !rec.Deleted {
+			fs.countActive(rec.UserID, rec.ShortURL, rec.ExpiresAt)
+		}
+	}
+	for short, n := range clicks {
+		fs.clicks[short] = n
+	}
+	if len(clicks) > 0 {
+		if err := fs.persistClicks(); err != nil {
+			return 0, err
+		}
+	}
+	return len(records), nil
+}
@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/service"
+)
+
+func (m *MemoryStore) SaveAPIKey(ctx context.Context, key dto.APIKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.apiKeys[key.ID] = key
+	m.apiKeyIdx[key.Hash] = key.ID
+	return nil
+}
+
+func (m *MemoryStore) GetAPIKeyByHash(ctx context.Context, hash string) (dto.APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	id, ok := m.apiKeyIdx[hash]
+	if !ok {
+		return dto.APIKey{}, service.ErrAPIKeyNotFound
+	}
+	return m.apiKeys[id], nil
+}
+
+func (m *MemoryStore) ListAPIKeys(ctx context.Context, userID string) ([]dto.APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var result []dto.APIKey
+	for _, key := range m.apiKeys {
+		if key.UserID == userID {
+			result = append(result, key)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+func (m *MemoryStore) RevokeAPIKey(ctx context.Context, userID, keyID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key, ok := m.apiKeys[keyID]
+	if !ok || key.UserID != userID {
+		return service.ErrAPIKeyNotFound
+	}
+	delete(m.apiKeys, keyID)
+	delete(m.apiKeyIdx, key.Hash)
+	return nil
+}
+
+func (m *MemoryStore) TouchAPIKey(ctx context.Context, keyID string, usedAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key, ok := m.apiKeys[keyID]
+	if !ok {
+		return service.ErrAPIKeyNotFound
+	}
+	key.LastUsedAt = &usedAt
+	m.apiKeys[keyID] = key
+	return nil
+}
+
+// IterateAPIKeys обходит все API-ключи в порядке создания.
+func (m *MemoryStore) IterateAPIKeys(ctx context.Context, fn func(dto.APIKey) error) error {
+	m.mu.RLock()
+	keys := make([]dto.APIKey, 0, len(m.apiKeys))
+	for _, key := range m.apiKeys {
+		keys = append(keys, key)
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	for _, key := range keys {
+		if err := fn(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,66 +1,140 @@
 package memory
 
 import (
+	"context"
 	"errors"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/service"
 )
 
 type StoredURL struct {
 	OriginalURL string
 	UserID      string
+	CreatedAt   time.Time
+	ExpiresAt   *time.Time
+	Clicks      int64
 	Deleted     bool
+	Options     dto.LinkOptions
 }
 
 type MemoryStore struct {
 	mu          sync.RWMutex
 	data        map[string]StoredURL
 	originalIdx map[string]string
+	activeCount map[string]int
+	// expiring — сроки жизни активных ссылок пользователя, у которых он есть:
+	// истёкшие вычитаются из activeCount при подсчёте
+	expiring  map[string]map[string]time.Time
+	apiKeys   map[string]dto.APIKey
+	apiKeyIdx map[string]string
 }
 
 func NewMemoryStore() *MemoryStore {
 	return &MemoryStore{
 		data:        make(map[string]StoredURL),
 		originalIdx: make(map[string]string),
+		activeCount: make(map[string]int),
+		expiring:    make(map[string]map[string]time.Time),
+		apiKeys:     make(map[string]dto.APIKey),
+		apiKeyIdx:   make(map[string]string),
 	}
 }
 
-func (m *MemoryStore) Save(shortURL, originalURL, userID string) (string, error) {
+func (m *MemoryStore) Save(ctx context.Context, shortURL, originalURL, userID string) (string, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if existingShort, ok := m.originalIdx[originalURL]; ok {
-		record := m.data[existingShort]
-		if !record.Deleted {
-			return existingShort, nil
-		}
+	if existingShort, ok := m.originalIdx[originalURL]; ok && m.data[existingShort].active() {
+		return existingShort, nil
 	}
 
 	m.data[shortURL] = StoredURL{
 		OriginalURL: originalURL,
 		UserID:      userID,
+		CreatedAt:   time.Now().UTC(),
 		Deleted:     false,
 	}
 	m.originalIdx[originalURL] = shortURL
+	m.countActive(userID, shortURL, nil)
 	return shortURL, nil
 }
 
-func (m *MemoryStore) Get(shortURL string) (string, error) {
+// SaveBatch сохраняет пакет ссылок под одной блокировкой.
+// Повтор оригинального URL (в хранилище или внутри пакета) возвращает уже выданный код;
+// удалённая или истёкшая ссылка с тем же URL не мешает создать новую.
+func (m *MemoryStore) SaveBatch(ctx context.Context, userID string, items []dto.BatchSaveItem) ([]dto.BatchSaveItem, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make([]dto.BatchSaveItem, len(items))
+	for i, item := range items {
+		if existingShort, ok := m.originalIdx[item.OriginalURL]; ok && m.data[existingShort].active() {
+			result[i] = dto.BatchSaveItem{ShortURL: existingShort, OriginalURL: item.OriginalURL, Existing: true}
+			continue
+		}
+		if _, taken := m.data[item.ShortURL]; taken {
+			result[i] = item
+			result[i].Conflict = true
+			continue
+		}
+
+		m.data[item.ShortURL] = StoredURL{
+			OriginalURL: item.OriginalURL,
+			UserID:      userID,
+			CreatedAt:   time.Now().UTC(),
+			ExpiresAt:   item.ExpiresAt,
+			Options:     item.LinkOptions,
+		}
+		m.originalIdx[item.OriginalURL] = item.ShortURL
+		m.countActive(userID, item.ShortURL, item.ExpiresAt)
+		result[i] = item
+	}
+	return result, nil
+}
+
+func (m *MemoryStore) Get(ctx context.Context, shortURL string) (string, error) {
+	link, err := m.Resolve(ctx, shortURL)
+	return link.OriginalURL, err
+}
+
+// Resolve возвращает ссылку для перехода вместе со сроком жизни и настройками.
+func (m *MemoryStore) Resolve(ctx context.Context, shortURL string) (dto.ResolvedLink, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	record, ok := m.data[shortURL]
 	if !ok {
-		return "", errors.New("not found")
+		return dto.ResolvedLink{}, errors.New("not found")
 	}
-	if record.Deleted {
-		return "", errors.New("gone")
+	if record.Deleted || expired(record.ExpiresAt) {
+		return dto.ResolvedLink{}, errors.New("gone")
 	}
-	return record.OriginalURL, nil
+	return dto.ResolvedLink{
+		OriginalURL: record.OriginalURL,
+		ExpiresAt:   record.ExpiresAt,
+		LinkOptions: record.Options,
+	}, nil
 }
 
-func (m *MemoryStore) GetByOriginalURL(originalURL string) (string, error) {
+// UpdateLinkOptions заменяет настройки перехода по активной ссылке пользователя.
+func (m *MemoryStore) UpdateLinkOptions(ctx context.Context, userID, shortURL string, opts dto.LinkOptions) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record, ok := m.data[shortURL]
+	if !ok || record.UserID != userID || record.Deleted || expired(record.ExpiresAt) {
+		return service.ErrLinkNotFound
+	}
+	record.Options = opts
+	m.data[shortURL] = record
+	return nil
+}
+
+func (m *MemoryStore) GetByOriginalURL(ctx context.Context, originalURL string) (string, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
@@ -68,14 +142,13 @@ func (m *MemoryStore) GetByOriginalURL(originalURL string) (string, error) {
 	if !ok {
 		return "", errors.New("not found")
 	}
-	record := m.data[shortURL]
-	if record.Deleted {
+	if !m.data[shortURL].active() {
 		return "", errors.New("not found")
 	}
 	return shortURL, nil
 }
 
-func (m *MemoryStore) GetAllByUser(userID string) ([]dto.UserURL, error) {
+func (m *MemoryStore) GetAllByUser(ctx context.Context, userID string) ([]dto.UserURL, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
@@ -91,16 +164,194 @@ func (m *MemoryStore) GetAllByUser(userID string) ([]dto.UserURL, error) {
 	return result, nil
 }
 
-func (m *MemoryStore) BatchDelete(userID string, shortURLs []string) error {
+// BatchDelete помечает ссылки пользователя удалёнными и сообщает итог по каждой.
+func (m *MemoryStore) BatchDelete(ctx context.Context, userID string, shortURLs []string) ([]dto.DeleteResult, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	results := make([]dto.DeleteResult, 0, len(shortURLs))
 	for _, shortURL := range shortURLs {
 		record, ok := m.data[shortURL]
-		if ok && record.UserID == userID {
+		status := service.DeleteResultDeleted
+		switch {
+		case !ok:
+			status = service.DeleteResultNotFound
+		case record.UserID != userID:
+			status = service.DeleteResultNotOwned
+		case !record.Deleted:
 			record.Deleted = true
 			m.data[shortURL] = record
+			m.uncountActive(userID, shortURL)
 		}
+		results = append(results, dto.DeleteResult{ShortURL: shortURL, Status: status})
 	}
+	return results, nil
+}
+
+// CountActiveByUser возвращает число неудалённых и неистёкших ссылок пользователя.
+// Счётчик ведётся при сохранении и удалении, а истёкшие вычитаются по сроку жизни,
+// поэтому подсчёт не требует обхода всех записей.
+func (m *MemoryStore) CountActiveByUser(ctx context.Context, userID string) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	count := m.activeCount[userID]
+	now := time.Now()
+	for _, expiresAt := range m.expiring[userID] {
+		if !expiresAt.After(now) {
+			count--
+		}
+	}
+	return count, nil
+}
+
+// countActive учитывает новую активную ссылку пользователя.
+func (m *MemoryStore) countActive(userID, shortURL string, expiresAt *time.Time) {
+	m.activeCount[userID]++
+	if expiresAt != nil {
+		if m.expiring[userID] == nil {
+			m.expiring[userID] = make(map[string]time.Time)
+		}
+		m.expiring[userID][shortURL] = *expiresAt
+	}
+}
+
+// uncountActive убирает удалённую ссылку из подсчёта.
+func (m *MemoryStore) uncountActive(userID, shortURL string) {
+	m.activeCount[userID]--
+	delete(m.expiring[userID], shortURL)
+}
+
+// active сообщает, что по ссылке можно перейти: она не удалена и не истекла.
+func (s StoredURL) active() bool {
+	return !s.Deleted && !expired(s.ExpiresAt)
+}
+
+// Ping всегда успешен: in-memory хранилищу нечему отказывать.
+func (m *MemoryStore) Ping(ctx context.Context) error {
 	return nil
 }
+
+// IterateByUser обходит ссылки пользователя. Снимок берётся под блокировкой,
+// а fn вызывается уже без неё, чтобы медленный клиент не задерживал запись.
+func (m *MemoryStore) IterateByUser(ctx context.Context, userID string, fn func(dto.LinkExport) error) error {
+	m.mu.RLock()
+	var links []dto.LinkExport
+	for short, record := range m.data {
+		if record.UserID != userID {
+			continue
+		}
+		createdAt := record.CreatedAt
+		links = append(links, dto.LinkExport{
+			ShortURL:    short,
+			OriginalURL: record.OriginalURL,
+			CreatedAt:   &createdAt,
+			Deleted:     record.Deleted,
+			ExpiresAt:   record.ExpiresAt,
+			Clicks:      record.Clicks,
+			LinkOptions: record.Options,
+		})
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(links, func(i, j int) bool { return links[i].CreatedAt.Before(*links[j].CreatedAt) })
+	for _, link := range links {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(link); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddClicks прибавляет накопленные переходы к счётчикам ссылок.
+func (m *MemoryStore) AddClicks(ctx context.Context, counts map[string]int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for short, n := range counts {
+		if record, ok := m.data[short]; ok {
+			record.Clicks += n
+			m.data[short] = record
+		}
+	}
+	return nil
+}
+
+// expired сообщает, истёк ли срок жизни ссылки.
+func expired(expiresAt *time.Time) bool {
+	return expiresAt != nil && !expiresAt.After(time.Now())
+}
+
+// IterateLinks обходит все ссылки в порядке короткого кода, начиная после after.
+func (m *MemoryStore) IterateLinks(ctx context.Context, after string, fn func(dto.StoredLink) error) error {
+	m.mu.RLock()
+	var links []dto.StoredLink
+	for short, record := range m.data {
+		if short <= after {
+			continue
+		}
+		link := dto.StoredLink{
+			ShortURL:    short,
+			OriginalURL: record.OriginalURL,
+			UserID:      record.UserID,
+			ExpiresAt:   record.ExpiresAt,
+			Deleted:     record.Deleted,
+			Clicks:      record.Clicks,
+			LinkOptions: record.Options,
+		}
+		if !record.CreatedAt.IsZero() {
+			createdAt := record.CreatedAt
+			link.CreatedAt = &createdAt
+		}
+		links = append(links, link)
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(links, func(i, j int) bool { return links[i].ShortURL < links[j].ShortURL })
+	for _, link := range links {
+		if err := fn(link); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImportLinks сохраняет ссылки как есть. Ссылка с занятым кодом и активная ссылка,
+// чей оригинальный URL уже у другой активной, пропускаются, поэтому повторный импорт безопасен. Возвращает число добавленных.
+func (m *MemoryStore) ImportLinks(ctx context.Context, links []dto.StoredLink) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	imported := 0
+	for _, link := range links {
+		if _, taken := m.data[link.ShortURL]; taken {
+			continue
+		}
+		// Удалённых ссылок на один URL может быть сколько угодно, активная — одна
+		if existingShort, ok := m.originalIdx[link.OriginalURL]; ok && !link.Deleted && m.data[existingShort].active() {
+			continue
+		}
+
+		record := StoredURL{
+			OriginalURL: link.OriginalURL,
+			UserID:      link.UserID,
+			ExpiresAt:   link.ExpiresAt,
+			Clicks:      link.Clicks,
+			Deleted:     link.Deleted,
+			Options:     link.LinkOptions,
+		}
+		if link.CreatedAt != nil {
+			record.CreatedAt = *link.CreatedAt
+		}
+		m.data[link.ShortURL] = record
+		if !link.Deleted {
+			m.originalIdx[link.OriginalURL] = link.ShortURL
+			m.countActive(link.UserID, link.ShortURL, link.ExpiresAt)
+		}
+		imported++
+	}
+	return imported, nil
+}
@@ -0,0 +1,43 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_ExpiredLinkIsReplaced(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	past := time.Now().Add(-time.Minute)
+	_, err := store.SaveBatch(ctx, "alice", []dto.BatchSaveItem{
+		{ShortURL: "old", OriginalURL: "https://example.com", ExpiresAt: &past},
+	})
+	require.NoError(t, err)
+
+	count, err := store.CountActiveByUser(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	_, err = store.GetByOriginalURL(ctx, "https://example.com")
+	assert.EqualError(t, err, "not found")
+
+	// Истёкшая ссылка не выдаётся повторно: сохраняется новая
+	short, err := store.Save(ctx, "new", "https://example.com", "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "new", short)
+
+	short, err = store.GetByOriginalURL(ctx, "https://example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "new", short)
+	count, err = store.CountActiveByUser(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	_, err = store.Get(ctx, "old")
+	assert.EqualError(t, err, "gone")
+}
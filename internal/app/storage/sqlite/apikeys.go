@@ -0,0 +1,97 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/service"
+	"github.com/DaniYer/GoProject.git/internal/app/storage/sqlite/queries"
+)
+
+func (s *SQLiteStore) SaveAPIKey(ctx context.Context, key dto.APIKey) error {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	return s.queries.InsertAPIKey(ctx, queries.InsertAPIKeyParams{
+		ID:        key.ID,
+		UserID:    key.UserID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		KeyHash:   key.Hash,
+		CreatedAt: key.CreatedAt.UTC(),
+	})
+}
+
+func (s *SQLiteStore) GetAPIKeyByHash(ctx context.Context, hash string) (dto.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	row, err := s.queries.GetAPIKeyByHash(ctx, hash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.APIKey{}, service.ErrAPIKeyNotFound
+		}
+		return dto.APIKey{}, err
+	}
+	return apiKeyFromRow(row), nil
+}
+
+func (s *SQLiteStore) ListAPIKeys(ctx context.Context, userID string) ([]dto.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	rows, err := s.queries.ListAPIKeysByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dto.APIKey, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, apiKeyFromRow(row))
+	}
+	return result, nil
+}
+
+func (s *SQLiteStore) RevokeAPIKey(ctx context.Context, userID, keyID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	affected, err := s.queries.DeleteAPIKey(ctx, queries.DeleteAPIKeyParams{ID: keyID, UserID: userID})
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return service.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (s *SQLiteStore) TouchAPIKey(ctx context.Context, keyID string, usedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	return s.queries.TouchAPIKey(ctx, queries.TouchAPIKeyParams{
+		ID:         keyID,
+		LastUsedAt: nullTime(usedAt),
+	})
+}
+
+// apiKeyFromRow переводит строку таблицы api_keys в dto.APIKey.
+func apiKeyFromRow(row queries.ApiKey) dto.APIKey {
+	key := dto.APIKey{
+		ID:        row.ID,
+		UserID:    row.UserID,
+		Name:      row.Name,
+		Prefix:    row.Prefix,
+		Hash:      row.KeyHash,
+		CreatedAt: row.CreatedAt,
+	}
+	if row.LastUsedAt.Valid {
+		lastUsed := row.LastUsedAt.Time
+		key.LastUsedAt = &lastUsed
+	}
+	return key
+}
@@ -0,0 +1,87 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/storage/sqlite/queries"
+)
+
+// exportPageSize — сколько строк читается за один запрос при выгрузке.
+const exportPageSize = 1000
+
+// IterateByUser обходит ссылки пользователя страницами по exportPageSize строк,
+// поэтому в памяти одновременно находится не больше одной страницы.
+func (s *SQLiteStore) IterateByUser(ctx context.Context, userID string, fn func(dto.LinkExport) error) error {
+	var afterID int64
+	for {
+		rows, err := s.listUserLinksPage(ctx, userID, afterID)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			link := dto.LinkExport{
+				ShortURL:    row.ShortUrl,
+				OriginalURL: row.OriginalUrl,
+				Deleted:     row.IsDeleted,
+				Clicks:      row.Clicks,
+				CreatedAt:   timePtr(row.CreatedAt),
+				ExpiresAt:   timePtr(row.ExpiresAt),
+				LinkOptions: linkOptions(row.RedirectType, row.QueryMode, row.ForwardPath),
+			}
+			if err := fn(link); err != nil {
+				return err
+			}
+			afterID = row.ID
+		}
+		if len(rows) < exportPageSize {
+			return nil
+		}
+	}
+}
+
+func (s *SQLiteStore) listUserLinksPage(ctx context.Context, userID string, afterID int64) ([]queries.ListUserLinksPageRow, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return s.queries.ListUserLinksPage(ctx, queries.ListUserLinksPageParams{
+		UserID:   sql.NullString{String: userID, Valid: true},
+		AfterID:  afterID,
+		PageSize: exportPageSize,
+	})
+}
+
+// AddClicks прибавляет накопленные переходы к счётчикам в одной транзакции.
+func (s *SQLiteStore) AddClicks(ctx context.Context, counts map[string]int64) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := s.queries.WithTx(tx)
+
+	for short, n := range counts {
+		if err := qtx.AddClicks(ctx, queries.AddClicksParams{ShortUrl: short, N: n}); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// timePtr переводит NULL-время из базы в указатель.
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// linkOptions собирает настройки перехода из колонок urls.
+func linkOptions(redirectType int64, queryMode string, forwardPath bool) dto.LinkOptions {
+	return dto.LinkOptions{RedirectType: int(redirectType), QueryMode: queryMode, ForwardPath: forwardPath}
+}
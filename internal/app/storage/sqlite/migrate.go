@@ -0,0 +1,102 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/storage/sqlite/queries"
+)
+
+// IterateLinks обходит все ссылки в порядке короткого кода, начиная после after,
+// страницами по exportPageSize строк.
+func (s *SQLiteStore) IterateLinks(ctx context.Context, after string, fn func(dto.StoredLink) error) error {
+	for {
+		rows, err := s.listLinksPage(ctx, after)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			link := dto.StoredLink{
+				ShortURL:    row.ShortUrl,
+				OriginalURL: row.OriginalUrl,
+				UserID:      row.UserID.String,
+				Deleted:     row.IsDeleted,
+				Clicks:      row.Clicks,
+				CreatedAt:   timePtr(row.CreatedAt),
+				ExpiresAt:   timePtr(row.ExpiresAt),
+				LinkOptions: linkOptions(row.RedirectType, row.QueryMode, row.ForwardPath),
+			}
+			if err := fn(link); err != nil {
+				return err
+			}
+			after = row.ShortUrl
+		}
+		if len(rows) < exportPageSize {
+			return nil
+		}
+	}
+}
+
+func (s *SQLiteStore) listLinksPage(ctx context.Context, after string) ([]queries.ListLinksPageRow, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return s.queries.ListLinksPage(ctx, queries.ListLinksPageParams{AfterShortUrl: after, PageSize: exportPageSize})
+}
+
+// ImportLinks вставляет ссылки как есть в одной транзакции. Ссылка с занятым кодом и активная
+// ссылка, чей оригинальный URL уже у другой активной, пропускаются, поэтому повторный импорт безопасен.
+// Возвращает число добавленных.
+func (s *SQLiteStore) ImportLinks(ctx context.Context, links []dto.StoredLink) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	qtx := s.queries.WithTx(tx)
+
+	imported := 0
+	for _, link := range links {
+		params := queries.ImportLinkParams{
+			ShortUrl:     link.ShortURL,
+			OriginalUrl:  link.OriginalURL,
+			CreatedAt:    nullTimePtr(link.CreatedAt),
+			ExpiresAt:    nullTimePtr(link.ExpiresAt),
+			IsDeleted:    link.Deleted,
+			Clicks:       link.Clicks,
+			RedirectType: int64(link.RedirectType),
+			QueryMode:    link.QueryMode,
+			ForwardPath:  link.ForwardPath,
+		}
+		if link.UserID != "" {
+			params.UserID.String, params.UserID.Valid = link.UserID, true
+		}
+		inserted, err := qtx.ImportLink(ctx, params)
+		if err != nil {
+			return 0, err
+		}
+		imported += int(inserted)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return imported, nil
+}
+
+// IterateAPIKeys обходит все API-ключи в порядке создания.
+func (s *SQLiteStore) IterateAPIKeys(ctx context.Context, fn func(dto.APIKey) error) error {
+	rows, err := s.queries.ListAllAPIKeys(ctx)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := fn(apiKeyFromRow(row)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
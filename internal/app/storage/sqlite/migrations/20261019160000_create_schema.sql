@@ -0,0 +1,56 @@
+-- +goose Up
+-- Схема повторяет PostgreSQL: массивы хранятся как JSON-текст, время — в UTC
+CREATE TABLE IF NOT EXISTS urls (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    short_url TEXT UNIQUE NOT NULL,
+    original_url TEXT UNIQUE NOT NULL,
+    user_id TEXT,
+    is_deleted BOOLEAN NOT NULL DEFAULT FALSE,
+    expires_at DATETIME,
+    created_at DATETIME,
+    clicks INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_urls_active_user_id ON urls (user_id) WHERE is_deleted = false;
+CREATE INDEX IF NOT EXISTS idx_urls_user_id_id ON urls (user_id, id);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    key_hash TEXT UNIQUE NOT NULL,
+    created_at DATETIME NOT NULL,
+    last_used_at DATETIME
+);
+
+CREATE TABLE IF NOT EXISTS delete_outbox (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    short_urls TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    locked_until DATETIME NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    results TEXT NOT NULL DEFAULT '[]',
+    completed_at DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_delete_outbox_locked_until ON delete_outbox (locked_until);
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id TEXT NOT NULL,
+    idem_key TEXT NOT NULL,
+    fingerprint TEXT NOT NULL,
+    completed BOOLEAN NOT NULL DEFAULT FALSE,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    content_type TEXT NOT NULL DEFAULT '',
+    location TEXT NOT NULL DEFAULT '',
+    body BLOB NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL,
+    PRIMARY KEY (user_id, idem_key)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS idempotency_keys;
+DROP TABLE IF EXISTS delete_outbox;
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS urls;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Код перенаправления ссылки; 0 — умолчание сервера
+ALTER TABLE urls ADD COLUMN redirect_type INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE urls DROP COLUMN redirect_type;
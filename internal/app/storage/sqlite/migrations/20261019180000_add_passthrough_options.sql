@@ -0,0 +1,8 @@
+-- +goose Up
+-- Перенос строки запроса и пути при переходе; пустой режим и false — без переноса
+ALTER TABLE urls ADD COLUMN query_mode TEXT NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN forward_path BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE urls DROP COLUMN forward_path;
+ALTER TABLE urls DROP COLUMN query_mode;
@@ -0,0 +1,45 @@
+-- +goose Up
+-- Оригинальный URL уникален только среди активных ссылок. Ограничение столбца
+-- в SQLite не снимается, поэтому таблица пересоздаётся
+CREATE TABLE urls_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    short_url TEXT UNIQUE NOT NULL,
+    original_url TEXT NOT NULL,
+    user_id TEXT,
+    is_deleted BOOLEAN NOT NULL DEFAULT FALSE,
+    expires_at DATETIME,
+    created_at DATETIME,
+    clicks INTEGER NOT NULL DEFAULT 0,
+    redirect_type INTEGER NOT NULL DEFAULT 0,
+    query_mode TEXT NOT NULL DEFAULT '',
+    forward_path BOOLEAN NOT NULL DEFAULT false
+);
+INSERT INTO urls_new (id, short_url, original_url, user_id, is_deleted, expires_at, created_at, clicks, redirect_type, query_mode, forward_path)
+SELECT id, short_url, original_url, user_id, is_deleted, expires_at, created_at, clicks, redirect_type, query_mode, forward_path FROM urls;
+DROP TABLE urls;
+ALTER TABLE urls_new RENAME TO urls;
+CREATE INDEX IF NOT EXISTS idx_urls_active_user_id ON urls (user_id) WHERE is_deleted = false;
+CREATE INDEX IF NOT EXISTS idx_urls_user_id_id ON urls (user_id, id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_urls_active_original_url ON urls (original_url) WHERE is_deleted = false;
+
+-- +goose Down
+-- Откат невозможен, если один URL уже сокращался после удаления: ограничение не создастся
+CREATE TABLE urls_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    short_url TEXT UNIQUE NOT NULL,
+    original_url TEXT UNIQUE NOT NULL,
+    user_id TEXT,
+    is_deleted BOOLEAN NOT NULL DEFAULT FALSE,
+    expires_at DATETIME,
+    created_at DATETIME,
+    clicks INTEGER NOT NULL DEFAULT 0,
+    redirect_type INTEGER NOT NULL DEFAULT 0,
+    query_mode TEXT NOT NULL DEFAULT '',
+    forward_path BOOLEAN NOT NULL DEFAULT false
+);
+INSERT INTO urls_old (id, short_url, original_url, user_id, is_deleted, expires_at, created_at, clicks, redirect_type, query_mode, forward_path)
+SELECT id, short_url, original_url, user_id, is_deleted, expires_at, created_at, clicks, redirect_type, query_mode, forward_path FROM urls;
+DROP TABLE urls;
+ALTER TABLE urls_old RENAME TO urls;
+CREATE INDEX IF NOT EXISTS idx_urls_active_user_id ON urls (user_id) WHERE is_deleted = false;
+CREATE INDEX IF NOT EXISTS idx_urls_user_id_id ON urls (user_id, id);
@@ -0,0 +1,113 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/service"
+	"github.com/DaniYer/GoProject.git/internal/app/storage/sqlite/queries"
+)
+
+// AppendDeleteJob сохраняет задачу удаления в outbox, сразу закрепляя её
+// за текущим экземпляром до leaseUntil. Коды ссылок хранятся JSON-массивом.
+func (s *SQLiteStore) AppendDeleteJob(ctx context.Context, job dto.DeleteJob, leaseUntil time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	shortURLs, err := json.Marshal(job.ShortURLs)
+	if err != nil {
+		return err
+	}
+	return s.queries.InsertDeleteJob(ctx, queries.InsertDeleteJobParams{
+		ID:          job.ID,
+		UserID:      job.UserID,
+		ShortUrls:   string(shortURLs),
+		CreatedAt:   job.CreatedAt.UTC(),
+		LockedUntil: leaseUntil.UTC(),
+	})
+}
+
+// ClaimDeleteJobs забирает до limit невыполненных задач с истёкшей арендой и продлевает её до leaseUntil.
+// Писатель в SQLite один, поэтому выборка и продление аренды не пересекаются с другими.
+func (s *SQLiteStore) ClaimDeleteJobs(ctx context.Context, now, leaseUntil time.Time, limit int) ([]dto.DeleteJob, error) {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	rows, err := s.queries.ClaimDeleteJobs(ctx, queries.ClaimDeleteJobsParams{
+		LeaseUntil: leaseUntil.UTC(),
+		Now:        now.UTC(),
+		MaxJobs:    int64(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]dto.DeleteJob, 0, len(rows))
+	for _, row := range rows {
+		job := dto.DeleteJob{
+			ID:        row.ID,
+			UserID:    row.UserID,
+			CreatedAt: row.CreatedAt,
+		}
+		if err := json.Unmarshal([]byte(row.ShortUrls), &job.ShortURLs); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// CompleteDeleteJob сохраняет итог выполненной задачи.
+func (s *SQLiteStore) CompleteDeleteJob(ctx context.Context, status dto.DeleteJobStatus) error {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	results, err := json.Marshal(status.Results)
+	if err != nil {
+		return err
+	}
+	return s.queries.CompleteDeleteJob(ctx, queries.CompleteDeleteJobParams{
+		ID:          status.ID,
+		Status:      status.Status,
+		Results:     string(results),
+		CompletedAt: nullTimePtr(status.CompletedAt),
+	})
+}
+
+// GetDeleteJob возвращает состояние задачи пользователя.
+// Чужая задача неотличима от несуществующей.
+func (s *SQLiteStore) GetDeleteJob(ctx context.Context, userID, jobID string) (dto.DeleteJobStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	row, err := s.queries.GetDeleteJob(ctx, queries.GetDeleteJobParams{ID: jobID, UserID: userID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.DeleteJobStatus{}, service.ErrDeleteJobNotFound
+		}
+		return dto.DeleteJobStatus{}, err
+	}
+
+	status := dto.DeleteJobStatus{
+		ID:          row.ID,
+		Status:      row.Status,
+		CreatedAt:   row.CreatedAt,
+		CompletedAt: timePtr(row.CompletedAt),
+	}
+	if err := json.Unmarshal([]byte(row.Results), &status.Results); err != nil {
+		return dto.DeleteJobStatus{}, err
+	}
+	return status, nil
+}
+
+// PurgeDeleteJobs удаляет выполненные задачи, завершённые раньше completedBefore.
+func (s *SQLiteStore) PurgeDeleteJobs(ctx context.Context, completedBefore time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	return s.queries.PurgeDeleteJobs(ctx, nullTime(completedBefore))
+}
@@ -0,0 +1,23 @@
+-- name: InsertAPIKey :exec
+INSERT INTO api_keys (id, user_id, name, prefix, key_hash, created_at)
+VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: GetAPIKeyByHash :one
+SELECT id, user_id, name, prefix, key_hash, created_at, last_used_at
+FROM api_keys WHERE key_hash = ?;
+
+-- name: ListAPIKeysByUser :many
+SELECT id, user_id, name, prefix, key_hash, created_at, last_used_at
+FROM api_keys WHERE user_id = ?
+ORDER BY created_at;
+
+-- name: DeleteAPIKey :execrows
+DELETE FROM api_keys WHERE id = ? AND user_id = ?;
+
+-- name: TouchAPIKey :exec
+UPDATE api_keys SET last_used_at = ? WHERE id = ?;
+
+-- name: ListAllAPIKeys :many
+SELECT id, user_id, name, prefix, key_hash, created_at, last_used_at
+FROM api_keys
+ORDER BY created_at;
@@ -0,0 +1,150 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: delete_outbox.sql
+
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const claimDeleteJobs = `-- name: ClaimDeleteJobs :many
+UPDATE delete_outbox SET locked_until = ?1
+WHERE id IN (
+    SELECT o.id FROM delete_outbox o
+    WHERE o.status = 'pending' AND o.locked_until < ?2
+    ORDER BY o.created_at
+    LIMIT ?3
+)
+RETURNING id, user_id, short_urls, created_at
+`
+
+type ClaimDeleteJobsParams struct {
+	LeaseUntil time.Time
+	Now        time.Time
+	MaxJobs    int64
+}
+
+type ClaimDeleteJobsRow struct {
+	ID        string
+	UserID    string
+	ShortUrls string
+	CreatedAt time.Time
+}
+
+func (q *Queries) ClaimDeleteJobs(ctx context.Context, arg ClaimDeleteJobsParams) ([]ClaimDeleteJobsRow, error) {
+	rows, err := q.db.QueryContext(ctx, claimDeleteJobs, arg.LeaseUntil, arg.Now, arg.MaxJobs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClaimDeleteJobsRow
+	for rows.Next() {
+		var i ClaimDeleteJobsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.ShortUrls,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const completeDeleteJob = `-- name: CompleteDeleteJob :exec
+UPDATE delete_outbox SET status = ?, results = ?, completed_at = ?
+WHERE id = ?
+`
+
+type CompleteDeleteJobParams struct {
+	Status      string
+	Results     string
+	CompletedAt sql.NullTime
+	ID          string
+}
+
+func (q *Queries) CompleteDeleteJob(ctx context.Context, arg CompleteDeleteJobParams) error {
+	_, err := q.db.ExecContext(ctx, completeDeleteJob,
+		arg.Status,
+		arg.Results,
+		arg.CompletedAt,
+		arg.ID,
+	)
+	return err
+}
+
+const getDeleteJob = `-- name: GetDeleteJob :one
+SELECT id, status, results, created_at, completed_at
+FROM delete_outbox WHERE id = ? AND user_id = ?
+`
+
+type GetDeleteJobParams struct {
+	ID     string
+	UserID string
+}
+
+type GetDeleteJobRow struct {
+	ID          string
+	Status      string
+	Results     string
+	CreatedAt   time.Time
+	CompletedAt sql.NullTime
+}
+
+func (q *Queries) GetDeleteJob(ctx context.Context, arg GetDeleteJobParams) (GetDeleteJobRow, error) {
+	row := q.db.QueryRowContext(ctx, getDeleteJob, arg.ID, arg.UserID)
+	var i GetDeleteJobRow
+	err := row.Scan(
+		&i.ID,
+		&i.Status,
+		&i.Results,
+		&i.CreatedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const insertDeleteJob = `-- name: InsertDeleteJob :exec
+INSERT INTO delete_outbox (id, user_id, short_urls, created_at, locked_until)
+VALUES (?, ?, ?, ?, ?)
+`
+
+type InsertDeleteJobParams struct {
+	ID          string
+	UserID      string
+	ShortUrls   string
+	CreatedAt   time.Time
+	LockedUntil time.Time
+}
+
+func (q *Queries) InsertDeleteJob(ctx context.Context, arg InsertDeleteJobParams) error {
+	_, err := q.db.ExecContext(ctx, insertDeleteJob,
+		arg.ID,
+		arg.UserID,
+		arg.ShortUrls,
+		arg.CreatedAt,
+		arg.LockedUntil,
+	)
+	return err
+}
+
+const purgeDeleteJobs = `-- name: PurgeDeleteJobs :exec
+DELETE FROM delete_outbox WHERE status <> 'pending' AND completed_at < ?
+`
+
+func (q *Queries) PurgeDeleteJobs(ctx context.Context, completedAt sql.NullTime) error {
+	_, err := q.db.ExecContext(ctx, purgeDeleteJobs, completedAt)
+	return err
+}
@@ -0,0 +1,28 @@
+-- name: ReserveIdempotencyKey :execrows
+INSERT INTO idempotency_keys (user_id, idem_key, fingerprint, created_at, expires_at)
+VALUES (sqlc.arg(user_id), sqlc.arg(idem_key), sqlc.arg(fingerprint), sqlc.arg(now), sqlc.arg(expires_at))
+ON CONFLICT (user_id, idem_key) DO UPDATE
+SET fingerprint = excluded.fingerprint,
+    completed = FALSE,
+    status_code = 0,
+    content_type = '',
+    location = '',
+    body = '',
+    created_at = excluded.created_at,
+    expires_at = excluded.expires_at
+WHERE idempotency_keys.expires_at < excluded.created_at;
+
+-- name: GetIdempotencyKey :one
+SELECT fingerprint, completed, status_code, content_type, location, body, expires_at
+FROM idempotency_keys WHERE user_id = ? AND idem_key = ?;
+
+-- name: CompleteIdempotencyKey :exec
+UPDATE idempotency_keys
+SET completed = TRUE, status_code = ?, content_type = ?, location = ?, body = ?
+WHERE user_id = ? AND idem_key = ?;
+
+-- name: ReleaseIdempotencyKey :exec
+DELETE FROM idempotency_keys WHERE user_id = ? AND idem_key = ? AND NOT completed;
+
+-- name: PurgeIdempotencyKeys :exec
+DELETE FROM idempotency_keys WHERE expires_at < ?;
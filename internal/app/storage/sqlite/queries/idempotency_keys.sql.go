@@ -0,0 +1,133 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: idempotency_keys.sql
+
+package queries
+
+import (
+	"context"
+	"time"
+)
+
+const completeIdempotencyKey = `-- name: CompleteIdempotencyKey :exec
+UPDATE idempotency_keys
+SET completed = TRUE, status_code = ?, content_type = ?, location = ?, body = ?
+WHERE user_id = ? AND idem_key = ?
+`
+
+type CompleteIdempotencyKeyParams struct {
+	StatusCode  int64
+	ContentType string
+	Location    string
+	Body        []byte
+	UserID      string
+	IdemKey     string
+}
+
+func (q *Queries) CompleteIdempotencyKey(ctx context.Context, arg CompleteIdempotencyKeyParams) error {
+	_, err := q.db.ExecContext(ctx, completeIdempotencyKey,
+		arg.StatusCode,
+		arg.ContentType,
+		arg.Location,
+		arg.Body,
+		arg.UserID,
+		arg.IdemKey,
+	)
+	return err
+}
+
+const getIdempotencyKey = `-- name: GetIdempotencyKey :one
+SELECT fingerprint, completed, status_code, content_type, location, body, expires_at
+FROM idempotency_keys WHERE user_id = ? AND idem_key = ?
+`
+
+type GetIdempotencyKeyParams struct {
+	UserID  string
+	IdemKey string
+}
+
+type GetIdempotencyKeyRow struct {
+	Fingerprint string
+	Completed   bool
+	StatusCode  int64
+	ContentType string
+	Location    string
+	Body        []byte
+	ExpiresAt   time.Time
+}
+
+func (q *Queries) GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (GetIdempotencyKeyRow, error) {
+	row := q.db.QueryRowContext(ctx, getIdempotencyKey, arg.UserID, arg.IdemKey)
+	var i GetIdempotencyKeyRow
+	err := row.Scan(
+		&i.Fingerprint,
+		&i.Completed,
+		&i.StatusCode,
+		&i.ContentType,
+		&i.Location,
+		&i.Body,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const purgeIdempotencyKeys = `-- name: PurgeIdempotencyKeys :exec
+DELETE FROM idempotency_keys WHERE expires_at < ?
+`
+
+func (q *Queries) PurgeIdempotencyKeys(ctx context.Context, expiresAt time.Time) error {
+	_, err := q.db.ExecContext(ctx, purgeIdempotencyKeys, expiresAt)
+	return err
+}
+
+const releaseIdempotencyKey = `-- name: ReleaseIdempotencyKey :exec
+DELETE FROM idempotency_keys WHERE user_id = ? AND idem_key = ? AND NOT completed
+`
+
+type ReleaseIdempotencyKeyParams struct {
+	UserID  string
+	IdemKey string
+}
+
+func (q *Queries) ReleaseIdempotencyKey(ctx context.Context, arg ReleaseIdempotencyKeyParams) error {
+	_, err := q.db.ExecContext(ctx, releaseIdempotencyKey, arg.UserID, arg.IdemKey)
+	return err
+}
+
+const reserveIdempotencyKey = `-- name: ReserveIdempotencyKey :execrows
+INSERT INTO idempotency_keys (user_id, idem_key, fingerprint, created_at, expires_at)
+VALUES (?1, ?2, ?3, ?4, ?5)
+ON CONFLICT (user_id, idem_key) DO UPDATE
+SET fingerprint = excluded.fingerprint,
+    completed = FALSE,
+    status_code = 0,
+    content_type = '',
+    location = '',
+    body = '',
+    created_at = excluded.created_at,
+    expires_at = excluded.expires_at
+WHERE idempotency_keys.expires_at < excluded.created_at
+`
+
+type ReserveIdempotencyKeyParams struct {
+	UserID      string
+	IdemKey     string
+	Fingerprint string
+	Now         time.Time
+	ExpiresAt   time.Time
+}
+
+func (q *Queries) ReserveIdempotencyKey(ctx context.Context, arg ReserveIdempotencyKeyParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, reserveIdempotencyKey,
+		arg.UserID,
+		arg.IdemKey,
+		arg.Fingerprint,
+		arg.Now,
+		arg.ExpiresAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,11 @@
+-- name: ListLinksPage :many
+SELECT short_url, original_url, user_id, created_at, expires_at, is_deleted, clicks, redirect_type, query_mode, forward_path
+FROM urls
+WHERE short_url > sqlc.arg(after_short_url)
+ORDER BY short_url
+LIMIT sqlc.arg(page_size);
+
+-- name: ImportLink :execrows
+INSERT INTO urls (short_url, original_url, user_id, created_at, expires_at, is_deleted, clicks, redirect_type, query_mode, forward_path)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT DO NOTHING;
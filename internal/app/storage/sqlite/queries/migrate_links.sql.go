@@ -0,0 +1,109 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: migrate_links.sql
+
+package queries
+
+import (
+	"context"
+	"database/sql"
+)
+
+const importLink = `-- name: ImportLink :execrows
+INSERT INTO urls (short_url, original_url, user_id, created_at, expires_at, is_deleted, clicks, redirect_type, query_mode, forward_path)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT DO NOTHING
+`
+
+type ImportLinkParams struct {
+	ShortUrl     string
+	OriginalUrl  string
+	UserID       sql.NullString
+	CreatedAt    sql.NullTime
+	ExpiresAt    sql.NullTime
+	IsDeleted    bool
+	Clicks       int64
+	RedirectType int64
+	QueryMode    string
+	ForwardPath  bool
+}
+
+func (q *Queries) ImportLink(ctx context.Context, arg ImportLinkParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, importLink,
+		arg.ShortUrl,
+		arg.OriginalUrl,
+		arg.UserID,
+		arg.CreatedAt,
+		arg.ExpiresAt,
+		arg.IsDeleted,
+		arg.Clicks,
+		arg.RedirectType,
+		arg.QueryMode,
+		arg.ForwardPath,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const listLinksPage = `-- name: ListLinksPage :many
+SELECT short_url, original_url, user_id, created_at, expires_at, is_deleted, clicks, redirect_type, query_mode, forward_path
+FROM urls
+WHERE short_url > ?1
+ORDER BY short_url
+LIMIT ?2
+`
+
+type ListLinksPageParams struct {
+	AfterShortUrl string
+	PageSize      int64
+}
+
+type ListLinksPageRow struct {
+	ShortUrl     string
+	OriginalUrl  string
+	UserID       sql.NullString
+	CreatedAt    sql.NullTime
+	ExpiresAt    sql.NullTime
+	IsDeleted    bool
+	Clicks       int64
+	RedirectType int64
+	QueryMode    string
+	ForwardPath  bool
+}
+
+func (q *Queries) ListLinksPage(ctx context.Context, arg ListLinksPageParams) ([]ListLinksPageRow, error) {
+	rows, err := q.db.QueryContext(ctx, listLinksPage, arg.AfterShortUrl, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLinksPageRow
+	for rows.Next() {
+		var i ListLinksPageRow
+		if err := rows.Scan(
+			&i.ShortUrl,
+			&i.OriginalUrl,
+			&i.UserID,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.IsDeleted,
+			&i.Clicks,
+			&i.RedirectType,
+			&i.QueryMode,
+			&i.ForwardPath,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,58 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+
+package queries
+
+import (
+	"database/sql"
+	"time"
+)
+
+type ApiKey struct {
+	ID         string
+	UserID     string
+	Name       string
+	Prefix     string
+	KeyHash    string
+	CreatedAt  time.Time
+	LastUsedAt sql.NullTime
+}
+
+type DeleteOutbox struct {
+	ID          string
+	UserID      string
+	ShortUrls   string
+	CreatedAt   time.Time
+	LockedUntil time.Time
+	Status      string
+	Results     string
+	CompletedAt sql.NullTime
+}
+
+type IdempotencyKey struct {
+	UserID      string
+	IdemKey     string
+	Fingerprint string
+	Completed   bool
+	StatusCode  int64
+	ContentType string
+	Location    string
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+type Url struct {
+	ID           int64
+	ShortUrl     string
+	OriginalUrl  string
+	UserID       sql.NullString
+	IsDeleted    bool
+	ExpiresAt    sql.NullTime
+	CreatedAt    sql.NullTime
+	Clicks       int64
+	RedirectType int64
+	QueryMode    string
+	ForwardPath  bool
+}
@@ -0,0 +1,52 @@
+-- name: InsertOrGetShortURL :one
+INSERT INTO urls (short_url, original_url, user_id, is_deleted, expires_at, created_at, redirect_type, query_mode, forward_path)
+VALUES (sqlc.arg(short_url), sqlc.arg(original_url), sqlc.arg(user_id), false, sqlc.narg(expires_at), sqlc.arg(created_at), sqlc.arg(redirect_type),
+    sqlc.arg(query_mode), sqlc.arg(forward_path))
+ON CONFLICT (original_url) WHERE is_deleted = false DO NOTHING
+RETURNING short_url;
+
+-- name: GetByShortURL :one
+SELECT original_url, is_deleted, expires_at, redirect_type, query_mode, forward_path FROM urls WHERE short_url = ?;
+
+-- name: GetByOriginalURL :one
+SELECT short_url FROM urls
+WHERE original_url = sqlc.arg(original_url) AND is_deleted = false
+    AND (expires_at IS NULL OR expires_at > sqlc.arg(now));
+
+-- name: RetireExpiredURL :exec
+UPDATE urls SET is_deleted = true
+WHERE original_url = sqlc.arg(original_url) AND is_deleted = false
+    AND expires_at IS NOT NULL AND expires_at <= sqlc.arg(now);
+
+-- name: SelectTakenShortURLs :many
+SELECT short_url FROM urls WHERE short_url IN (sqlc.slice(short_urls));
+
+-- name: GetAllByUserID :many
+SELECT short_url, original_url FROM urls
+WHERE user_id = ? AND is_deleted = false;
+
+-- name: CountActiveByUserID :one
+SELECT COUNT(*) FROM urls
+WHERE user_id = sqlc.arg(user_id) AND is_deleted = false
+    AND (expires_at IS NULL OR expires_at > sqlc.arg(now));
+
+-- name: BatchDeleteURLs :many
+UPDATE urls SET is_deleted = true
+WHERE user_id = sqlc.arg(user_id) AND short_url IN (sqlc.slice(short_urls))
+RETURNING short_url;
+
+-- name: ListUserLinksPage :many
+SELECT id, short_url, original_url, created_at, is_deleted, expires_at, clicks, redirect_type, query_mode, forward_path
+FROM urls
+WHERE user_id = sqlc.arg(user_id) AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(page_size);
+
+-- name: AddClicks :exec
+UPDATE urls SET clicks = clicks + sqlc.arg(n) WHERE short_url = sqlc.arg(short_url);
+
+-- name: UpdateLinkOptions :execrows
+UPDATE urls SET redirect_type = sqlc.arg(redirect_type), query_mode = sqlc.arg(query_mode),
+    forward_path = sqlc.arg(forward_path)
+WHERE short_url = sqlc.arg(short_url) AND user_id = sqlc.arg(user_id) AND is_deleted = false
+    AND (expires_at IS NULL OR expires_at > sqlc.arg(now));
@@ -0,0 +1,347 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: urls.sql
+
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+const addClicks = `-- name: AddClicks :exec
+UPDATE urls SET clicks = clicks + ?1 WHERE short_url = ?2
+`
+
+type AddClicksParams struct {
+	N        int64
+	ShortUrl string
+}
+
+func (q *Queries) AddClicks(ctx context.Context, arg AddClicksParams) error {
+	_, err := q.db.ExecContext(ctx, addClicks, arg.N, arg.ShortUrl)
+	return err
+}
+
+const batchDeleteURLs = `-- name: BatchDeleteURLs :many
+UPDATE urls SET is_deleted = true
+WHERE user_id = ?1 AND short_url IN (/*SLICE:short_urls*/?)
+RETURNING short_url
+`
+
+type BatchDeleteURLsParams struct {
+	UserID    sql.NullString
+	ShortUrls []string
+}
+
+func (q *Queries) BatchDeleteURLs(ctx context.Context, arg BatchDeleteURLsParams) ([]string, error) {
+	query := batchDeleteURLs
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.UserID)
+	if len(arg.ShortUrls) > 0 {
+		for _, v := range arg.ShortUrls {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:short_urls*/?", strings.Repeat(",?", len(arg.ShortUrls))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:short_urls*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var short_url string
+		if err := rows.Scan(&short_url); err != nil {
+			return nil, err
+		}
+		items = append(items, short_url)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countActiveByUserID = `-- name: CountActiveByUserID :one
+SELECT COUNT(*) FROM urls
+WHERE user_id = ?1 AND is_deleted = false
+    AND (expires_at IS NULL OR expires_at > ?2)
+`
+
+type CountActiveByUserIDParams struct {
+	UserID sql.NullString
+	Now    sql.NullTime
+}
+
+func (q *Queries) CountActiveByUserID(ctx context.Context, arg CountActiveByUserIDParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countActiveByUserID, arg.UserID, arg.Now)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const getAllByUserID = `-- name: GetAllByUserID :many
+SELECT short_url, original_url FROM urls
+WHERE user_id = ? AND is_deleted = false
+`
+
+type GetAllByUserIDRow struct {
+	ShortUrl    string
+	OriginalUrl string
+}
+
+func (q *Queries) GetAllByUserID(ctx context.Context, userID sql.NullString) ([]GetAllByUserIDRow, error) {
+	rows, err := q.db.QueryContext(ctx, getAllByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetAllByUserIDRow
+	for rows.Next() {
+		var i GetAllByUserIDRow
+		if err := rows.Scan(&i.ShortUrl, &i.OriginalUrl); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getByOriginalURL = `-- name: GetByOriginalURL :one
+SELECT short_url FROM urls
+WHERE original_url = ?1 AND is_deleted = false
+    AND (expires_at IS NULL OR expires_at > ?2)
+`
+
+type GetByOriginalURLParams struct {
+	OriginalUrl string
+	Now         sql.NullTime
+}
+
+func (q *Queries) GetByOriginalURL(ctx context.Context, arg GetByOriginalURLParams) (string, error) {
+	row := q.db.QueryRowContext(ctx, getByOriginalURL, arg.OriginalUrl, arg.Now)
+	var short_url string
+	err := row.Scan(&short_url)
+	return short_url, err
+}
+
+const getByShortURL = `-- name: GetByShortURL :one
+SELECT original_url, is_deleted, expires_at, redirect_type, query_mode, forward_path FROM urls WHERE short_url = ?
+`
+
+type GetByShortURLRow struct {
+	OriginalUrl  string
+	IsDeleted    bool
+	ExpiresAt    sql.NullTime
+	RedirectType int64
+	QueryMode    string
+	ForwardPath  bool
+}
+
+func (q *Queries) GetByShortURL(ctx context.Context, shortUrl string) (GetByShortURLRow, error) {
+	row := q.db.QueryRowContext(ctx, getByShortURL, shortUrl)
+	var i GetByShortURLRow
+	err := row.Scan(
+		&i.OriginalUrl,
+		&i.IsDeleted,
+		&i.ExpiresAt,
+		&i.RedirectType,
+		&i.QueryMode,
+		&i.ForwardPath,
+	)
+	return i, err
+}
+
+const insertOrGetShortURL = `-- name: InsertOrGetShortURL :one
+INSERT INTO urls (short_url, original_url, user_id, is_deleted, expires_at, created_at, redirect_type, query_mode, forward_path)
+VALUES (?1, ?2, ?3, false, ?4, ?5, ?6,
+    ?7, ?8)
+ON CONFLICT (original_url) WHERE is_deleted = false DO NOTHING
+RETURNING short_url
+`
+
+type InsertOrGetShortURLParams struct {
+	ShortUrl     string
+	OriginalUrl  string
+	UserID       sql.NullString
+	ExpiresAt    sql.NullTime
+	CreatedAt    sql.NullTime
+	RedirectType int64
+	QueryMode    string
+	ForwardPath  bool
+}
+
+func (q *Queries) InsertOrGetShortURL(ctx context.Context, arg InsertOrGetShortURLParams) (string, error) {
+	row := q.db.QueryRowContext(ctx, insertOrGetShortURL,
+		arg.ShortUrl,
+		arg.OriginalUrl,
+		arg.UserID,
+		arg.ExpiresAt,
+		arg.CreatedAt,
+		arg.RedirectType,
+		arg.QueryMode,
+		arg.ForwardPath,
+	)
+	var short_url string
+	err := row.Scan(&short_url)
+	return short_url, err
+}
+
+const listUserLinksPage = `-- name: ListUserLinksPage :many
+SELECT id, short_url, original_url, created_at, is_deleted, expires_at, clicks, redirect_type, query_mode, forward_path
+FROM urls
+WHERE user_id = ?1 AND id > ?2
+ORDER BY id
+LIMIT ?3
+`
+
+type ListUserLinksPageParams struct {
+	UserID   sql.NullString
+	AfterID  int64
+	PageSize int64
+}
+
+type ListUserLinksPageRow struct {
+	ID           int64
+	ShortUrl     string
+	OriginalUrl  string
+	CreatedAt    sql.NullTime
+	IsDeleted    bool
+	ExpiresAt    sql.NullTime
+	Clicks       int64
+	RedirectType int64
+	QueryMode    string
+	ForwardPath  bool
+}
+
+func (q *Queries) ListUserLinksPage(ctx context.Context, arg ListUserLinksPageParams) ([]ListUserLinksPageRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUserLinksPage, arg.UserID, arg.AfterID, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserLinksPageRow
+	for rows.Next() {
+		var i ListUserLinksPageRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ShortUrl,
+			&i.OriginalUrl,
+			&i.CreatedAt,
+			&i.IsDeleted,
+			&i.ExpiresAt,
+			&i.Clicks,
+			&i.RedirectType,
+			&i.QueryMode,
+			&i.ForwardPath,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const retireExpiredURL = `-- name: RetireExpiredURL :exec
+UPDATE urls SET is_deleted = true
+WHERE original_url = ?1 AND is_deleted = false
+    AND expires_at IS NOT NULL AND expires_at <= ?2
+`
+
+type RetireExpiredURLParams struct {
+	OriginalUrl string
+	Now         sql.NullTime
+}
+
+func (q *Queries) RetireExpiredURL(ctx context.Context, arg RetireExpiredURLParams) error {
+	_, err := q.db.ExecContext(ctx, retireExpiredURL, arg.OriginalUrl, arg.Now)
+	return err
+}
+
+const selectTakenShortURLs = `-- name: SelectTakenShortURLs :many
+SELECT short_url FROM urls WHERE short_url IN (/*SLICE:short_urls*/?)
+`
+
+func (q *Queries) SelectTakenShortURLs(ctx context.Context, shortUrls []string) ([]string, error) {
+	query := selectTakenShortURLs
+	var queryParams []interface{}
+	if len(shortUrls) > 0 {
+		for _, v := range shortUrls {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:short_urls*/?", strings.Repeat(",?", len(shortUrls))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:short_urls*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var short_url string
+		if err := rows.Scan(&short_url); err != nil {
+			return nil, err
+		}
+		items = append(items, short_url)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateLinkOptions = `-- name: UpdateLinkOptions :execrows
+UPDATE urls SET redirect_type = ?1, query_mode = ?2,
+    forward_path = ?3
+WHERE short_url = ?4 AND user_id = ?5 AND is_deleted = false
+    AND (expires_at IS NULL OR expires_at > ?6)
+`
+
+type UpdateLinkOptionsParams struct {
+	RedirectType int64
+	QueryMode    string
+	ForwardPath  bool
+	ShortUrl     string
+	UserID       sql.NullString
+	Now          sql.NullTime
+}
+
+func (q *Queries) UpdateLinkOptions(ctx context.Context, arg UpdateLinkOptionsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateLinkOptions,
+		arg.RedirectType,
+		arg.QueryMode,
+		arg.ForwardPath,
+		arg.ShortUrl,
+		arg.UserID,
+		arg.Now,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,363 @@
+// Package sqlite реализует хранилище ссылок во встроенной базе SQLite.
+// Схема повторяет PostgreSQL-хранилище, миграции вшиты в бинарник,
+// поэтому для работы достаточно пути к файлу базы.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/service"
+	"github.com/DaniYer/GoProject.git/internal/app/storage/sqlite/queries"
+	"github.com/pressly/goose/v3"
+	_ "modernc.org/sqlite" // SQLite драйвер на чистом Go
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+type SQLiteStore struct {
+	db      *sql.DB
+	queries *queries.Queries
+}
+
+// Open открывает (или создаёт) файл базы по пути path и применяет вшитые миграции.
+func Open(ctx context.Context, path string) (*SQLiteStore, error) {
+	db, err := OpenDB(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := Migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return NewSQLiteStore(db), nil
+}
+
+// OpenDB открывает (или создаёт) файл базы по пути path без применения миграций.
+func OpenDB(path string) (*sql.DB, error) {
+	params := url.Values{}
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_pragma", "foreign_keys(1)")
+	// Время пишется текстом одного формата, поэтому сравнения в SQL остаются корректными
+	params.Set("_time_format", "sqlite")
+
+	db, err := sql.Open("sqlite", "file:"+path+"?"+params.Encode())
+	if err != nil {
+		return nil, err
+	}
+	// SQLite допускает одного писателя: одно соединение избавляет от SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+// NewMigrator возвращает goose-провайдер вшитых миграций SQLite.
+func NewMigrator(db *sql.DB) (*goose.Provider, error) {
+	fsys, err := fs.Sub(migrations, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return goose.NewProvider(goose.DialectSQLite3, db, fsys)
+}
+
+// Migrate применяет к базе все вшитые миграции, которые ещё не применены.
+func Migrate(ctx context.Context, db *sql.DB) error {
+	provider, err := NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	if _, err := provider.Up(ctx); err != nil {
+		return fmt.Errorf("sqlite migrations: %w", err)
+	}
+	return nil
+}
+
+func NewSQLiteStore(db *sql.DB) *SQLiteStore {
+	return &SQLiteStore{
+		db:      db,
+		queries: queries.New(db),
+	}
+}
+
+// Close закрывает базу.
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// DB возвращает пул соединений, например для метрик.
+func (s *SQLiteStore) DB() *sql.DB {
+	return s.db
+}
+
+// Save сохраняет ссылку или возвращает код активной ссылки с тем же оригинальным URL.
+// Удалённая или истёкшая ссылка остаётся у владельца, новая ложится отдельной строкой.
+func (s *SQLiteStore) Save(ctx context.Context, shortURL, originalURL, userID string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	createdAt := nullTime(now())
+	if err := s.queries.RetireExpiredURL(ctx, queries.RetireExpiredURLParams{OriginalUrl: originalURL, Now: createdAt}); err != nil {
+		return "", err
+	}
+	newShortURL, err := s.queries.InsertOrGetShortURL(ctx, queries.InsertOrGetShortURLParams{
+		ShortUrl:    shortURL,
+		OriginalUrl: originalURL,
+		UserID:      sql.NullString{String: userID, Valid: true},
+		CreatedAt:   createdAt,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return s.queries.GetByOriginalURL(ctx, queries.GetByOriginalURLParams{OriginalUrl: originalURL, Now: createdAt})
+	}
+	if err != nil {
+		return "", err
+	}
+	return newShortURL, nil
+}
+
+// SaveBatch сохраняет пакет ссылок в одной транзакции построчными INSERT:
+// массивов и unnest в SQLite нет, а внутри транзакции вставки дешёвые.
+// Ссылки, чей оригинальный URL уже есть в базе (или повторяется внутри пакета),
+// получают существующий код, занятый код помечается конфликтом. Удалённая или истёкшая
+// ссылка с тем же URL не мешает: новая ссылка ложится отдельной строкой, а старая остаётся у владельца.
+func (s *SQLiteStore) SaveBatch(ctx context.Context, userID string, items []dto.BatchSaveItem) ([]dto.BatchSaveItem, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	qtx := s.queries.WithTx(tx)
+
+	codes := make([]string, len(items))
+	for i, item := range items {
+		codes[i] = item.ShortURL
+	}
+	takenRows, err := qtx.SelectTakenShortURLs(ctx, codes)
+	if err != nil {
+		return nil, err
+	}
+	taken := make(map[string]bool, len(takenRows))
+	for _, shortURL := range takenRows {
+		taken[shortURL] = true
+	}
+
+	createdAt := nullTime(now())
+	result := make([]dto.BatchSaveItem, len(items))
+	for i, item := range items {
+		if existing, err := qtx.GetByOriginalURL(ctx, queries.GetByOriginalURLParams{
+			OriginalUrl: item.OriginalURL,
+			Now:         createdAt,
+		}); err == nil {
+			result[i] = dto.BatchSaveItem{ShortURL: existing, OriginalURL: item.OriginalURL, Existing: true}
+			continue
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		if taken[item.ShortURL] {
+			result[i] = item
+			result[i].Conflict = true
+			continue
+		}
+
+		if err := qtx.RetireExpiredURL(ctx, queries.RetireExpiredURLParams{
+			OriginalUrl: item.OriginalURL,
+			Now:         createdAt,
+		}); err != nil {
+			return nil, err
+		}
+		_, err := qtx.InsertOrGetShortURL(ctx, queries.InsertOrGetShortURLParams{
+			ShortUrl:     item.ShortURL,
+			OriginalUrl:  item.OriginalURL,
+			UserID:       sql.NullString{String: userID, Valid: true},
+			ExpiresAt:    nullTimePtr(item.ExpiresAt),
+			CreatedAt:    createdAt,
+			RedirectType: int64(item.RedirectType),
+			QueryMode:    item.QueryMode,
+			ForwardPath:  item.ForwardPath,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			// Строку с этим URL изменили между проверкой и вставкой: вызывающий повторит её
+			result[i] = item
+			result[i].Conflict = true
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		taken[item.ShortURL] = true
+		result[i] = item
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Get возвращает оригинальный URL. Удалённая или истёкшая ссылка даёт ошибку "gone".
+func (s *SQLiteStore) Get(ctx context.Context, shortURL string) (string, error) {
+	link, err := s.Resolve(ctx, shortURL)
+	return link.OriginalURL, err
+}
+
+// Resolve возвращает ссылку для перехода вместе со сроком жизни и настройками.
+func (s *SQLiteStore) Resolve(ctx context.Context, shortURL string) (dto.ResolvedLink, error) {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	row, err := s.queries.GetByShortURL(ctx, shortURL)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.ResolvedLink{}, errors.New("not found")
+		}
+		return dto.ResolvedLink{}, err
+	}
+	if row.IsDeleted || (row.ExpiresAt.Valid && !row.ExpiresAt.Time.After(time.Now())) {
+		return dto.ResolvedLink{}, errors.New("gone")
+	}
+	return dto.ResolvedLink{
+		OriginalURL: row.OriginalUrl,
+		ExpiresAt:   timePtr(row.ExpiresAt),
+		LinkOptions: linkOptions(row.RedirectType, row.QueryMode, row.ForwardPath),
+	}, nil
+}
+
+// UpdateLinkOptions заменяет настройки перехода по активной ссылке пользователя.
+func (s *SQLiteStore) UpdateLinkOptions(ctx context.Context, userID, shortURL string, opts dto.LinkOptions) error {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	updated, err := s.queries.UpdateLinkOptions(ctx, queries.UpdateLinkOptionsParams{
+		RedirectType: int64(opts.RedirectType),
+		QueryMode:    opts.QueryMode,
+		ForwardPath:  opts.ForwardPath,
+		ShortUrl:     shortURL,
+		UserID:       sql.NullString{String: userID, Valid: true},
+		Now:          nullTime(now()),
+	})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return service.ErrLinkNotFound
+	}
+	return nil
+}
+
+func (s *SQLiteStore) GetByOriginalURL(ctx context.Context, originalURL string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	return s.queries.GetByOriginalURL(ctx, queries.GetByOriginalURLParams{OriginalUrl: originalURL, Now: nullTime(now())})
+}
+
+func (s *SQLiteStore) GetAllByUser(ctx context.Context, userID string) ([]dto.UserURL, error) {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	urls, err := s.queries.GetAllByUserID(ctx, sql.NullString{String: userID, Valid: true})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dto.UserURL, 0, len(urls))
+	for _, u := range urls {
+		result = append(result, dto.UserURL{
+			ShortURL:    u.ShortUrl,
+			OriginalURL: u.OriginalUrl,
+		})
+	}
+	return result, nil
+}
+
+// BatchDelete помечает ссылки пользователя удалёнными и сообщает итог по каждой.
+// Ссылки, которые не удалось удалить, дополнительно ищутся без учёта владельца,
+// чтобы отличить чужую ссылку от несуществующей.
+func (s *SQLiteStore) BatchDelete(ctx context.Context, userID string, shortURLs []string) ([]dto.DeleteResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	deleted, err := s.queries.BatchDeleteURLs(ctx, queries.BatchDeleteURLsParams{
+		UserID:    sql.NullString{String: userID, Valid: true},
+		ShortUrls: shortURLs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	status := make(map[string]string, len(shortURLs))
+	for _, shortURL := range deleted {
+		status[shortURL] = service.DeleteResultDeleted
+	}
+
+	var rest []string
+	for _, shortURL := range shortURLs {
+		if _, ok := status[shortURL]; !ok {
+			rest = append(rest, shortURL)
+		}
+	}
+	if len(rest) > 0 {
+		existing, err := s.queries.SelectTakenShortURLs(ctx, rest)
+		if err != nil {
+			return nil, err
+		}
+		for _, shortURL := range existing {
+			status[shortURL] = service.DeleteResultNotOwned
+		}
+	}
+
+	results := make([]dto.DeleteResult, 0, len(shortURLs))
+	for _, shortURL := range shortURLs {
+		result, ok := status[shortURL]
+		if !ok {
+			result = service.DeleteResultNotFound
+		}
+		results = append(results, dto.DeleteResult{ShortURL: shortURL, Status: result})
+	}
+	return results, nil
+}
+
+func (s *SQLiteStore) CountActiveByUser(ctx context.Context, userID string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	count, err := s.queries.CountActiveByUserID(ctx, queries.CountActiveByUserIDParams{
+		UserID: sql.NullString{String: userID, Valid: true},
+		Now:    nullTime(now()),
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// Ping проверяет, что файл базы доступен.
+func (s *SQLiteStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// now возвращает текущее время в UTC — в этой зоне хранится всё время в базе.
+func now() time.Time {
+	return time.Now().UTC()
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+func nullTimePtr(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return nullTime(*t)
+}
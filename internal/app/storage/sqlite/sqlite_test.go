@@ -0,0 +1,290 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(t *testing.T) *SQLiteStore {
+	t.Helper()
+	store, err := Open(context.Background(), filepath.Join(t.TempDir(), "shortener.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestSQLiteStore_SaveAndGet(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	short, err := store.Save(ctx, "abc", "https://example.com", "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", short)
+
+	// Повтор оригинального URL возвращает уже выданный код
+	short, err = store.Save(ctx, "xyz", "https://example.com", "bob")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", short)
+
+	original, err := store.Get(ctx, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", original)
+
+	_, err = store.Get(ctx, "missing")
+	assert.EqualError(t, err, "not found")
+
+	count, err := store.CountActiveByUser(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestSQLiteStore_SaveBatch(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	_, err := store.Save(ctx, "taken", "https://example.com/taken", "bob")
+	require.NoError(t, err)
+
+	past := time.Now().Add(-time.Minute)
+	result, err := store.SaveBatch(ctx, "alice", []dto.BatchSaveItem{
+		{ShortURL: "a1", OriginalURL: "https://example.com/1"},
+		{ShortURL: "a2", OriginalURL: "https://example.com/1"},
+		{ShortURL: "taken", OriginalURL: "https://example.com/2"},
+		{ShortURL: "a3", OriginalURL: "https://example.com/taken"},
+		{ShortURL: "old", OriginalURL: "https://example.com/old", ExpiresAt: &past},
+	})
+	require.NoError(t, err)
+	require.Len(t, result, 5)
+
+	assert.Equal(t, dto.BatchSaveItem{ShortURL: "a1", OriginalURL: "https://example.com/1"}, result[0])
+	assert.Equal(t, "a1", result[1].ShortURL)
+	assert.True(t, result[1].Existing)
+	assert.True(t, result[2].Conflict)
+	assert.Equal(t, "taken", result[3].ShortURL)
+	assert.True(t, result[3].Existing)
+
+	_, err = store.Get(ctx, "old")
+	assert.EqualError(t, err, "gone")
+}
+
+func TestSQLiteStore_LinkOptions(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	opts := dto.LinkOptions{RedirectType: 301, QueryMode: service.QueryModeMerge, ForwardPath: true}
+	_, err := store.SaveBatch(ctx, "alice", []dto.BatchSaveItem{
+		{ShortURL: "docs", OriginalURL: "https://example.com/docs", LinkOptions: opts},
+	})
+	require.NoError(t, err)
+
+	link, err := store.Resolve(ctx, "docs")
+	require.NoError(t, err)
+	assert.Equal(t, opts, link.LinkOptions)
+
+	// Менять настройки может только владелец
+	assert.ErrorIs(t, store.UpdateLinkOptions(ctx, "bob", "docs", dto.LinkOptions{}), service.ErrLinkNotFound)
+	require.NoError(t, store.UpdateLinkOptions(ctx, "alice", "docs", dto.LinkOptions{QueryMode: service.QueryModeOverride}))
+
+	link, err = store.Resolve(ctx, "docs")
+	require.NoError(t, err)
+	assert.Equal(t, dto.LinkOptions{QueryMode: service.QueryModeOverride}, link.LinkOptions)
+}
+
+func TestSQLiteStore_BatchDeleteAndExport(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	_, err := store.Save(ctx, "a1", "https://example.com/1", "alice")
+	require.NoError(t, err)
+	_, err = store.Save(ctx, "b1", "https://example.com/2", "bob")
+	require.NoError(t, err)
+
+	results, err := store.BatchDelete(ctx, "alice", []string{"a1", "b1", "zz"})
+	require.NoError(t, err)
+	assert.Equal(t, []dto.DeleteResult{
+		{ShortURL: "a1", Status: service.DeleteResultDeleted},
+		{ShortURL: "b1", Status: service.DeleteResultNotOwned},
+		{ShortURL: "zz", Status: service.DeleteResultNotFound},
+	}, results)
+
+	_, err = store.Get(ctx, "a1")
+	assert.EqualError(t, err, "gone")
+
+	require.NoError(t, store.AddClicks(ctx, map[string]int64{"a1": 3}))
+
+	var links []dto.LinkExport
+	require.NoError(t, store.IterateByUser(ctx, "alice", func(link dto.LinkExport) error {
+		links = append(links, link)
+		return nil
+	}))
+	require.Len(t, links, 1)
+	assert.True(t, links[0].Deleted)
+	assert.Equal(t, int64(3), links[0].Clicks)
+	require.NotNil(t, links[0].CreatedAt)
+	assert.WithinDuration(t, time.Now(), *links[0].CreatedAt, time.Minute)
+}
+
+func TestSQLiteStore_DeleteJobs(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	now := time.Now()
+	job := dto.DeleteJob{ID: "job-1", UserID: "alice", ShortURLs: []string{"a1", "a2"}, CreatedAt: now}
+	require.NoError(t, store.AppendDeleteJob(ctx, job, now.Add(time.Minute)))
+
+	// Аренда ещё действует — задачу никто не забирает
+	jobs, err := store.ClaimDeleteJobs(ctx, now, now.Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.Empty(t, jobs)
+
+	jobs, err = store.ClaimDeleteJobs(ctx, now.Add(2*time.Minute), now.Add(3*time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, []string{"a1", "a2"}, jobs[0].ShortURLs)
+
+	completedAt := now.Add(2 * time.Minute)
+	require.NoError(t, store.CompleteDeleteJob(ctx, dto.DeleteJobStatus{
+		ID:          "job-1",
+		Status:      "completed",
+		Results:     []dto.DeleteResult{{ShortURL: "a1", Status: service.DeleteResultDeleted}},
+		CompletedAt: &completedAt,
+	}))
+
+	status, err := store.GetDeleteJob(ctx, "alice", "job-1")
+	require.NoError(t, err)
+	assert.Equal(t, "completed", status.Status)
+	assert.Len(t, status.Results, 1)
+
+	_, err = store.GetDeleteJob(ctx, "bob", "job-1")
+	assert.ErrorIs(t, err, service.ErrDeleteJobNotFound)
+
+	require.NoError(t, store.PurgeDeleteJobs(ctx, now.Add(time.Hour)))
+	_, err = store.GetDeleteJob(ctx, "alice", "job-1")
+	assert.ErrorIs(t, err, service.ErrDeleteJobNotFound)
+}
+
+func TestSQLiteStore_IdempotencyKeys(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	now := time.Now()
+	rec := dto.IdempotencyRecord{Fingerprint: "f1", ExpiresAt: now.Add(time.Hour)}
+	_, reserved, err := store.ReserveIdempotencyKey(ctx, "alice", "k", rec, now)
+	require.NoError(t, err)
+	assert.True(t, reserved)
+
+	existing, reserved, err := store.ReserveIdempotencyKey(ctx, "alice", "k", dto.IdempotencyRecord{Fingerprint: "f2", ExpiresAt: now.Add(time.Hour)}, now)
+	require.NoError(t, err)
+	assert.False(t, reserved)
+	assert.Equal(t, "f1", existing.Fingerprint)
+
+	require.NoError(t, store.CompleteIdempotencyKey(ctx, "alice", "k", dto.IdempotencyRecord{StatusCode: 201, Body: []byte("ok")}))
+	existing, _, err = store.ReserveIdempotencyKey(ctx, "alice", "k", rec, now)
+	require.NoError(t, err)
+	assert.True(t, existing.Completed)
+	assert.Equal(t, []byte("ok"), existing.Body)
+
+	// После истечения ключ можно занять заново
+	_, reserved, err = store.ReserveIdempotencyKey(ctx, "alice", "k", dto.IdempotencyRecord{Fingerprint: "f3", ExpiresAt: now.Add(3 * time.Hour)}, now.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.True(t, reserved)
+}
+
+func TestSQLiteStore_ExpiredLinkIsReplaced(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	past := time.Now().Add(-time.Minute)
+	_, err := store.SaveBatch(ctx, "alice", []dto.BatchSaveItem{
+		{ShortURL: "old", OriginalURL: "https://example.com", ExpiresAt: &past},
+	})
+	require.NoError(t, err)
+
+	count, err := store.CountActiveByUser(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	_, err = store.GetByOriginalURL(ctx, "https://example.com")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	// Истёкшая строка заменяется новой ссылкой, а не выдаётся повторно
+	short, err := store.Save(ctx, "new", "https://example.com", "bob")
+	require.NoError(t, err)
+	assert.Equal(t, "new", short)
+
+	result, err := store.SaveBatch(ctx, "alice", []dto.BatchSaveItem{
+		{ShortURL: "again", OriginalURL: "https://example.com"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "new", result[0].ShortURL)
+	assert.True(t, result[0].Existing)
+
+	count, err = store.CountActiveByUser(ctx, "bob")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	_, err = store.Get(ctx, "old")
+	assert.EqualError(t, err, "gone")
+}
+
+func TestSQLiteStore_DeletedLinkIsReplaced(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	_, err := store.Save(ctx, "old", "https://example.com", "alice")
+	require.NoError(t, err)
+	_, err = store.BatchDelete(ctx, "alice", []string{"old"})
+	require.NoError(t, err)
+
+	// Удалённая ссылка не мешает сократить тот же URL заново — ни по одному, ни пакетом
+	result, err := store.SaveBatch(ctx, "bob", []dto.BatchSaveItem{
+		{ShortURL: "new", OriginalURL: "https://example.com"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, dto.BatchSaveItem{ShortURL: "new", OriginalURL: "https://example.com"}, result[0])
+	// Новая ссылка — отдельная строка: старая по-прежнему удалена
+	_, err = store.Get(ctx, "old")
+	assert.EqualError(t, err, "gone")
+
+	_, err = store.BatchDelete(ctx, "bob", []string{"new"})
+	require.NoError(t, err)
+	short, err := store.Save(ctx, "newer", "https://example.com", "bob")
+	require.NoError(t, err)
+	assert.Equal(t, "newer", short)
+
+	original, err := store.Get(ctx, "newer")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", original)
+}
+
+func TestSQLiteStore_DeletedLinkStaysInOwnerExport(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	_, err := store.Save(ctx, "old", "https://example.com", "alice")
+	require.NoError(t, err)
+	_, err = store.BatchDelete(ctx, "alice", []string{"old"})
+	require.NoError(t, err)
+
+	short, err := store.Save(ctx, "new", "https://example.com", "bob")
+	require.NoError(t, err)
+	assert.Equal(t, "new", short)
+
+	// Чужое повторное сокращение не забирает строку у прежнего владельца
+	var exported []dto.LinkExport
+	require.NoError(t, store.IterateByUser(ctx, "alice", func(link dto.LinkExport) error {
+		exported = append(exported, link)
+		return nil
+	}))
+	require.Len(t, exported, 1)
+	assert.Equal(t, "old", exported[0].ShortURL)
+	assert.True(t, exported[0].Deleted)
+	_, err = store.Get(ctx, "old")
+	assert.EqualError(t, err, "gone")
+}
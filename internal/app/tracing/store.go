@@ -0,0 +1,122 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/service"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Store — обёртка над service.URLStore, которая создаёт спан на каждый вызов хранилища.
+// Так в трассе видно, сколько времени занял, например, поиск по оригинальному URL и сама вставка.
+type Store struct {
+	next    service.URLStore
+	backend string
+}
+
+// NewStore оборачивает хранилище. backend (postgresql, file, memory) попадает в атрибут db.system.
+func NewStore(next service.URLStore, backend string) *Store {
+	return &Store{
+		next:    next,
+		backend: backend,
+	}
+}
+
+// start открывает спан вызова хранилища.
+func (s *Store) start(ctx context.Context, op string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, attribute.String("db.system", s.backend), attribute.String("db.operation", op))
+	return otel.Tracer("github.com/DaniYer/GoProject.git/internal/app/tracing").Start(ctx, "URLStore."+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// end закрывает спан, отмечая ошибку, если она есть.
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (s *Store) Save(ctx context.Context, shortURL, originalURL, userID string) (string, error) {
+	ctx, span := s.start(ctx, "Save")
+	result, err := s.next.Save(ctx, shortURL, originalURL, userID)
+	end(span, err)
+	return result, err
+}
+
+func (s *Store) SaveBatch(ctx context.Context, userID string, items []dto.BatchSaveItem) ([]dto.BatchSaveItem, error) {
+	ctx, span := s.start(ctx, "SaveBatch", attribute.Int("shortener.batch_size", len(items)))
+	result, err := s.next.SaveBatch(ctx, userID, items)
+	end(span, err)
+	return result, err
+}
+
+func (s *Store) Get(ctx context.Context, shortURL string) (string, error) {
+	ctx, span := s.start(ctx, "Get", attribute.String("shortener.short_url", shortURL))
+	result, err := s.next.Get(ctx, shortURL)
+	end(span, err)
+	return result, err
+}
+
+func (s *Store) Resolve(ctx context.Context, shortURL string) (dto.ResolvedLink, error) {
+	ctx, span := s.start(ctx, "Resolve", attribute.String("shortener.short_url", shortURL))
+	result, err := s.next.Resolve(ctx, shortURL)
+	end(span, err)
+	return result, err
+}
+
+func (s *Store) GetByOriginalURL(ctx context.Context, originalURL string) (string, error) {
+	ctx, span := s.start(ctx, "GetByOriginalURL")
+	result, err := s.next.GetByOriginalURL(ctx, originalURL)
+	// Отсутствие ссылки здесь — обычный путь создания новой, а не сбой
+	span.SetAttributes(attribute.Bool("shortener.found", err == nil))
+	span.End()
+	return result, err
+}
+
+func (s *Store) GetAllByUser(ctx context.Context, userID string) ([]dto.UserURL, error) {
+	ctx, span := s.start(ctx, "GetAllByUser")
+	result, err := s.next.GetAllByUser(ctx, userID)
+	end(span, err)
+	return result, err
+}
+
+func (s *Store) BatchDelete(ctx context.Context, userID string, shortURLs []string) ([]dto.DeleteResult, error) {
+	ctx, span := s.start(ctx, "BatchDelete", attribute.Int("shortener.batch_size", len(shortURLs)))
+	result, err := s.next.BatchDelete(ctx, userID, shortURLs)
+	end(span, err)
+	return result, err
+}
+
+func (s *Store) CountActiveByUser(ctx context.Context, userID string) (int, error) {
+	ctx, span := s.start(ctx, "CountActiveByUser")
+	result, err := s.next.CountActiveByUser(ctx, userID)
+	end(span, err)
+	return result, err
+}
+
+func (s *Store) UpdateLinkOptions(ctx context.Context, userID, shortURL string, opts dto.LinkOptions) error {
+	ctx, span := s.start(ctx, "UpdateLinkOptions", attribute.String("shortener.short_url", shortURL))
+	err := s.next.UpdateLinkOptions(ctx, userID, shortURL, opts)
+	end(span, err)
+	return err
+}
+
+func (s *Store) IterateByUser(ctx context.Context, userID string, fn func(dto.LinkExport) error) error {
+	ctx, span := s.start(ctx, "IterateByUser")
+	count := 0
+	err := s.next.IterateByUser(ctx, userID, func(link dto.LinkExport) error {
+		count++
+		return fn(link)
+	})
+	span.SetAttributes(attribute.Int("shortener.links", count))
+	end(span, err)
+	return err
+}
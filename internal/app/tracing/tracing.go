@@ -0,0 +1,90 @@
+// Package tracing настраивает OpenTelemetry: провайдер трассировки, экспортёр
+// и обёртку над хранилищем, которая создаёт спан на каждый вызов URLStore.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Поддерживаемые экспортёры трассировки.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Config — параметры трассировки.
+type Config struct {
+	Exporter    string  // none, stdout или otlp
+	Endpoint    string  // адрес OTLP/HTTP коллектора (host:port); пустой — из OTEL_EXPORTER_OTLP_ENDPOINT
+	ServiceName string  // имя сервиса в трассах
+	SampleRatio float64 // доля сэмплируемых корневых трасс, от 0 до 1
+}
+
+// Init настраивает глобальный провайдер трассировки и пропагатор W3C traceparent.
+// Возвращает функцию, которую нужно вызвать при остановке, чтобы выгрузить оставшиеся спаны.
+// Пропагатор настраивается всегда: даже без экспорта входящий контекст трассы
+// продолжается в логах и исходящих вызовах.
+func Init(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, err
+		}
+		exporter = exp
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint), otlptracehttp.WithInsecure())
+		}
+		exp, err := otlptracehttp.New(ctx, opts...)
+		if err != nil {
+			return nil, err
+		}
+		exporter = exp
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", cfg.Exporter)
+	}
+
+	provider := NewProvider(cfg, sdktrace.WithBatcher(exporter))
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// NewProvider создаёт провайдер трассировки с ресурсом сервиса и сэмплером из конфигурации.
+// Тесты передают сюда синхронный процессор с in-memory экспортёром.
+func NewProvider(cfg Config, opts ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = "shortener"
+	}
+	ratio := cfg.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+
+	opts = append([]sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	}, opts...)
+	return sdktrace.NewTracerProvider(opts...)
+}
@@ -0,0 +1,107 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/handlers"
+	"github.com/DaniYer/GoProject.git/internal/app/middlewares"
+	"github.com/DaniYer/GoProject.git/internal/app/service"
+	"github.com/DaniYer/GoProject.git/internal/app/storage/memory"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// setupTracing подключает провайдер с in-memory экспортёром на время теста.
+func setupTracing(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := NewProvider(Config{}, sdktrace.WithSyncer(exporter))
+
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		provider.Shutdown(context.Background())
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+	return exporter
+}
+
+func TestTracing_SpansFromHandlerToStore(t *testing.T) {
+	exporter := setupTracing(t)
+
+	svc := &service.URLService{
+		Store:   NewStore(memory.NewMemoryStore(), "memory"),
+		BaseURL: "http://localhost:8080",
+	}
+	router := chi.NewRouter()
+	router.Use(middlewares.WithTracing)
+	router.Use(middlewares.InjectTestUserIDMiddleware("test-user-id"))
+	router.Post("/api/shorten", handlers.NewHandleShortenURLv13(svc))
+
+	body, _ := json.Marshal(dto.ShortenRequest{URL: "http://example.com"})
+	req := httptest.NewRequest(http.MethodPost, "/api/shorten", bytes.NewReader(body))
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	spans := exporter.GetSpans()
+	byName := make(map[string]tracetest.SpanStub, len(spans))
+	for _, span := range spans {
+		byName[span.Name] = span
+	}
+	require.Contains(t, byName, "POST /api/shorten")
+	require.Contains(t, byName, "URLService.Shorten")
+	require.Contains(t, byName, "URLStore.GetByOriginalURL")
+	require.Contains(t, byName, "URLStore.Save")
+
+	httpSpan := byName["POST /api/shorten"]
+	serviceSpan := byName["URLService.Shorten"]
+
+	// Трасса продолжает входящий traceparent
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", httpSpan.SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", httpSpan.Parent.SpanID().String())
+
+	// handler → service → store
+	assert.Equal(t, httpSpan.SpanContext.SpanID(), serviceSpan.Parent.SpanID())
+	assert.Equal(t, serviceSpan.SpanContext.SpanID(), byName["URLStore.GetByOriginalURL"].Parent.SpanID())
+	assert.Equal(t, serviceSpan.SpanContext.SpanID(), byName["URLStore.Save"].Parent.SpanID())
+}
+
+func TestTracing_StoreErrorMarksSpan(t *testing.T) {
+	exporter := setupTracing(t)
+
+	svc := &service.URLService{Store: NewStore(memory.NewMemoryStore(), "memory")}
+	router := chi.NewRouter()
+	router.Use(middlewares.WithTracing)
+	router.Get("/{id}", handlers.NewRedirectToOriginalURL(svc, 0))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/unknown", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	var storeSpan *tracetest.SpanStub
+	for _, span := range exporter.GetSpans() {
+		if span.Name == "URLStore.Resolve" {
+			storeSpan = &span
+		}
+	}
+	require.NotNil(t, storeSpan)
+	assert.Equal(t, "Error", storeSpan.Status.Code.String())
+	require.Len(t, storeSpan.Events, 1)
+	assert.Equal(t, "exception", storeSpan.Events[0].Name)
+}
@@ -0,0 +1,112 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/service"
+	"go.uber.org/zap"
+)
+
+// ClickCounter копит переходы по ссылкам в памяти и раз в interval одним вызовом
+// AddClicks сбрасывает их в хранилище. На пути редиректа остаётся только инкремент под мьютексом.
+// Если сброс не удался, счётчики возвращаются в буфер и уходят со следующим.
+type ClickCounter struct {
+	store    service.ClickStore
+	interval time.Duration
+	logger   *zap.SugaredLogger
+
+	mu      sync.Mutex
+	pending map[string]int64
+
+	started  atomic.Bool
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewClickCounter создаёт счётчик переходов; logger может быть nil — тогда сбои сброса никуда не пишутся.
+func NewClickCounter(store service.ClickStore, interval time.Duration, logger *zap.SugaredLogger) *ClickCounter {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	if logger == nil {
+		logger = zap.NewNop().Sugar()
+	}
+	return &ClickCounter{
+		store:    store,
+		interval: interval,
+		logger:   logger,
+		pending:  make(map[string]int64),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// RecordClick учитывает один переход по короткой ссылке.
+func (c *ClickCounter) RecordClick(shortURL string) {
+	c.mu.Lock()
+	c.pending[shortURL]++
+	c.mu.Unlock()
+}
+
+// Start запускает периодический сброс счётчиков.
+func (c *ClickCounter) Start() {
+	c.started.Store(true)
+	go func() {
+		defer close(c.done)
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				c.flushAndLog()
+			case <-c.stop:
+				c.flushAndLog()
+				return
+			}
+		}
+	}()
+}
+
+// Flush сразу сбрасывает накопленные переходы в хранилище.
+func (c *ClickCounter) Flush(ctx context.Context) error {
+	c.mu.Lock()
+	if len(c.pending) == 0 {
+		c.mu.Unlock()
+		return nil
+	}
+	counts := c.pending
+	c.pending = make(map[string]int64)
+	c.mu.Unlock()
+
+	if err := c.store.AddClicks(ctx, counts); err != nil {
+		c.mu.Lock()
+		for short, n := range counts {
+			c.pending[short] += n
+		}
+		c.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (c *ClickCounter) flushAndLog() {
+	if err := c.Flush(context.Background()); err != nil {
+		c.logger.Errorw("flush clicks failed", "error", err)
+	}
+}
+
+// Shutdown останавливает сброс по таймеру, предварительно сбросив всё накопленное.
+// Повторный вызов безопасен.
+func (c *ClickCounter) Shutdown() {
+	c.stopOnce.Do(func() { close(c.stop) })
+	if !c.started.Load() {
+		c.flushAndLog()
+		return
+	}
+	<-c.done
+}
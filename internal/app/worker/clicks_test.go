@@ -0,0 +1,56 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clickStore запоминает сброшенные счётчики и может отказать в сбросе.
+type clickStore struct {
+	mu     sync.Mutex
+	fail   bool
+	totals map[string]int64
+}
+
+func (s *clickStore) AddClicks(ctx context.Context, counts map[string]int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail {
+		return errors.New("connection reset")
+	}
+	for short, n := range counts {
+		s.totals[short] += n
+	}
+	return nil
+}
+
+func TestClickCounter_KeepsClicksWhenFlushFails(t *testing.T) {
+	store := &clickStore{fail: true, totals: map[string]int64{}}
+	counter := NewClickCounter(store, 0, nil)
+
+	counter.RecordClick("a")
+	counter.RecordClick("a")
+	counter.RecordClick("b")
+	require.Error(t, counter.Flush(context.Background()))
+
+	store.fail = false
+	counter.RecordClick("a")
+	require.NoError(t, counter.Flush(context.Background()))
+	assert.Equal(t, map[string]int64{"a": 3, "b": 1}, store.totals)
+}
+
+func TestClickCounter_ShutdownFlushes(t *testing.T) {
+	store := &clickStore{totals: map[string]int64{}}
+	counter := NewClickCounter(store, 0, nil)
+	counter.Start()
+
+	counter.RecordClick("a")
+	counter.Shutdown()
+	counter.Shutdown()
+	assert.Equal(t, map[string]int64{"a": 1}, store.totals)
+}
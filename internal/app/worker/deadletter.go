@@ -0,0 +1,74 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DeadLetter — удаление, которое не удалось выполнить за все попытки.
+type DeadLetter struct {
+	UserID    string    `json:"user_id"`
+	ShortURLs []string  `json:"short_urls"`
+	Error     string    `json:"error"`
+	Attempts  int       `json:"attempts"`
+	FailedAt  time.Time `json:"failed_at"`
+}
+
+// DeadLetterSink сохраняет окончательно неудавшиеся удаления для разбора или повторной отправки.
+type DeadLetterSink interface {
+	RecordDeadLetter(ctx context.Context, letter DeadLetter) error
+}
+
+// LogDeadLetters только пишет неудавшееся удаление в лог.
+type LogDeadLetters struct {
+	Logger *zap.SugaredLogger
+}
+
+func (l LogDeadLetters) RecordDeadLetter(ctx context.Context, letter DeadLetter) error {
+	l.Logger.Errorw("delete failed permanently",
+		"user_id", letter.UserID,
+		"short_urls", letter.ShortURLs,
+		"attempts", letter.Attempts,
+		"error", letter.Error,
+	)
+	return nil
+}
+
+// FileDeadLetters дописывает неудавшиеся удаления в JSONL-файл (по записи на строку)
+// и дублирует их в лог. Файл создаётся при первой записи.
+type FileDeadLetters struct {
+	mu   sync.Mutex
+	path string
+	log  LogDeadLetters
+}
+
+func NewFileDeadLetters(path string, logger *zap.SugaredLogger) *FileDeadLetters {
+	return &FileDeadLetters{path: path, log: LogDeadLetters{Logger: logger}}
+}
+
+func (f *FileDeadLetters) RecordDeadLetter(ctx context.Context, letter DeadLetter) error {
+	f.log.RecordDeadLetter(ctx, letter)
+
+	data, err := json.Marshal(letter)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
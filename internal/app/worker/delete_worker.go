@@ -1,76 +1,367 @@
 package worker
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/metrics"
 	"github.com/DaniYer/GoProject.git/internal/app/service"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
 )
 
+var (
+	// ErrQueueFull — очередь удаления заполнена, запрос нужно повторить позже.
+	ErrQueueFull = errors.New("delete queue is full")
+	// ErrShuttingDown — пул останавливается и новые задачи не принимает.
+	ErrShuttingDown = errors.New("delete worker is shutting down")
+)
+
+// DeleteTask — запрос пользователя на удаление набора коротких ссылок.
 type DeleteTask struct {
-	UserID string
-	Short  string
+	UserID    string
+	ShortURLs []string
+}
+
+// Config — параметры пула удаления.
+type Config struct {
+	QueueSize     int           // ёмкость очереди задач; при переполнении AddTask возвращает ErrQueueFull
+	BatchSize     int           // число ссылок, при котором пачка сбрасывается, не дожидаясь таймера
+	FlushInterval time.Duration // максимальное время ожидания ссылки в пачке
+	MaxRetries    int           // число повторов неудачного BatchDelete
+	RetryBackoff  time.Duration // пауза перед первым повтором, далее удваивается
+	JournalLease  time.Duration // срок аренды задачи из журнала; после него задачу заберёт другой экземпляр
+	JobRetention  time.Duration // сколько хранить итог выполненной задачи
+}
+
+// DefaultConfig — параметры по умолчанию.
+var DefaultConfig = Config{
+	QueueSize:     1024,
+	BatchSize:     100,
+	FlushInterval: 5 * time.Second,
+	MaxRetries:    3,
+	RetryBackoff:  200 * time.Millisecond,
+	JournalLease:  time.Minute,
+	JobRetention:  24 * time.Hour,
 }
 
+// DeleteWorkerPool — единственный конвейер асинхронного удаления ссылок.
+// Задачи копятся в пачке и сбрасываются в хранилище по размеру или по таймеру.
+// Неудачный сброс повторяется с экспоненциальной паузой, а после исчерпания
+// попыток ссылки попадают в DeadLetterSink.
+//
+// Каждая задача сохраняется в Journal до ответа клиенту, а после сброса в нём же
+// записывается итог по каждой ссылке. Невыполненные задачи (после падения этого или
+// другого экземпляра) воркер забирает из журнала при старте и на каждом тике.
 type DeleteWorkerPool struct {
-	service  *service.URLService
-	tasks    chan DeleteTask
-	wg       sync.WaitGroup
-	flushDur time.Duration
-	batch    map[string][]string
-	mu       sync.Mutex
+	store       service.URLStore
+	deadLetters DeadLetterSink
+	journal     Journal
+	cfg         Config
+	logger      *zap.SugaredLogger
+
+	tasks   chan dto.DeleteJob
+	batch   map[string][]string // принадлежит горутине воркера
+	jobs    []dto.DeleteJob     // задачи, вошедшие в batch
+	pending int                 // число ссылок в batch
+	wg      sync.WaitGroup
+
+	closeMu sync.RWMutex
+	closed  bool
+
+	queued   atomic.Int64 // принятые, но ещё не сброшенные ссылки
+	running  atomic.Bool  // горутина воркера запущена и не завершилась
+	lastBeat atomic.Int64 // время последней итерации цикла воркера (UnixNano)
+
+	sleep func(time.Duration) // пауза между повторами, подменяется в тестах
 }
 
-func NewDeleteWorkerPool(service *service.URLService, bufferSize int, flushDur time.Duration) *DeleteWorkerPool {
+// NewDeleteWorkerPool создаёт пул удаления поверх хранилища.
+// Нулевые поля cfg заменяются значениями из DefaultConfig; deadLetters может быть nil —
+// тогда неудачные удаления только пишутся в лог. journal может быть nil — тогда
+// задачи отслеживаются в памяти (NewMemoryJournal) и не переживают рестарт.
+// logger может быть nil — тогда сбои воркера никуда не пишутся.
+func NewDeleteWorkerPool(store service.URLStore, cfg Config, deadLetters DeadLetterSink, journal Journal, logger *zap.SugaredLogger) *DeleteWorkerPool {
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = DefaultConfig.QueueSize
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultConfig.BatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultConfig.FlushInterval
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = DefaultConfig.RetryBackoff
+	}
+	if cfg.JournalLease <= 0 {
+		cfg.JournalLease = DefaultConfig.JournalLease
+	}
+	if cfg.JobRetention <= 0 {
+		cfg.JobRetention = DefaultConfig.JobRetention
+	}
+	if logger == nil {
+		logger = zap.NewNop().Sugar()
+	}
+	if deadLetters == nil {
+		deadLetters = LogDeadLetters{Logger: logger}
+	}
+	if journal == nil {
+		journal = NewMemoryJournal()
+	}
+
 	return &DeleteWorkerPool{
-		service:  service,
-		tasks:    make(chan DeleteTask, bufferSize),
-		flushDur: flushDur,
-		batch:    make(map[string][]string),
+		store:       store,
+		deadLetters: deadLetters,
+		journal:     journal,
+		cfg:         cfg,
+		logger:      logger,
+		tasks:       make(chan dto.DeleteJob, cfg.QueueSize),
+		batch:       make(map[string][]string),
+		sleep:       time.Sleep,
 	}
 }
 
 func (p *DeleteWorkerPool) Start() {
 	p.wg.Add(1)
+	p.running.Store(true)
+	p.lastBeat.Store(time.Now().UnixNano())
 	go p.worker()
 }
 
-func (p *DeleteWorkerPool) AddTask(task DeleteTask) {
-	p.tasks <- task
+// AddTask надёжно записывает задачу в журнал и ставит её в очередь, не блокируя вызывающего.
+// Возвращает принятую задачу: по её ID клиент узнаёт итог через Job.
+// Если очередь заполнена, возвращает ErrQueueFull; после Shutdown — ErrShuttingDown.
+func (p *DeleteWorkerPool) AddTask(ctx context.Context, task DeleteTask) (dto.DeleteJob, error) {
+	p.closeMu.RLock()
+	defer p.closeMu.RUnlock()
+
+	if p.closed {
+		return dto.DeleteJob{}, ErrShuttingDown
+	}
+	if len(p.tasks) >= cap(p.tasks) {
+		metrics.DeleteRejected.Inc()
+		return dto.DeleteJob{}, ErrQueueFull
+	}
+
+	job := dto.DeleteJob{
+		ID:        uuid.NewString(),
+		UserID:    task.UserID,
+		ShortURLs: task.ShortURLs,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := p.journal.AppendDeleteJob(ctx, job, time.Now().Add(p.cfg.JournalLease)); err != nil {
+		return dto.DeleteJob{}, fmt.Errorf("journal delete job: %w", err)
+	}
+
+	// Счётчик увеличивается до отправки, чтобы воркер не успел вычесть ссылки раньше
+	p.queued.Add(int64(len(job.ShortURLs)))
+	select {
+	case p.tasks <- job:
+	default:
+		// Очередь успела заполниться после проверки. Задача уже в журнале,
+		// её заберут из него, когда истечёт аренда
+		p.queued.Add(-int64(len(job.ShortURLs)))
+	}
+	return job, nil
 }
 
+// Job возвращает состояние задачи удаления пользователя.
+// Если задачи нет или она чужая, возвращает service.ErrDeleteJobNotFound.
+func (p *DeleteWorkerPool) Job(ctx context.Context, userID, jobID string) (dto.DeleteJobStatus, error) {
+	return p.journal.GetDeleteJob(ctx, userID, jobID)
+}
+
+// QueueDepth возвращает число удалений, которые приняты, но ещё не сброшены в хранилище.
+func (p *DeleteWorkerPool) QueueDepth() int {
+	return int(p.queued.Load())
+}
+
+// Ping сообщает, жив ли воркер: горутина должна работать и проходить цикл хотя бы
+// раз в несколько интервалов сброса. Иначе воркер завершился или завис на удалении.
+func (p *DeleteWorkerPool) Ping(ctx context.Context) error {
+	if !p.running.Load() {
+		return errors.New("delete worker is not running")
+	}
+	if since := time.Since(time.Unix(0, p.lastBeat.Load())); since > 3*p.cfg.FlushInterval {
+		return fmt.Errorf("delete worker stalled for %s", since.Round(time.Second))
+	}
+	return nil
+}
+
+// Shutdown перестаёт принимать задачи, сбрасывает всё накопленное и дожидается воркера.
+// Повторный вызов ничего не делает.
 func (p *DeleteWorkerPool) Shutdown() {
+	p.closeMu.Lock()
+	if p.closed {
+		p.closeMu.Unlock()
+		return
+	}
+	p.closed = true
 	close(p.tasks)
+	p.closeMu.Unlock()
+
 	p.wg.Wait()
 }
 
 func (p *DeleteWorkerPool) worker() {
 	defer p.wg.Done()
-	ticker := time.NewTicker(p.flushDur)
+	defer p.running.Store(false)
+
+	ticker := time.NewTicker(p.cfg.FlushInterval)
 	defer ticker.Stop()
 
+	// Задачи, не выполненные до прошлой остановки
+	p.recover()
+
 	for {
+		p.lastBeat.Store(time.Now().UnixNano())
 		select {
-		case task, ok := <-p.tasks:
+		case job, ok := <-p.tasks:
 			if !ok {
 				p.flush()
 				return
 			}
-			p.mu.Lock()
-			p.batch[task.UserID] = append(p.batch[task.UserID], task.Short)
-			p.mu.Unlock()
+			p.add(job)
 		case <-ticker.C:
+			p.recover()
 			p.flush()
+			p.purge()
 		}
 	}
 }
 
-func (p *DeleteWorkerPool) flush() {
-	p.mu.Lock()
-	defer p.mu.Unlock()
+// add кладёт задачу в пачку и сбрасывает её, если набрался BatchSize.
+func (p *DeleteWorkerPool) add(job dto.DeleteJob) {
+	p.batch[job.UserID] = append(p.batch[job.UserID], job.ShortURLs...)
+	p.pending += len(job.ShortURLs)
+	p.jobs = append(p.jobs, job)
+	if p.pending >= p.cfg.BatchSize {
+		p.flush()
+	}
+}
+
+// recover забирает из журнала задачи с истёкшей арендой: принятые до падения
+// этого или другого экземпляра.
+func (p *DeleteWorkerPool) recover() {
+	now := time.Now()
+	jobs, err := p.journal.ClaimDeleteJobs(context.Background(), now, now.Add(p.cfg.JournalLease), p.cfg.BatchSize)
+	if err != nil {
+		p.logger.Errorw("claim delete jobs failed", "error", err)
+		return
+	}
+	for _, job := range jobs {
+		p.queued.Add(int64(len(job.ShortURLs)))
+		p.add(job)
+	}
+}
 
-	for userID, urls := range p.batch {
-		_ = p.service.Store.BatchDelete(userID, urls)
+// purge удаляет из журнала итоги задач старше JobRetention.
+func (p *DeleteWorkerPool) purge() {
+	if err := p.journal.PurgeDeleteJobs(context.Background(), time.Now().Add(-p.cfg.JobRetention)); err != nil {
+		p.logger.Errorw("purge delete jobs failed", "error", err)
 	}
+}
+
+// flush сбрасывает накопленную пачку группами по пользователю
+// и записывает в журнал итог каждой вошедшей в неё задачи.
+func (p *DeleteWorkerPool) flush() {
+	if len(p.jobs) == 0 {
+		return
+	}
+	start := time.Now()
+	defer func() {
+		metrics.DeleteFlushDuration.Observe(time.Since(start).Seconds())
+	}()
+
+	batch, pending, jobs := p.batch, p.pending, p.jobs
 	p.batch = make(map[string][]string)
+	p.pending = 0
+	p.jobs = nil
+
+	outcome := make(map[string]map[string]string, len(batch))
+	for userID, urls := range batch {
+		outcome[userID] = p.deleteWithRetry(userID, urls)
+	}
+	p.queued.Add(-int64(pending))
+
+	completedAt := time.Now().UTC()
+	for _, job := range jobs {
+		status := dto.DeleteJobStatus{
+			ID:          job.ID,
+			Status:      service.DeleteJobDone,
+			CreatedAt:   job.CreatedAt,
+			CompletedAt: &completedAt,
+			Results:     make([]dto.DeleteResult, 0, len(job.ShortURLs)),
+		}
+		for _, shortURL := range job.ShortURLs {
+			result := outcome[job.UserID][shortURL]
+			if result != service.DeleteResultDeleted {
+				status.Status = service.DeleteJobPartiallyFailed
+			}
+			status.Results = append(status.Results, dto.DeleteResult{ShortURL: shortURL, Status: result})
+		}
+
+		// Незавершённая задача будет выполнена повторно после истечения аренды —
+		// удаление идемпотентно, так что это безопасно
+		if err := p.journal.CompleteDeleteJob(context.Background(), status); err != nil {
+			p.logger.Errorw("complete delete job failed", "job_id", job.ID, "error", err)
+		}
+	}
+}
+
+// deleteWithRetry вызывает BatchDelete, повторяя его до MaxRetries раз с удвоением паузы,
+// и возвращает итог по каждой ссылке. Если все попытки неудачны, ссылки записываются
+// в dead letter и получают итог failed.
+func (p *DeleteWorkerPool) deleteWithRetry(userID string, urls []string) map[string]string {
+	outcome := make(map[string]string, len(urls))
+	if len(urls) == 0 {
+		return outcome
+	}
+
+	backoff := p.cfg.RetryBackoff
+	attempts := 0
+
+	var err error
+	for {
+		attempts++
+		var results []dto.DeleteResult
+		if results, err = p.store.BatchDelete(context.Background(), userID, urls); err == nil {
+			for _, result := range results {
+				outcome[result.ShortURL] = result.Status
+			}
+			return outcome
+		}
+		if attempts > p.cfg.MaxRetries {
+			break
+		}
+		metrics.DeleteRetries.Inc()
+		p.sleep(backoff)
+		backoff *= 2
+	}
+
+	metrics.DeleteDeadLetters.Inc()
+	letter := DeadLetter{
+		UserID:    userID,
+		ShortURLs: urls,
+		Error:     err.Error(),
+		Attempts:  attempts,
+		FailedAt:  time.Now().UTC(),
+	}
+	if err := p.deadLetters.RecordDeadLetter(context.Background(), letter); err != nil {
+		p.logger.Errorw("dead letter write failed",
+			"user_id", userID, "short_urls", urls, "error", err)
+	}
+	for _, shortURL := range urls {
+		outcome[shortURL] = service.DeleteResultFailed
+	}
+	return outcome
 }
@@ -0,0 +1,206 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/service"
+	"github.com/DaniYer/GoProject.git/internal/app/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyStore — хранилище, у которого BatchDelete падает заданное число раз подряд.
+type flakyStore struct {
+	*memory.MemoryStore
+
+	mu       sync.Mutex
+	failures int
+	calls    [][]string
+}
+
+func (s *flakyStore) BatchDelete(ctx context.Context, userID string, shortURLs []string) ([]dto.DeleteResult, error) {
+	s.mu.Lock()
+	s.calls = append(s.calls, shortURLs)
+	if s.failures > 0 {
+		s.failures--
+		s.mu.Unlock()
+		return nil, errors.New("connection reset")
+	}
+	s.mu.Unlock()
+	return s.MemoryStore.BatchDelete(ctx, userID, shortURLs)
+}
+
+func (s *flakyStore) callCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.calls)
+}
+
+// memoryDeadLetters запоминает dead letters для проверки в тестах.
+type memoryDeadLetters struct {
+	mu      sync.Mutex
+	letters []DeadLetter
+}
+
+func (m *memoryDeadLetters) RecordDeadLetter(ctx context.Context, letter DeadLetter) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.letters = append(m.letters, letter)
+	return nil
+}
+
+func newTestStore(t *testing.T, failures int, shortURLs ...string) *flakyStore {
+	t.Helper()
+	store := &flakyStore{MemoryStore: memory.NewMemoryStore(), failures: failures}
+	for i, shortURL := range shortURLs {
+		_, err := store.Save(context.Background(), shortURL, "http://example.com/"+string(rune('a'+i)), "user")
+		require.NoError(t, err)
+	}
+	return store
+}
+
+func TestDeleteWorker_FlushesBySize(t *testing.T) {
+	store := newTestStore(t, 0, "a", "b")
+	pool := NewDeleteWorkerPool(store, Config{BatchSize: 2, FlushInterval: time.Hour}, nil, nil, nil)
+	pool.Start()
+	defer pool.Shutdown()
+
+	_, err := pool.AddTask(context.Background(), DeleteTask{UserID: "user", ShortURLs: []string{"a", "b"}})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool { return store.callCount() == 1 }, time.Second, 5*time.Millisecond)
+	assert.Eventually(t, func() bool { return pool.QueueDepth() == 0 }, time.Second, 5*time.Millisecond)
+	_, err = store.Get(context.Background(), "a")
+	assert.EqualError(t, err, "gone")
+}
+
+func TestDeleteWorker_FlushesByTimer(t *testing.T) {
+	store := newTestStore(t, 0, "a")
+	pool := NewDeleteWorkerPool(store, Config{BatchSize: 100, FlushInterval: 20 * time.Millisecond}, nil, nil, nil)
+	pool.Start()
+	defer pool.Shutdown()
+
+	_, err := pool.AddTask(context.Background(), DeleteTask{UserID: "user", ShortURLs: []string{"a"}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, pool.QueueDepth())
+
+	assert.Eventually(t, func() bool { return store.callCount() == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestDeleteWorker_RetriesWithBackoff(t *testing.T) {
+	store := newTestStore(t, 2, "a")
+	deadLetters := &memoryDeadLetters{}
+	pool := NewDeleteWorkerPool(store, Config{BatchSize: 1, MaxRetries: 3, RetryBackoff: 10 * time.Millisecond}, deadLetters, nil, nil)
+
+	var pauses []time.Duration
+	pool.sleep = func(d time.Duration) { pauses = append(pauses, d) }
+	pool.Start()
+
+	_, err := pool.AddTask(context.Background(), DeleteTask{UserID: "user", ShortURLs: []string{"a"}})
+	require.NoError(t, err)
+	pool.Shutdown()
+
+	assert.Equal(t, 3, store.callCount())
+	assert.Equal(t, []time.Duration{10 * time.Millisecond, 20 * time.Millisecond}, pauses)
+	assert.Empty(t, deadLetters.letters)
+}
+
+func TestDeleteWorker_DeadLettersAfterRetries(t *testing.T) {
+	store := newTestStore(t, 100, "a")
+	deadLetters := &memoryDeadLetters{}
+	pool := NewDeleteWorkerPool(store, Config{BatchSize: 1, MaxRetries: 2}, deadLetters, nil, nil)
+	pool.sleep = func(time.Duration) {}
+	pool.Start()
+
+	_, err := pool.AddTask(context.Background(), DeleteTask{UserID: "user", ShortURLs: []string{"a"}})
+	require.NoError(t, err)
+	pool.Shutdown()
+
+	assert.Equal(t, 3, store.callCount())
+	require.Len(t, deadLetters.letters, 1)
+	letter := deadLetters.letters[0]
+	assert.Equal(t, "user", letter.UserID)
+	assert.Equal(t, []string{"a"}, letter.ShortURLs)
+	assert.Equal(t, 3, letter.Attempts)
+	assert.Equal(t, "connection reset", letter.Error)
+}
+
+func TestDeleteWorker_QueueFullDoesNotBlock(t *testing.T) {
+	store := newTestStore(t, 0)
+	// Воркер не запущен — очередь никто не разбирает
+	pool := NewDeleteWorkerPool(store, Config{QueueSize: 1}, nil, nil, nil)
+
+	_, err := pool.AddTask(context.Background(), DeleteTask{UserID: "user", ShortURLs: []string{"a"}})
+	require.NoError(t, err)
+	_, err = pool.AddTask(context.Background(), DeleteTask{UserID: "user", ShortURLs: []string{"b"}})
+	assert.ErrorIs(t, err, ErrQueueFull)
+	assert.Equal(t, 1, pool.QueueDepth())
+}
+
+func TestDeleteWorker_ShutdownFlushesAndRejects(t *testing.T) {
+	store := newTestStore(t, 0, "a")
+	pool := NewDeleteWorkerPool(store, Config{BatchSize: 100, FlushInterval: time.Hour}, nil, nil, nil)
+	pool.Start()
+
+	_, err := pool.AddTask(context.Background(), DeleteTask{UserID: "user", ShortURLs: []string{"a"}})
+	require.NoError(t, err)
+	pool.Shutdown()
+
+	assert.Equal(t, 1, store.callCount())
+	_, err = pool.AddTask(context.Background(), DeleteTask{UserID: "user", ShortURLs: []string{"a"}})
+	assert.ErrorIs(t, err, ErrShuttingDown)
+	pool.Shutdown()
+}
+
+func TestDeleteWorker_JobReportsPerURLResults(t *testing.T) {
+	store := newTestStore(t, 0, "a")
+	_, err := store.Save(context.Background(), "foreign", "http://example.com/foreign", "other")
+	require.NoError(t, err)
+
+	pool := NewDeleteWorkerPool(store, Config{BatchSize: 100, FlushInterval: time.Hour}, nil, nil, nil)
+	pool.Start()
+
+	job, err := pool.AddTask(context.Background(), DeleteTask{UserID: "user", ShortURLs: []string{"a", "foreign", "missing"}})
+	require.NoError(t, err)
+
+	status, err := pool.Job(context.Background(), "user", job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, service.DeleteJobPending, status.Status)
+
+	pool.Shutdown()
+
+	status, err = pool.Job(context.Background(), "user", job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, service.DeleteJobPartiallyFailed, status.Status)
+	assert.NotNil(t, status.CompletedAt)
+	assert.Equal(t, []dto.DeleteResult{
+		{ShortURL: "a", Status: service.DeleteResultDeleted},
+		{ShortURL: "foreign", Status: service.DeleteResultNotOwned},
+		{ShortURL: "missing", Status: service.DeleteResultNotFound},
+	}, status.Results)
+
+	// Чужая задача не видна
+	_, err = pool.Job(context.Background(), "other", job.ID)
+	assert.ErrorIs(t, err, service.ErrDeleteJobNotFound)
+}
+
+func TestDeleteWorker_DeadLetteredURLsReportFailed(t *testing.T) {
+	store := newTestStore(t, 100, "a")
+	pool := NewDeleteWorkerPool(store, Config{BatchSize: 1, MaxRetries: 1}, &memoryDeadLetters{}, nil, nil)
+	pool.sleep = func(time.Duration) {}
+	pool.Start()
+
+	job, err := pool.AddTask(context.Background(), DeleteTask{UserID: "user", ShortURLs: []string{"a"}})
+	require.NoError(t, err)
+	pool.Shutdown()
+
+	status, err := pool.Job(context.Background(), "user", job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, service.DeleteJobPartiallyFailed, status.Status)
+	assert.Equal(t, []dto.DeleteResult{{ShortURL: "a", Status: service.DeleteResultFailed}}, status.Results)
+}
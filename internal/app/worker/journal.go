@@ -0,0 +1,280 @@
+package worker
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/service"
+)
+
+// Journal — надёжное хранилище принятых задач удаления. Задача записывается в журнал
+// до ответа клиенту и помечается выполненной только после сброса, поэтому падение
+// процесса между приёмом и сбросом пачки не теряет удаления. Выполненные задачи
+// хранятся с итогом по каждой ссылке, пока их не удалит PurgeDeleteJobs.
+//
+// Задача, взятая в работу, закреплена за экземпляром до истечения аренды. Если экземпляр
+// упал, аренда истекает и задачу забирает любой другой (или этот же после рестарта).
+type Journal interface {
+	AppendDeleteJob(ctx context.Context, job dto.DeleteJob, leaseUntil time.Time) error
+	ClaimDeleteJobs(ctx context.Context, now, leaseUntil time.Time, limit int) ([]dto.DeleteJob, error)
+	CompleteDeleteJob(ctx context.Context, status dto.DeleteJobStatus) error
+	GetDeleteJob(ctx context.Context, userID, jobID string) (dto.DeleteJobStatus, error)
+	PurgeDeleteJobs(ctx context.Context, completedBefore time.Time) error
+}
+
+// journalRecord — строка файла журнала: принятая задача или итог её выполнения.
+type journalRecord struct {
+	Op     string               `json:"op"`
+	Job    *dto.DeleteJob       `json:"job,omitempty"`
+	Status *dto.DeleteJobStatus `json:"status,omitempty"`
+}
+
+const (
+	journalOpAdd      = "add"
+	journalOpComplete = "complete"
+)
+
+// journalEntry — задача в памяти FileJournal.
+type journalEntry struct {
+	job        dto.DeleteJob
+	seq        int
+	leaseUntil time.Time
+	status     *dto.DeleteJobStatus // nil, пока задача не выполнена
+}
+
+// FileJournal — append-only журнал удалений в JSONL-файле для файлового и in-memory хранилищ.
+// Каждая запись синхронизируется на диск до возврата из AppendDeleteJob.
+// При открытии и после очистки устаревших задач файл переписывается целиком.
+type FileJournal struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File // nil у журнала, созданного NewMemoryJournal
+	entries map[string]*journalEntry
+	seq     int
+}
+
+// OpenFileJournal открывает (или создаёт) журнал и восстанавливает задачи.
+func OpenFileJournal(path string) (*FileJournal, error) {
+	j := NewMemoryJournal()
+	j.path = path
+	if err := j.load(); err != nil {
+		return nil, err
+	}
+	file, err := j.compact()
+	if err != nil {
+		return nil, err
+	}
+	j.file = file
+	return j, nil
+}
+
+// NewMemoryJournal создаёт журнал без файла: задачи отслеживаются, но не переживают рестарт.
+func NewMemoryJournal() *FileJournal {
+	return &FileJournal{entries: make(map[string]*journalEntry)}
+}
+
+func (j *FileJournal) load() error {
+	file, err := os.Open(j.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var rec journalRecord
+		// Недописанная последняя строка после падения пропускается
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+		switch {
+		case rec.Op == journalOpAdd && rec.Job != nil:
+			j.seq++
+			j.entries[rec.Job.ID] = &journalEntry{job: *rec.Job, seq: j.seq}
+		case rec.Op == journalOpComplete && rec.Status != nil:
+			if entry, ok := j.entries[rec.Status.ID]; ok {
+				entry.status = rec.Status
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// compact переписывает файл, оставляя только известные задачи и их итоги, и возвращает
+// новый файл, открытый на дозапись. Файл собирается во временном и подменяет журнал
+// переименованием, поэтому при ошибке прежний файл остаётся целым и открытым.
+func (j *FileJournal) compact() (*os.File, error) {
+	tmpPath := j.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	fail := func(err error) (*os.File, error) {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return nil, err
+	}
+
+	writer := bufio.NewWriter(tmp)
+	for _, entry := range j.sorted() {
+		job := entry.job
+		records := []journalRecord{{Op: journalOpAdd, Job: &job}}
+		if entry.status != nil {
+			records = append(records, journalRecord{Op: journalOpComplete, Status: entry.status})
+		}
+		for _, rec := range records {
+			data, err := json.Marshal(rec)
+			if err != nil {
+				return fail(err)
+			}
+			writer.Write(append(data, '\n'))
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return fail(err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fail(err)
+	}
+	// Дескриптор остаётся за файлом и после переименования: дозапись идёт уже в журнал
+	if err := os.Rename(tmpPath, j.path); err != nil {
+		return fail(err)
+	}
+	return tmp, nil
+}
+
+// sorted возвращает задачи в порядке приёма.
+func (j *FileJournal) sorted() []*journalEntry {
+	entries := make([]*journalEntry, 0, len(j.entries))
+	for _, entry := range j.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(a, b int) bool { return entries[a].seq < entries[b].seq })
+	return entries
+}
+
+// write дописывает запись и синхронизирует файл.
+func (j *FileJournal) write(rec journalRecord) error {
+	if j.file == nil {
+		return nil
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if _, err := j.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return j.file.Sync()
+}
+
+func (j *FileJournal) AppendDeleteJob(ctx context.Context, job dto.DeleteJob, leaseUntil time.Time) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := j.write(journalRecord{Op: journalOpAdd, Job: &job}); err != nil {
+		return err
+	}
+	j.seq++
+	j.entries[job.ID] = &journalEntry{job: job, seq: j.seq, leaseUntil: leaseUntil}
+	return nil
+}
+
+func (j *FileJournal) ClaimDeleteJobs(ctx context.Context, now, leaseUntil time.Time, limit int) ([]dto.DeleteJob, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var jobs []dto.DeleteJob
+	for _, entry := range j.sorted() {
+		if len(jobs) >= limit {
+			break
+		}
+		if entry.status == nil && entry.leaseUntil.Before(now) {
+			entry.leaseUntil = leaseUntil
+			jobs = append(jobs, entry.job)
+		}
+	}
+	return jobs, nil
+}
+
+func (j *FileJournal) CompleteDeleteJob(ctx context.Context, status dto.DeleteJobStatus) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entry, ok := j.entries[status.ID]
+	if !ok {
+		return service.ErrDeleteJobNotFound
+	}
+	if err := j.write(journalRecord{Op: journalOpComplete, Status: &status}); err != nil {
+		return err
+	}
+	entry.status = &status
+	return nil
+}
+
+func (j *FileJournal) GetDeleteJob(ctx context.Context, userID, jobID string) (dto.DeleteJobStatus, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entry, ok := j.entries[jobID]
+	if !ok || entry.job.UserID != userID {
+		return dto.DeleteJobStatus{}, service.ErrDeleteJobNotFound
+	}
+	if entry.status != nil {
+		return *entry.status, nil
+	}
+	return dto.DeleteJobStatus{
+		ID:        entry.job.ID,
+		Status:    service.DeleteJobPending,
+		CreatedAt: entry.job.CreatedAt,
+	}, nil
+}
+
+// PurgeDeleteJobs удаляет выполненные задачи, завершённые раньше completedBefore,
+// и, если что-то удалено, переписывает файл журнала.
+func (j *FileJournal) PurgeDeleteJobs(ctx context.Context, completedBefore time.Time) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	purged := 0
+	for id, entry := range j.entries {
+		if entry.status != nil && entry.status.CompletedAt != nil && entry.status.CompletedAt.Before(completedBefore) {
+			delete(j.entries, id)
+			purged++
+		}
+	}
+	if purged == 0 || j.file == nil {
+		return nil
+	}
+
+	// Старый файл закрывается только после подмены: если переписать журнал не вышло,
+	// запись продолжается в него, а очищенные задачи останутся в файле до следующей перезаписи
+	file, err := j.compact()
+	if err != nil {
+		return err
+	}
+	old := j.file
+	j.file = file
+	return old.Close()
+}
+
+// Close закрывает файл журнала.
+func (j *FileJournal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.file == nil {
+		return nil
+	}
+	return j.file.Close()
+}
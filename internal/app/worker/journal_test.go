@@ -0,0 +1,130 @@
+package worker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DaniYer/GoProject.git/internal/app/dto"
+	"github.com/DaniYer/GoProject.git/internal/app/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileJournal_ReplaysAfterCrash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "delete_journal.jsonl")
+	store := newTestStore(t, 0, "a", "b")
+
+	// Задача принята, но воркер так и не запустился — процесс «упал»
+	journal, err := OpenFileJournal(path)
+	require.NoError(t, err)
+	crashed := NewDeleteWorkerPool(store, Config{}, nil, journal, nil)
+	job, err := crashed.AddTask(context.Background(), DeleteTask{UserID: "user", ShortURLs: []string{"a", "b"}})
+	require.NoError(t, err)
+	require.NoError(t, journal.Close())
+
+	journal, err = OpenFileJournal(path)
+	require.NoError(t, err)
+	defer journal.Close()
+	pool := NewDeleteWorkerPool(store, Config{BatchSize: 100, FlushInterval: time.Hour}, nil, journal, nil)
+	pool.Start()
+	pool.Shutdown()
+
+	_, err = store.Get(context.Background(), "a")
+	assert.EqualError(t, err, "gone")
+	_, err = store.Get(context.Background(), "b")
+	assert.EqualError(t, err, "gone")
+
+	// Итог задачи записан в журнал и переживает ещё один рестарт
+	require.NoError(t, journal.Close())
+	journal, err = OpenFileJournal(path)
+	require.NoError(t, err)
+	status, err := journal.GetDeleteJob(context.Background(), "user", job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, service.DeleteJobDone, status.Status)
+	assert.Equal(t, []dto.DeleteResult{
+		{ShortURL: "a", Status: service.DeleteResultDeleted},
+		{ShortURL: "b", Status: service.DeleteResultDeleted},
+	}, status.Results)
+
+	jobs, err := journal.ClaimDeleteJobs(context.Background(), time.Now(), time.Now().Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.Empty(t, jobs)
+}
+
+func TestFileJournal_PurgeRewritesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "delete_journal.jsonl")
+	journal, err := OpenFileJournal(path)
+	require.NoError(t, err)
+	defer journal.Close()
+
+	ctx := context.Background()
+	completedAt := time.Now().Add(-48 * time.Hour)
+	require.NoError(t, journal.AppendDeleteJob(ctx, dto.DeleteJob{ID: "1", UserID: "user"}, time.Now()))
+	require.NoError(t, journal.CompleteDeleteJob(ctx, dto.DeleteJobStatus{ID: "1", Status: service.DeleteJobDone, CompletedAt: &completedAt}))
+
+	require.NoError(t, journal.PurgeDeleteJobs(ctx, time.Now().Add(-24*time.Hour)))
+
+	_, err = journal.GetDeleteJob(ctx, "user", "1")
+	assert.ErrorIs(t, err, service.ErrDeleteJobNotFound)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Zero(t, info.Size())
+}
+
+func TestFileJournal_PurgeKeepsFileWhenCompactionFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "delete_journal.jsonl")
+	journal, err := OpenFileJournal(path)
+	require.NoError(t, err)
+	defer journal.Close()
+
+	ctx := context.Background()
+	completedAt := time.Now().Add(-48 * time.Hour)
+	require.NoError(t, journal.AppendDeleteJob(ctx, dto.DeleteJob{ID: "1", UserID: "user"}, time.Now()))
+	require.NoError(t, journal.CompleteDeleteJob(ctx, dto.DeleteJobStatus{ID: "1", Status: service.DeleteJobDone, CompletedAt: &completedAt}))
+
+	// Каталог на месте временного файла не даёт переписать журнал
+	require.NoError(t, os.Mkdir(path+".tmp", 0755))
+	assert.Error(t, journal.PurgeDeleteJobs(ctx, time.Now().Add(-24*time.Hour)))
+
+	// Журнал по-прежнему принимает задачи, и они переживают рестарт
+	require.NoError(t, journal.AppendDeleteJob(ctx, dto.DeleteJob{ID: "2", UserID: "user"}, time.Now()))
+	require.NoError(t, journal.Close())
+	require.NoError(t, os.Remove(path+".tmp"))
+
+	journal, err = OpenFileJournal(path)
+	require.NoError(t, err)
+	defer journal.Close()
+	status, err := journal.GetDeleteJob(ctx, "user", "2")
+	require.NoError(t, err)
+	assert.Equal(t, service.DeleteJobPending, status.Status)
+}
+
+func TestFileJournal_LeasePreventsDoubleClaim(t *testing.T) {
+	journal, err := OpenFileJournal(filepath.Join(t.TempDir(), "delete_journal.jsonl"))
+	require.NoError(t, err)
+	defer journal.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+	require.NoError(t, journal.AppendDeleteJob(ctx, dto.DeleteJob{ID: "1", UserID: "user", ShortURLs: []string{"a"}}, now.Add(-time.Second)))
+	require.NoError(t, journal.AppendDeleteJob(ctx, dto.DeleteJob{ID: "2", UserID: "user", ShortURLs: []string{"b"}}, now.Add(time.Minute)))
+
+	// Задача 2 ещё в аренде у принявшего её воркера
+	jobs, err := journal.ClaimDeleteJobs(ctx, now, now.Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "1", jobs[0].ID)
+
+	jobs, err = journal.ClaimDeleteJobs(ctx, now, now.Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.Empty(t, jobs)
+
+	// После истечения аренды обе задачи снова доступны
+	later := now.Add(2 * time.Minute)
+	jobs, err = journal.ClaimDeleteJobs(ctx, later, later.Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.Len(t, jobs, 2)
+}